@@ -0,0 +1,200 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/filterexpr"
+	"github.com/bhojpur/text/pkg/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+// adminCmd represents the admin command
+var adminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Commands for operators of a Bhojpur Text installation",
+}
+
+var adminRetentionCmdOpts outputOpts
+
+// retentionCandidateColumns are the columns of the retention report table
+var retentionCandidateColumns = []output.Column{
+	{Header: "NAME", Value: retentionCandidateColumn(func(c *v1.RetentionCandidate) string { return c.Engine.GetName() })},
+	{Header: "SPEC", Value: retentionCandidateColumn(func(c *v1.RetentionCandidate) string { return c.Engine.GetMetadata().GetEngineSpecName() })},
+	{Header: "OWNER", Value: retentionCandidateColumn(func(c *v1.RetentionCandidate) string { return c.Engine.GetMetadata().GetOwner() })},
+	{Header: "FINISHED", Value: retentionCandidateColumn(func(c *v1.RetentionCandidate) string {
+		return formatTimestamp(c.Engine.GetMetadata().GetFinished())
+	})},
+	{Header: "REASON", Value: retentionCandidateColumn(func(c *v1.RetentionCandidate) string { return c.Reason })},
+}
+
+func retentionCandidateColumn(f func(*v1.RetentionCandidate) string) func(proto.Message) string {
+	return func(m proto.Message) string {
+		return f(m.(*v1.RetentionCandidate))
+	}
+}
+
+var adminRetentionCmd = &cobra.Command{
+	Use:   "retention",
+	Short: "Lists the engines the retention policy would remove if it ran now",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := output.ParseFormat(adminRetentionCmdOpts.Output)
+		if err != nil {
+			return err
+		}
+		printer, err := output.NewPrinter(f, adminRetentionCmdOpts.NoHeaders, retentionCandidateColumns, func(m proto.Message) string {
+			return m.(*v1.RetentionCandidate).Engine.GetName()
+		})
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		conn := dial()
+		defer conn.Close()
+		client := v1.NewTextAdminClient(conn)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		resp, err := client.GetRetentionReport(ctx, &v1.GetRetentionReportRequest{})
+		if err != nil {
+			return err
+		}
+
+		if adminRetentionCmdOpts.isDefault() {
+			fmt.Fprintf(os.Stderr, "retention policy: %s\n", resp.Policy)
+			if len(resp.Candidates) == 0 {
+				fmt.Fprintln(os.Stderr, "no engines would be removed")
+				return nil
+			}
+		}
+		msgs := make([]proto.Message, len(resp.Candidates))
+		for i, c := range resp.Candidates {
+			msgs[i] = c
+		}
+		return printer.PrintList(os.Stdout, msgs)
+	},
+}
+
+var adminAuditCmdOpts struct {
+	outputOpts
+	Filter []string
+	Start  int32
+	Limit  int32
+}
+
+// auditEventColumns are the columns of the audit log table
+var auditEventColumns = []output.Column{
+	{Header: "TIME", Value: auditEventColumn(func(e *v1.AuditEvent) string { return formatTimestamp(e.Time) })},
+	{Header: "IDENTITY", Value: auditEventColumn(func(e *v1.AuditEvent) string { return e.Identity })},
+	{Header: "METHOD", Value: auditEventColumn(func(e *v1.AuditEvent) string { return path.Base(e.Method) })},
+	{Header: "ENGINE", Value: auditEventColumn(func(e *v1.AuditEvent) string { return e.Engine })},
+	{Header: "CODE", Value: auditEventColumn(func(e *v1.AuditEvent) string { return e.Code })},
+	{Header: "DIGEST", Wide: true, Value: auditEventColumn(func(e *v1.AuditEvent) string { return e.RequestDigest })},
+	{Header: "MESSAGE", Wide: true, Value: auditEventColumn(func(e *v1.AuditEvent) string { return e.Message })},
+}
+
+func auditEventColumn(f func(*v1.AuditEvent) string) func(proto.Message) string {
+	return func(m proto.Message) string {
+		return f(m.(*v1.AuditEvent))
+	}
+}
+
+var adminAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Lists the audit log of calls which started or stopped engines, newest first",
+	Long: `Lists the audit log of calls which started or stopped engines, newest first, e.g.
+
+  text admin audit --filter identity==alice --filter code!=OK
+  text admin audit --filter engine==my-engine -o wide
+  text admin audit --filter time>=2022-03-01T00:00:00Z --filter time<2022-03-02T00:00:00Z
+
+Supported filter fields are time, identity, method, engine, digest and code.
+The time only supports >= and < with RFC3339 values.
+Methods are recorded by their full name, e.g. /v1.TextService/StopEngine,
+hence filter them with method=|StopEngine. See "text list" for the filter syntax.`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := filterexpr.Parse(adminAuditCmdOpts.Filter)
+		if err != nil {
+			return err
+		}
+		f, err := output.ParseFormat(adminAuditCmdOpts.Output)
+		if err != nil {
+			return err
+		}
+		printer, err := output.NewPrinter(f, adminAuditCmdOpts.NoHeaders, auditEventColumns, func(m proto.Message) string {
+			return strconv.FormatInt(m.(*v1.AuditEvent).Id, 10)
+		})
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		conn := dial()
+		defer conn.Close()
+		client := v1.NewTextAdminClient(conn)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		resp, err := client.ListAuditEvents(ctx, &v1.ListAuditEventsRequest{
+			Filter: filter,
+			Start:  adminAuditCmdOpts.Start,
+			Limit:  adminAuditCmdOpts.Limit,
+		})
+		if err != nil {
+			return err
+		}
+
+		msgs := make([]proto.Message, len(resp.Result))
+		for i, e := range resp.Result {
+			msgs[i] = e
+		}
+		if err := printer.PrintList(os.Stdout, msgs); err != nil {
+			return err
+		}
+		if shown := int(adminAuditCmdOpts.Start) + len(resp.Result); int(resp.Total) > shown {
+			fmt.Fprintf(os.Stderr, "showing %d of %d events, use --start and --limit to see more\n", len(resp.Result), resp.Total)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(adminCmd)
+	adminCmd.AddCommand(adminRetentionCmd)
+	addOutputFlags(adminRetentionCmd, &adminRetentionCmdOpts)
+
+	adminCmd.AddCommand(adminAuditCmd)
+	addOutputFlags(adminAuditCmd, &adminAuditCmdOpts.outputOpts)
+	adminAuditCmd.Flags().StringArrayVar(&adminAuditCmdOpts.Filter, "filter", nil, "selects events using a filter term, e.g. identity==alice (can be repeated)")
+	adminAuditCmd.Flags().Int32Var(&adminAuditCmdOpts.Start, "start", 0, "number of events to skip")
+	adminAuditCmd.Flags().Int32Var(&adminAuditCmdOpts.Limit, "limit", 50, "maximum number of events to list")
+}
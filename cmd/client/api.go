@@ -0,0 +1,304 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// apiCmd represents the api command
+var apiCmd = &cobra.Command{
+	Use:   "api",
+	Short: "Calls the Bhojpur Text API directly, for debugging",
+}
+
+var apiListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists all methods of the API",
+	Args:  cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 3, ' ', 0)
+		fmt.Fprintln(tw, "METHOD\tTYPE\tREQUEST\tRESPONSE")
+		for _, m := range apiMethods() {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", methodName(m), methodType(m), m.Input().FullName(), m.Output().FullName())
+		}
+		return tw.Flush()
+	},
+}
+
+var apiCallCmdOpts struct {
+	Data   string
+	Output string
+}
+
+var apiCallCmd = &cobra.Command{
+	Use:   "call <method>",
+	Short: "Calls a method of the API with a JSON request",
+	Long: `Calls a method of the API with a request given as JSON, e.g.
+
+  text api call GetEngine --data '{"name": "my-engine"}'
+  text api call TextService/Listen --data '{"name": "my-engine", "updates": true}'
+  text api call ListEngines --data @request.json
+
+Methods can be named by their name alone, or qualified with their service.
+Requests use the protobuf JSON mapping of the messages in pkg/api/v1.
+Client-streaming methods take a sequence of JSON objects as data. Responses
+of server-streaming methods are printed as they arrive.
+
+The data is read from a file if prefixed with @, and from stdin if it is -.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeAPIMethods,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method, err := resolveMethod(args[0])
+		if err != nil {
+			return err
+		}
+		data, err := readAPIData(apiCallCmdOpts.Data)
+		if err != nil {
+			return err
+		}
+		reqs, err := parseAPIRequests(method, data)
+		if err != nil {
+			return err
+		}
+		f, err := output.ParseFormat(apiCallCmdOpts.Output)
+		if err != nil {
+			return err
+		}
+		switch f.Name {
+		case output.FormatTable, output.FormatWide, output.FormatName:
+			return fmt.Errorf("output format %s is not supported by api call", f.Name)
+		}
+		printer, err := output.NewPrinter(f, false, nil, nil)
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		conn := dial()
+		defer conn.Close()
+
+		return callAPI(context.Background(), conn, method, reqs, func(resp proto.Message) error {
+			return printer.PrintObject(os.Stdout, resp)
+		})
+	},
+}
+
+// apiMethods returns all methods of the API, sorted by their full name
+func apiMethods() []protoreflect.MethodDescriptor {
+	var res []protoreflect.MethodDescriptor
+	for _, fd := range []protoreflect.FileDescriptor{v1.File_text_proto, v1.File_text_ui_proto, v1.File_text_admin_proto} {
+		svcs := fd.Services()
+		for i := 0; i < svcs.Len(); i++ {
+			methods := svcs.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				res = append(res, methods.Get(j))
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].FullName() < res[j].FullName() })
+	return res
+}
+
+// methodName returns the name of a method in the form Service/Method
+func methodName(m protoreflect.MethodDescriptor) string {
+	return string(m.Parent().Name()) + "/" + string(m.Name())
+}
+
+// fullMethodName returns the name gRPC uses for a method, i.e. /package.Service/Method
+func fullMethodName(m protoreflect.MethodDescriptor) string {
+	return "/" + string(m.Parent().FullName()) + "/" + string(m.Name())
+}
+
+func methodType(m protoreflect.MethodDescriptor) string {
+	switch {
+	case m.IsStreamingClient() && m.IsStreamingServer():
+		return "bidi-streaming"
+	case m.IsStreamingClient():
+		return "client-streaming"
+	case m.IsStreamingServer():
+		return "server-streaming"
+	default:
+		return "unary"
+	}
+}
+
+// resolveMethod finds the method with the given name. The name can be fully qualified,
+// e.g. /v1.TextService/GetEngine or v1.TextService.GetEngine, or a suffix thereof.
+func resolveMethod(name string) (protoreflect.MethodDescriptor, error) {
+	name = strings.ReplaceAll(strings.TrimPrefix(name, "/"), "/", ".")
+
+	var matches []protoreflect.MethodDescriptor
+	for _, m := range apiMethods() {
+		fn := string(m.FullName())
+		if fn == name || strings.HasSuffix(fn, "."+name) {
+			matches = append(matches, m)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unknown method %s - use \"text api list\" to list all methods", name)
+	case 1:
+		return matches[0], nil
+	default:
+		names := make([]string, len(matches))
+		for i, m := range matches {
+			names[i] = methodName(m)
+		}
+		return nil, fmt.Errorf("method %s is ambiguous: could be any of %s", name, strings.Join(names, ", "))
+	}
+}
+
+// readAPIData reads the request data from a file if it starts with @, or from stdin if it is -
+func readAPIData(data string) ([]byte, error) {
+	switch {
+	case data == "-":
+		return io.ReadAll(os.Stdin)
+	case strings.HasPrefix(data, "@"):
+		return os.ReadFile(strings.TrimPrefix(data, "@"))
+	default:
+		return []byte(data), nil
+	}
+}
+
+// parseAPIRequests parses the request messages of a method from a sequence of JSON objects.
+// Only client-streaming methods accept more than one request.
+func parseAPIRequests(method protoreflect.MethodDescriptor, data []byte) ([]proto.Message, error) {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(method.Input().FullName())
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		res []proto.Message
+		dec = json.NewDecoder(bytes.NewReader(data))
+	)
+	for {
+		var doc json.RawMessage
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid request data: %w", err)
+		}
+
+		req := mt.New().Interface()
+		if err := protojson.Unmarshal(doc, req); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", method.Input().Name(), err)
+		}
+		res = append(res, req)
+	}
+
+	switch {
+	case len(res) == 0 && !method.IsStreamingClient():
+		// an empty request is a valid request
+		res = append(res, mt.New().Interface())
+	case len(res) > 1 && !method.IsStreamingClient():
+		return nil, fmt.Errorf("%s takes a single request, got %d", methodName(method), len(res))
+	}
+	return res, nil
+}
+
+// callAPI invokes the method with the requests and calls handle for every response
+func callAPI(ctx context.Context, conn grpc.ClientConnInterface, method protoreflect.MethodDescriptor, reqs []proto.Message, handle func(proto.Message) error) error {
+	mt, err := protoregistry.GlobalTypes.FindMessageByName(method.Output().FullName())
+	if err != nil {
+		return err
+	}
+
+	if !method.IsStreamingClient() && !method.IsStreamingServer() {
+		resp := mt.New().Interface()
+		if err := conn.Invoke(ctx, fullMethodName(method), reqs[0], resp); err != nil {
+			return err
+		}
+		return handle(resp)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{
+		StreamName:    string(method.Name()),
+		ServerStreams: method.IsStreamingServer(),
+		ClientStreams: method.IsStreamingClient(),
+	}, fullMethodName(method))
+	if err != nil {
+		return err
+	}
+	for _, req := range reqs {
+		if err := stream.SendMsg(req); err != nil {
+			return err
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		resp := mt.New().Interface()
+		err := stream.RecvMsg(resp)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := handle(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// completeAPIMethods completes method names in the form Service/Method
+func completeAPIMethods(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var res []string
+	for _, m := range apiMethods() {
+		res = append(res, methodName(m))
+	}
+	return res, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+	apiCmd.AddCommand(apiListCmd)
+	apiCmd.AddCommand(apiCallCmd)
+	apiCallCmd.Flags().StringVarP(&apiCallCmdOpts.Data, "data", "d", "{}", "request as JSON. Reads from a file if prefixed with @, or from stdin if -")
+	apiCallCmd.Flags().StringVarP(&apiCallCmdOpts.Output, "output", "o", output.FormatJSON, "output format. One of: json, yaml, jsonpath=<expr>, go-template=<template>")
+}
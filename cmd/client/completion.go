@@ -0,0 +1,286 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/spf13/cobra"
+)
+
+// completionTimeout bounds the time spent talking to the server while completing,
+// so that the shell never hangs on an unreachable server.
+const completionTimeout = 2 * time.Second
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish",
+	Short: "Generates shell completion scripts",
+	Long: `Generates shell completion scripts. Besides commands and flags, engine names,
+repositories, engine specs and their annotations are completed by asking the
+Bhojpur Text server.
+
+Bash:
+  source <(text completion bash)
+
+Zsh:
+  text completion zsh > "${fpath[1]}/_text"
+
+Fish:
+  text completion fish > ~/.config/fish/completions/text.fish`,
+	Args:                  cobra.ExactValidArgs(1),
+	ValidArgs:             []string{"bash", "zsh", "fish"},
+	DisableFlagsInUseLine: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletionV2(os.Stdout, true)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		default:
+			return fmt.Errorf("unsupported shell: %s", args[0])
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
+
+// completeWithServer runs fn against the server, giving up after completionTimeout.
+// Errors are ignored as there is no sensible way to report them during completion.
+func completeWithServer(cmd *cobra.Command, fn func(ctx context.Context, conn closableGrpcClientConnInterface) []string) []string {
+	// the context settings were applied before the flags of the completed command line were parsed
+	if err := applyContext(cmd); err != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+
+	res := make(chan []string, 1)
+	go func() {
+		conn, err := tryDial()
+		if err != nil {
+			res <- nil
+			return
+		}
+		defer conn.Close()
+		res <- fn(ctx, conn)
+	}()
+
+	select {
+	case r := <-res:
+		return r
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// completeEngineNames completes the names of engines, excluding the ones given already.
+// If unfinished is true, engines which are done already are not suggested.
+func completeEngineNames(unfinished bool, maxArgs int) func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if maxArgs > 0 && len(args) >= maxArgs {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+
+		req := &v1.ListEnginesRequest{
+			Order: []*v1.OrderExpression{{Field: "created", Ascending: false}},
+			Limit: 100,
+		}
+		if toComplete != "" {
+			req.Filter = append(req.Filter, &v1.FilterExpression{Terms: []*v1.FilterTerm{
+				{Field: "name", Value: toComplete, Operation: v1.FilterOp_OP_STARTS_WITH},
+			}})
+		}
+		if unfinished {
+			req.Filter = append(req.Filter, &v1.FilterExpression{Terms: []*v1.FilterTerm{
+				{Field: "phase", Value: formatPhase(v1.EnginePhase_PHASE_DONE), Operation: v1.FilterOp_OP_EQUALS, Negate: true},
+			}})
+		}
+
+		given := make(map[string]struct{}, len(args))
+		for _, a := range args {
+			given[a] = struct{}{}
+		}
+		res := completeWithServer(cmd, func(ctx context.Context, conn closableGrpcClientConnInterface) []string {
+			resp, err := v1.NewTextServiceClient(conn).ListEngines(ctx, req)
+			if err != nil {
+				return nil
+			}
+			var res []string
+			for _, e := range resp.Result {
+				if _, exists := given[e.Name]; exists || !strings.HasPrefix(e.Name, toComplete) {
+					continue
+				}
+				if unfinished && isFinished(e.Phase) {
+					continue
+				}
+				res = append(res, e.Name+"\t"+formatPhase(e.Phase))
+			}
+			return res
+		})
+		return res, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// listEngineSpecs returns all engine specs known to the server
+func listEngineSpecs(ctx context.Context, conn closableGrpcClientConnInterface) ([]*v1.ListEngineSpecsResponse, error) {
+	specs, err := v1.NewTextUIClient(conn).ListEngineSpecs(ctx, &v1.ListEngineSpecsRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*v1.ListEngineSpecsResponse
+	for {
+		spec, err := specs.Recv()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return res, err
+		}
+		res = append(res, spec)
+	}
+}
+
+// completeRunArgs completes the repository and engine path of the run command
+func completeRunArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 2 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	res := completeWithServer(cmd, func(ctx context.Context, conn closableGrpcClientConnInterface) []string {
+		// we use what we got if the stream breaks off
+		specs, _ := listEngineSpecs(ctx, conn)
+
+		var (
+			res  []string
+			seen = make(map[string]struct{})
+		)
+		for _, spec := range specs {
+			var candidate string
+			switch len(args) {
+			case 0:
+				candidate = formatRepository(spec.Repo)
+			case 1:
+				if !sameRepository(spec.Repo, args[0]) {
+					continue
+				}
+				candidate = spec.Path
+				if spec.Description != "" {
+					candidate += "\t" + spec.Description
+				}
+			}
+			if _, exists := seen[candidate]; exists || !strings.HasPrefix(candidate, toComplete) {
+				continue
+			}
+			seen[candidate] = struct{}{}
+			res = append(res, candidate)
+		}
+		sort.Strings(res)
+		return res
+	})
+	return res, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRunAnnotations completes the annotation keys the engine spec given to the run command accepts
+func completeRunAnnotations(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) < 2 || strings.Contains(toComplete, "=") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	given := make(map[string]struct{}, len(runCmdOpts.Annotations))
+	for _, kv := range runCmdOpts.Annotations {
+		given[strings.SplitN(kv, "=", 2)[0]] = struct{}{}
+	}
+	res := completeWithServer(cmd, func(ctx context.Context, conn closableGrpcClientConnInterface) []string {
+		specs, _ := listEngineSpecs(ctx, conn)
+
+		var res []string
+		for _, spec := range specs {
+			if spec.Path != args[1] || !sameRepository(spec.Repo, args[0]) {
+				continue
+			}
+			for _, arg := range spec.Arguments {
+				if _, exists := given[arg.Name]; exists || !strings.HasPrefix(arg.Name, toComplete) {
+					continue
+				}
+				desc := arg.Description
+				if arg.Required {
+					desc = strings.TrimSpace("(required) " + desc)
+				}
+				res = append(res, arg.Name+"=\t"+desc)
+			}
+			break
+		}
+		return res
+	})
+	return res, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// completeWaitCondition completes the --for flag of the wait command
+func completeWaitCondition(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	res := make([]string, 0, len(phaseOrder))
+	for p := range phaseOrder {
+		if p == v1.EnginePhase_PHASE_UNKNOWN {
+			continue
+		}
+		res = append(res, "phase="+formatPhase(p))
+	}
+	sort.Strings(res)
+	return res, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeContextNames completes the names of the contexts in the client configuration file
+func completeContextNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, _, err := loadClientConfig()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	res := make([]string, 0, len(cfg.Contexts))
+	for _, c := range cfg.Contexts {
+		res = append(res, c.Name)
+	}
+	return res, cobra.ShellCompDirectiveNoFileComp
+}
+
+// sameRepository returns true if arg refers to repo, regardless of the ref
+func sameRepository(repo *v1.Repository, arg string) bool {
+	other, err := parseRepository(arg)
+	if err != nil {
+		return false
+	}
+	return repo.GetHost() == other.Host && repo.GetOwner() == other.Owner && repo.GetRepo() == other.Repo
+}
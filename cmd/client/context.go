@@ -0,0 +1,304 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/bhojpur/text/pkg/clientconfig"
+	"github.com/bhojpur/text/pkg/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// contextCmd represents the context command
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manages the named contexts of the client configuration file",
+	Long: `Manages the named contexts of the client configuration file.
+
+A context describes how to connect to a Bhojpur Text installation. The settings
+of the current context (or the one selected using --context) apply unless they
+are overridden using flags or env vars.`,
+}
+
+var contextListCmdOpts outputOpts
+
+// contextColumns are the columns of the context table in the -o formats
+var contextColumns = []output.Column{
+	{Header: "CURRENT", Value: func(m proto.Message) string {
+		if m.(*structpb.Struct).Fields["current"].GetBoolValue() {
+			return "*"
+		}
+		return ""
+	}},
+	{Header: "NAME", Value: func(m proto.Message) string { return contextField(m, "name") }},
+	{Header: "DIAL MODE", Value: func(m proto.Message) string { return contextField(m, "dialMode") }},
+	{Header: "HOST", Wide: true, Value: func(m proto.Message) string { return contextField(m, "host") }},
+	{Header: "KUBE CONTEXT", Wide: true, Value: func(m proto.Message) string { return contextField(m, "kubeContext") }},
+	{Header: "NAMESPACE", Wide: true, Value: func(m proto.Message) string { return contextField(m, "namespace") }},
+}
+
+func contextField(m proto.Message, name string) string {
+	return m.(*structpb.Struct).Fields[name].GetStringValue()
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists all contexts",
+	Long: `Lists all contexts.
+
+With -o the contexts are printed as they are stored in the client configuration
+file, with a current field marking the current context. Tokens are masked.`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := output.ParseFormat(contextListCmdOpts.Output)
+		if err != nil {
+			return err
+		}
+		printer, err := output.NewPrinter(f, contextListCmdOpts.NoHeaders, contextColumns, func(m proto.Message) string {
+			return contextField(m, "name")
+		})
+		if err != nil {
+			return err
+		}
+
+		cfg, _, err := loadClientConfig()
+		if err != nil {
+			return err
+		}
+
+		if !contextListCmdOpts.isDefault() {
+			msgs := make([]proto.Message, len(cfg.Contexts))
+			for i, c := range cfg.Contexts {
+				msgs[i], err = contextMessage(c, c.Name == cfg.CurrentContext)
+				if err != nil {
+					return err
+				}
+			}
+			return printer.PrintList(os.Stdout, msgs)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "CURRENT\tNAME\tDIAL MODE\tTARGET")
+		for _, c := range cfg.Contexts {
+			var current string
+			if c.Name == cfg.CurrentContext {
+				current = "*"
+			}
+			target := c.Host
+			switch c.DialMode {
+			case dialModeKubernetes:
+				target = c.KubeContext
+				if c.Namespace != "" {
+					target += "/" + c.Namespace
+				}
+			case dialModeInCluster:
+				target = c.Namespace
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", current, c.Name, c.DialMode, target)
+		}
+		return tw.Flush()
+	},
+}
+
+// contextMessage converts a context into a message the output package can print. The token is masked.
+func contextMessage(c clientconfig.Context, current bool) (*structpb.Struct, error) {
+	if c.Token != "" {
+		c.Token = "xxxxx"
+	}
+	fc, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(fc, &fields); err != nil {
+		return nil, err
+	}
+	fields["current"] = current
+	return structpb.NewStruct(fields)
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:               "use <name>",
+	Short:             "Makes a context the current one",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContextNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, fn, err := loadClientConfig()
+		if err != nil {
+			return err
+		}
+		if err := cfg.Use(args[0]); err != nil {
+			return err
+		}
+		if err := cfg.Save(fn); err != nil {
+			return err
+		}
+		fmt.Printf("switched to context %s\n", args[0])
+		return nil
+	},
+}
+
+var contextSetCmd = &cobra.Command{
+	Use:   "set <name>",
+	Short: "Creates or updates a context",
+	Long: `Creates or updates a context from the connection flags passed along, e.g.
+
+  text context set prod --dial-mode kubernetes --kube-context prod-cluster --k8s-namespace text --tls
+
+Only flags which are set explicitly change the context.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, fn, err := loadClientConfig()
+		if err != nil {
+			return err
+		}
+
+		c := clientconfig.Context{Name: args[0]}
+		if existing, err := cfg.Context(args[0]); err == nil {
+			c = *existing
+		}
+
+		flags := cmd.Flags()
+		set := func(flag string, dst *string, val string) {
+			if flags.Changed(flag) {
+				*dst = val
+			}
+		}
+		set("dial-mode", &c.DialMode, rootCmdOpts.DialMode)
+		set("host", &c.Host, rootCmdOpts.Host)
+		set("kubeconfig", &c.Kubeconfig, rootCmdOpts.Kubeconfig)
+		set("kube-context", &c.KubeContext, rootCmdOpts.KubeContext)
+		set("k8s-namespace", &c.Namespace, rootCmdOpts.K8sNamespace)
+		set("token", &c.Token, rootCmdOpts.Token)
+		for _, f := range []string{"tls", "tls-ca", "tls-cert", "tls-key", "tls-server-name"} {
+			if flags.Changed(f) && c.TLS == nil {
+				c.TLS = &clientconfig.TLS{}
+			}
+		}
+		if c.TLS != nil {
+			if flags.Changed("tls") {
+				c.TLS.Enabled = rootCmdOpts.TLS
+			}
+			set("tls-ca", &c.TLS.CAFile, rootCmdOpts.TLSConfig.CAFile)
+			set("tls-cert", &c.TLS.CertFile, rootCmdOpts.TLSConfig.CertFile)
+			set("tls-key", &c.TLS.KeyFile, rootCmdOpts.TLSConfig.KeyFile)
+			set("tls-server-name", &c.TLS.ServerName, rootCmdOpts.TLSConfig.ServerName)
+		}
+
+		cfg.Set(c)
+		if cfg.CurrentContext == "" {
+			cfg.CurrentContext = c.Name
+		}
+		if err := cfg.Save(fn); err != nil {
+			return err
+		}
+		fmt.Printf("context %s saved to %s\n", c.Name, fn)
+		return nil
+	},
+}
+
+var contextDeleteCmd = &cobra.Command{
+	Use:               "delete <name>",
+	Short:             "Deletes a context",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeContextNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, fn, err := loadClientConfig()
+		if err != nil {
+			return err
+		}
+		if err := cfg.Delete(args[0]); err != nil {
+			return err
+		}
+		return cfg.Save(fn)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextListCmd)
+	addOutputFlags(contextListCmd, &contextListCmdOpts)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextSetCmd)
+	contextCmd.AddCommand(contextDeleteCmd)
+}
+
+func loadClientConfig() (cfg *clientconfig.Config, fn string, err error) {
+	fn, err = clientconfig.DefaultPath()
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot determine client config location: %w", err)
+	}
+	cfg, err = clientconfig.Load(fn)
+	if err != nil {
+		return nil, "", err
+	}
+	return cfg, fn, nil
+}
+
+// applyContext applies the settings of the selected context, unless they were
+// set explicitly using a flag or env var.
+func applyContext(cmd *cobra.Command) error {
+	cfg, _, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+
+	c := cfg.Current()
+	if rootCmdOpts.Context != "" {
+		c, err = cfg.Context(rootCmdOpts.Context)
+		if err != nil {
+			return err
+		}
+	}
+	if c == nil {
+		return nil
+	}
+
+	flags := cmd.Flags()
+	set := func(flag, env string, dst *string, val string) {
+		if val == "" || flags.Changed(flag) || os.Getenv(env) != "" {
+			return
+		}
+		*dst = val
+	}
+	set("dial-mode", "TEXT_DIAL_MODE", &rootCmdOpts.DialMode, c.DialMode)
+	set("host", "TEXT_HOST", &rootCmdOpts.Host, c.Host)
+	set("kubeconfig", "KUBECONFIG", &rootCmdOpts.Kubeconfig, c.Kubeconfig)
+	set("kube-context", "TEXT_KUBE_CONTEXT", &rootCmdOpts.KubeContext, c.KubeContext)
+	set("k8s-namespace", "TEXT_K8S_NAMESPACE", &rootCmdOpts.K8sNamespace, c.Namespace)
+	set("token", "TEXT_TOKEN", &rootCmdOpts.Token, c.Token)
+	if c.TLS != nil {
+		if !flags.Changed("tls") && os.Getenv("TEXT_TLS") == "" {
+			rootCmdOpts.TLS = c.TLS.Enabled
+		}
+		set("tls-ca", "TEXT_TLS_CA", &rootCmdOpts.TLSConfig.CAFile, c.TLS.CAFile)
+		set("tls-cert", "TEXT_TLS_CERT", &rootCmdOpts.TLSConfig.CertFile, c.TLS.CertFile)
+		set("tls-key", "TEXT_TLS_KEY", &rootCmdOpts.TLSConfig.KeyFile, c.TLS.KeyFile)
+		set("tls-server-name", "TEXT_TLS_SERVER_NAME", &rootCmdOpts.TLSConfig.ServerName, c.TLS.ServerName)
+	}
+	return nil
+}
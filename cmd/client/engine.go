@@ -0,0 +1,199 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/output"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// engineStatusTemplate renders the details of a single engine
+const engineStatusTemplate = `Name:	{{ .Name }}
+Phase:	{{ phase .Phase }}
+Success:	{{ .Conditions.Success }}
+{{- if .Details }}
+Details:	{{ .Details }}
+{{- end }}
+Owner:	{{ .Metadata.Owner }}
+Spec:	{{ .Metadata.EngineSpecName }}
+Trigger:	{{ trigger .Metadata.Trigger }}
+{{- with .Metadata.Repository }}
+Repository:	{{ .Host }}/{{ .Owner }}/{{ .Repo }}
+Ref:	{{ .Ref }}
+Revision:	{{ .Revision }}
+{{- end }}
+Created:	{{ timestamp .Metadata.Created }}
+Finished:	{{ timestamp .Metadata.Finished }}
+{{- if and .Metadata.Created .Metadata.Finished }}
+Duration:	{{ duration .Metadata.Created .Metadata.Finished }}
+{{- end }}
+Conditions:
+  Did execute:	{{ .Conditions.DidExecute }}
+  Can replay:	{{ .Conditions.CanReplay }}
+  Failure count:	{{ .Conditions.FailureCount }}
+{{- if .Conditions.WaitUntil }}
+  Wait until:	{{ timestamp .Conditions.WaitUntil }}
+{{- end }}
+{{- if .Metadata.Annotations }}
+Annotations:
+{{- range .Metadata.Annotations }}
+  {{ .Key }}:	{{ .Value }}
+{{- end }}
+{{- end }}
+{{- if .Results }}
+Results:
+{{- range .Results }}
+  {{ .Type }}:	{{ .Payload }}{{ if .Description }} ({{ .Description }}){{ end }}
+{{- end }}
+{{- end }}
+`
+
+// engineColumns are the columns of engine tables. Scripts may rely on their order, hence new
+// columns must be added at the end, or as wide column.
+var engineColumns = []output.Column{
+	{Header: "NAME", Value: engineColumn(func(s *v1.EngineStatus) string { return s.Name })},
+	{Header: "PHASE", Value: engineColumn(func(s *v1.EngineStatus) string { return formatPhase(s.Phase) })},
+	{Header: "SUCCESS", Value: engineColumn(func(s *v1.EngineStatus) string {
+		if !isFinished(s.Phase) {
+			return "-"
+		}
+		return fmt.Sprint(s.Conditions.GetSuccess())
+	})},
+	{Header: "OWNER", Value: engineColumn(func(s *v1.EngineStatus) string { return s.Metadata.GetOwner() })},
+	{Header: "SPEC", Value: engineColumn(func(s *v1.EngineStatus) string { return s.Metadata.GetEngineSpecName() })},
+	{Header: "TRIGGER", Value: engineColumn(func(s *v1.EngineStatus) string { return formatTrigger(s.Metadata.GetTrigger()) })},
+	{Header: "CREATED", Value: engineColumn(func(s *v1.EngineStatus) string { return formatTimestamp(s.Metadata.GetCreated()) })},
+	{Header: "DURATION", Value: engineColumn(func(s *v1.EngineStatus) string {
+		return formatDuration(s.Metadata.GetCreated(), s.Metadata.GetFinished())
+	})},
+	{Header: "REPOSITORY", Wide: true, Value: engineColumn(func(s *v1.EngineStatus) string {
+		if s.Metadata.GetRepository() == nil {
+			return ""
+		}
+		return formatRepository(s.Metadata.Repository)
+	})},
+	{Header: "REVISION", Wide: true, Value: engineColumn(func(s *v1.EngineStatus) string { return s.Metadata.GetRepository().GetRevision() })},
+	{Header: "DETAILS", Wide: true, Value: engineColumn(func(s *v1.EngineStatus) string { return s.Details })},
+}
+
+func engineColumn(f func(*v1.EngineStatus) string) func(proto.Message) string {
+	return func(m proto.Message) string {
+		return f(m.(*v1.EngineStatus))
+	}
+}
+
+var templateFuncs = template.FuncMap{
+	"phase":     formatPhase,
+	"trigger":   formatTrigger,
+	"timestamp": formatTimestamp,
+	"duration":  formatDuration,
+}
+
+// printEngineStatus prints the details of an engine in a human readable form
+func printEngineStatus(status *v1.EngineStatus) error {
+	return renderEngineStatus(os.Stdout, status)
+}
+
+// renderEngineStatus writes the details of an engine in a human readable form
+func renderEngineStatus(out io.Writer, status *v1.EngineStatus) error {
+	tpl, err := template.New("engine").Funcs(templateFuncs).Parse(engineStatusTemplate)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	if err := tpl.Execute(tw, status); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// formatRepository formats a repository the way the run command expects it
+func formatRepository(repo *v1.Repository) string {
+	return fmt.Sprintf("%s/%s/%s@%s", repo.GetHost(), repo.GetOwner(), repo.GetRepo(), repo.GetRef())
+}
+
+func formatPhase(p v1.EnginePhase) string {
+	return strings.ToLower(strings.TrimPrefix(p.String(), "PHASE_"))
+}
+
+func formatTrigger(t v1.EngineTrigger) string {
+	return strings.ToLower(strings.TrimPrefix(t.String(), "TRIGGER_"))
+}
+
+func formatTimestamp(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return "-"
+	}
+	return ts.AsTime().Local().Format(time.RFC3339)
+}
+
+func formatDuration(from, to *timestamppb.Timestamp) string {
+	if from == nil || to == nil {
+		return "-"
+	}
+	return to.AsTime().Sub(from.AsTime()).Round(time.Second).String()
+}
+
+// parsePhase parses a phase name like "done" or "PHASE_DONE"
+func parsePhase(s string) (v1.EnginePhase, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	if !strings.HasPrefix(name, "PHASE_") {
+		name = "PHASE_" + name
+	}
+	p, ok := v1.EnginePhase_value[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown phase %q", s)
+	}
+	return v1.EnginePhase(p), nil
+}
+
+// listAllEngines lists all engines matching the request, following through all pages.
+// Servers which do not return page tokens are paged by offset instead.
+func listAllEngines(ctx context.Context, client v1.TextServiceClient, req *v1.ListEnginesRequest) ([]*v1.EngineStatus, error) {
+	var res []*v1.EngineStatus
+	for {
+		resp, err := client.ListEngines(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, resp.Result...)
+		if resp.NextPageToken != "" {
+			req.PageToken, req.Start = resp.NextPageToken, 0
+			continue
+		}
+		if req.PageToken != "" || len(resp.Result) == 0 || len(res) >= int(resp.Total) {
+			return res, nil
+		}
+		req.Start = int32(len(res))
+	}
+}
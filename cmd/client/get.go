@@ -0,0 +1,187 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/history"
+	"github.com/bhojpur/text/pkg/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+var getCmdOpts struct {
+	outputOpts
+	Timeline bool
+}
+
+// getCmd represents the get command
+var getCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Prints the status of an engine",
+	Long: `Prints the status of an engine, or with --timeline its phase transitions and
+condition changes, and how long it spent in each phase, e.g.
+
+  text get my-engine
+  text get my-engine --timeline`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEngineNames(false, 1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if getCmdOpts.Timeline {
+			return getTimeline(cmd, args[0])
+		}
+
+		printer, err := getCmdOpts.enginePrinter()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		conn := dial()
+		defer conn.Close()
+		client := v1.NewTextServiceClient(conn)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		resp, err := client.GetEngine(ctx, &v1.GetEngineRequest{Name: args[0]})
+		if err != nil {
+			return err
+		}
+		if getCmdOpts.isDefault() {
+			return printEngineStatus(resp.Result)
+		}
+		return printer.PrintObject(os.Stdout, resp.Result)
+	},
+}
+
+// historyEventColumns are the columns of the engine timeline table
+var historyEventColumns = []output.Column{
+	{Header: "TIME", Value: historyEventColumn(func(e *v1.EngineHistoryEvent) string { return formatTimestamp(e.Time) })},
+	{Header: "PHASE", Value: historyEventColumn(func(e *v1.EngineHistoryEvent) string { return formatPhase(e.Phase) })},
+	{Header: "SUCCESS", Value: historyEventColumn(func(e *v1.EngineHistoryEvent) string { return fmt.Sprint(e.Conditions.GetSuccess()) })},
+	{Header: "FAILURES", Value: historyEventColumn(func(e *v1.EngineHistoryEvent) string { return fmt.Sprint(e.Conditions.GetFailureCount()) })},
+	{Header: "DETAILS", Wide: true, Value: historyEventColumn(func(e *v1.EngineHistoryEvent) string { return e.Details })},
+}
+
+func historyEventColumn(f func(*v1.EngineHistoryEvent) string) func(proto.Message) string {
+	return func(m proto.Message) string {
+		return f(m.(*v1.EngineHistoryEvent))
+	}
+}
+
+// getTimeline prints the history of an engine
+func getTimeline(cmd *cobra.Command, name string) error {
+	f, err := output.ParseFormat(getCmdOpts.Output)
+	if err != nil {
+		return err
+	}
+	printer, err := output.NewPrinter(f, getCmdOpts.NoHeaders, historyEventColumns, func(m proto.Message) string {
+		return m.(*v1.EngineHistoryEvent).Time.AsTime().Format(time.RFC3339Nano)
+	})
+	if err != nil {
+		return err
+	}
+
+	cmd.SilenceUsage = true
+	conn := dial()
+	defer conn.Close()
+	client := v1.NewTextServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	resp, err := client.GetEngineHistory(ctx, &v1.GetEngineHistoryRequest{Name: name})
+	if err != nil {
+		return err
+	}
+	if getCmdOpts.isDefault() {
+		return renderTimeline(os.Stdout, resp.Result, time.Now())
+	}
+
+	msgs := make([]proto.Message, len(resp.Result))
+	for i, e := range resp.Result {
+		msgs[i] = e
+	}
+	return printer.PrintList(os.Stdout, msgs)
+}
+
+// renderTimeline writes the events of an engine, how long each of them lasted and the
+// time spent in each phase. Engines which are not done yet are in their last phase until now.
+func renderTimeline(out io.Writer, events []*v1.EngineHistoryEvent, now time.Time) error {
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tPHASE\tFOR\tCHANGES")
+	for i, evt := range events {
+		var (
+			prev *v1.EngineHistoryEvent
+			end  = now
+		)
+		if i > 0 {
+			prev = events[i-1]
+		}
+		if i+1 < len(events) {
+			end = events[i+1].Time.AsTime()
+		}
+		dur := "-"
+		if i+1 < len(events) || evt.Phase != v1.EnginePhase_PHASE_DONE {
+			dur = end.Sub(evt.Time.AsTime()).Round(time.Second).String()
+		}
+		changes := strings.Join(history.Changes(prev, evt), " ")
+		if changes == "" {
+			changes = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", formatTimestamp(evt.Time), formatPhase(evt.Phase), dur, changes)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// list the phases in the order the engine first entered them
+	var (
+		periods   = history.Periods(events, now)
+		durations = history.Durations(periods)
+		spent     []string
+	)
+	for _, p := range periods {
+		d, ok := durations[p.Phase]
+		if !ok || p.Phase == v1.EnginePhase_PHASE_DONE {
+			continue
+		}
+		spent = append(spent, fmt.Sprintf("%s %s", formatPhase(p.Phase), d.Round(time.Second)))
+		delete(durations, p.Phase)
+	}
+	if len(spent) > 0 {
+		fmt.Fprintf(out, "\ntime spent: %s\n", strings.Join(spent, ", "))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(getCmd)
+	addOutputFlags(getCmd, &getCmdOpts.outputOpts)
+	getCmd.Flags().BoolVar(&getCmdOpts.Timeline, "timeline", false, "print the phase transitions and condition changes of the engine")
+}
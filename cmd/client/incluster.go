@@ -0,0 +1,188 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health" // enables client-side health checking
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+const inClusterNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// dialInCluster connects to the Bhojpur Text service directly, without port-forwarding.
+// This only works from within the cluster. If the service's endpoints can be listed the
+// client balances calls across the healthy ones, otherwise it dials the service's DNS name.
+func dialInCluster(opts []grpc.DialOption) (closableGrpcClientConnInterface, error) {
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load in-cluster config: %w", err)
+	}
+	namespace := rootCmdOpts.K8sNamespace
+	if namespace == "" {
+		ns, err := os.ReadFile(inClusterNamespaceFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot determine namespace - please use --k8s-namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(ns))
+	}
+	clientSet, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	svc, port, err := findTextService(ctx, clientSet, namespace, rootCmdOpts.K8sLabelSelector, rootCmdOpts.K8sPodPort)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find Bhojpur Text service: %w", err)
+	}
+	authority := net.JoinHostPort(fmt.Sprintf("%s.%s.svc", svc.Name, svc.Namespace), strconv.Itoa(int(port.Port)))
+
+	eps, err := clientSet.CoreV1().Endpoints(namespace).Get(ctx, svc.Name, metav1.GetOptions{})
+	var addrs []resolver.Address
+	if err == nil {
+		addrs = endpointAddresses(eps, port)
+	}
+	if len(addrs) == 0 {
+		log.WithError(err).WithField("service", authority).Debug("no endpoints available - dialing service instead")
+		res, err := grpc.Dial(authority, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return closableConn{ClientConnInterface: res, Closer: res.Close}, nil
+	}
+
+	r := manual.NewBuilderWithScheme("text-endpoints")
+	r.InitialState(resolver.State{Addresses: addrs})
+	opts = append(opts,
+		grpc.WithResolvers(r),
+		grpc.WithAuthority(authority),
+		grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig":[{"round_robin":{}}],"healthCheckConfig":{"serviceName":%q}}`, healthService)),
+	)
+	res, err := grpc.Dial(r.Scheme()+":///"+authority, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	go watchEndpoints(watchCtx, clientSet, namespace, svc.Name, port, r)
+
+	return closableConn{
+		ClientConnInterface: res,
+		Closer: func() error {
+			stopWatching()
+			return res.Close()
+		},
+	}, nil
+}
+
+// findTextService finds the service matching the selector and the service port which targets the pod port
+func findTextService(ctx context.Context, clientSet kubernetes.Interface, namespace, selector, podPort string) (*corev1.Service, *corev1.ServicePort, error) {
+	svcs, err := clientSet.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(svcs.Items) == 0 {
+		return nil, nil, fmt.Errorf("no service in %s with label %s", namespace, selector)
+	}
+
+	for i := range svcs.Items {
+		svc := &svcs.Items[i]
+		for j := range svc.Spec.Ports {
+			p := &svc.Spec.Ports[j]
+			if p.TargetPort.String() == podPort || strconv.Itoa(int(p.Port)) == podPort {
+				return svc, p, nil
+			}
+		}
+	}
+	svc := &svcs.Items[0]
+	if len(svc.Spec.Ports) == 1 {
+		return svc, &svc.Spec.Ports[0], nil
+	}
+	return nil, nil, fmt.Errorf("service %s has no port targeting %s", svc.Name, podPort)
+}
+
+// endpointAddresses returns the ready addresses serving the service port
+func endpointAddresses(eps *corev1.Endpoints, port *corev1.ServicePort) []resolver.Address {
+	var res []resolver.Address
+	for _, subset := range eps.Subsets {
+		for _, p := range subset.Ports {
+			if p.Name != port.Name {
+				continue
+			}
+			for _, addr := range subset.Addresses {
+				res = append(res, resolver.Address{Addr: net.JoinHostPort(addr.IP, strconv.Itoa(int(p.Port)))})
+			}
+		}
+	}
+	return res
+}
+
+// watchEndpoints keeps the resolver up to date with the service's endpoints
+func watchEndpoints(ctx context.Context, clientSet kubernetes.Interface, namespace, name string, port *corev1.ServicePort, r *manual.Resolver) {
+	for {
+		w, err := clientSet.CoreV1().Endpoints(namespace).Watch(ctx, metav1.ListOptions{
+			FieldSelector: "metadata.name=" + name,
+		})
+		if err != nil {
+			log.WithError(err).Debug("cannot watch endpoints")
+		} else {
+			for evt := range w.ResultChan() {
+				if evt.Type != watch.Added && evt.Type != watch.Modified {
+					continue
+				}
+				eps, ok := evt.Object.(*corev1.Endpoints)
+				if !ok {
+					continue
+				}
+				// an empty update would make all calls fail - we'd rather try the last known endpoints
+				if addrs := endpointAddresses(eps, port); len(addrs) > 0 {
+					r.UpdateState(resolver.State{Addresses: addrs})
+				}
+			}
+			w.Stop()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,448 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+func dialKubernetes(opts []grpc.DialOption) (closableGrpcClientConnInterface, error) {
+	kubecfg, namespace, err := getKubeconfig(rootCmdOpts.Kubeconfig, rootCmdOpts.KubeContext)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load kubeconfig %s: %w", rootCmdOpts.Kubeconfig, err)
+	}
+	if rootCmdOpts.K8sNamespace != "" {
+		namespace = rootCmdOpts.K8sNamespace
+	}
+
+	clientSet, err := kubernetes.NewForConfig(kubecfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &kubernetesDialer{
+		Config:    kubecfg,
+		ClientSet: clientSet,
+		Namespace: namespace,
+		Selector:  rootCmdOpts.K8sLabelSelector,
+		PodPort:   rootCmdOpts.K8sPodPort,
+		Probe:     healthProbe(opts),
+	}
+	// establish the first port-forward eagerly so that we fail early if there's no pod to talk to
+	_, err = dialer.forward(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts,
+		grpc.WithContextDialer(dialer.DialContext),
+		grpc.WithChainStreamInterceptor(resumeStreamInterceptor),
+	)
+	res, err := grpc.Dial("localhost", opts...)
+	if err != nil {
+		dialer.Close()
+		return nil, fmt.Errorf("cannot dial forwarded connection: %w", err)
+	}
+
+	return closableConn{
+		ClientConnInterface: res,
+		Closer: func() error {
+			res.Close()
+			return dialer.Close()
+		},
+	}, nil
+}
+
+// kubernetesDialer maintains a port-forward to a ready Bhojpur Text pod. Whenever the
+// port-forward is lost, e.g. because the pod restarted, the next dial selects a pod anew.
+type kubernetesDialer struct {
+	Config    *rest.Config
+	ClientSet kubernetes.Interface
+	Namespace string
+	Selector  string
+	PodPort   string
+	// Probe checks that the server behind a new port-forward is healthy. Optional.
+	Probe func(ctx context.Context, addr string) error
+
+	mu  sync.Mutex
+	fwd *podForward
+}
+
+// DialContext dials the Bhojpur Text server through the port-forward
+func (d *kubernetesDialer) DialContext(ctx context.Context, _ string) (net.Conn, error) {
+	fwd, err := d.forward(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", fwd.Addr())
+	if err != nil {
+		// the forward is likely broken - make sure we don't reuse it
+		fwd.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Close stops the port-forward
+func (d *kubernetesDialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.fwd != nil {
+		d.fwd.Close()
+		d.fwd = nil
+	}
+	return nil
+}
+
+func (d *kubernetesDialer) forward(ctx context.Context) (*podForward, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.fwd != nil {
+		select {
+		case <-d.fwd.Done():
+			log.WithField("pod", d.fwd.Pod).Debug("lost port-forward - selecting pod anew")
+			d.fwd = nil
+		default:
+			return d.fwd, nil
+		}
+	}
+
+	pods, err := findTextPods(ctx, d.ClientSet, d.Namespace, d.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("cannot find Bhojpur Text pod: %w", err)
+	}
+
+	// we try all ready replicas in turn - a pod can be ready, yet fail to port-forward or report itself unhealthy
+	var errs []string
+	for _, pod := range pods {
+		fwd, err := forwardPort(ctx, d.Config, d.ClientSet, d.Namespace, pod, d.PodPort)
+		if err != nil {
+			log.WithError(err).WithField("pod", pod).Debug("cannot forward port - trying next pod")
+			errs = append(errs, fmt.Sprintf("%s: %v", pod, err))
+			continue
+		}
+		if d.Probe != nil {
+			if err := d.Probe(ctx, fwd.Addr()); err != nil {
+				log.WithError(err).WithField("pod", pod).Debug("pod is unhealthy - trying next pod")
+				errs = append(errs, fmt.Sprintf("%s: %v", pod, err))
+				fwd.Close()
+				continue
+			}
+		}
+
+		log.WithField("pod", pod).WithField("addr", fwd.Addr()).Debug("port-forward established")
+		d.fwd = fwd
+		return fwd, nil
+	}
+	return nil, fmt.Errorf("cannot forward to any Bhojpur Text pod: %s", strings.Join(errs, "; "))
+}
+
+// healthService is the service name the server reports its health for
+const healthService = "v1.TextService"
+
+// healthProbeTimeout is the time a pod has to answer the health check before we try the next one
+const healthProbeTimeout = 5 * time.Second
+
+// healthProbe checks the grpc.health.v1 status of the Bhojpur Text service at addr. Servers
+// which predate health checking answer Unimplemented, and are considered healthy.
+func healthProbe(opts []grpc.DialOption) func(ctx context.Context, addr string) error {
+	return func(ctx context.Context, addr string) error {
+		ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+		defer cancel()
+
+		// we dial the same target as the actual connection so that TLS server name verification matches
+		conn, err := grpc.DialContext(ctx, "localhost", append(opts,
+			grpc.WithBlock(),
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "tcp", addr)
+			}),
+		)...)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: healthService})
+		if status.Code(err) == codes.Unimplemented {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if resp.Status != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("server is %s", resp.Status)
+		}
+		return nil
+	}
+}
+
+// GetKubeconfig loads kubernetes connection config from a kubeconfig file.
+// If kubeContext is empty the kubeconfig's current context is used.
+func getKubeconfig(kubeconfig, kubeContext string) (res *rest.Config, namespace string, err error) {
+	cfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: kubeContext},
+	)
+	namespace, _, err = cfg.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+
+	res, err = cfg.ClientConfig()
+	if err != nil {
+		return nil, namespace, err
+	}
+
+	return res, namespace, nil
+}
+
+// findTextPods returns the names of all ready pods matching the selector, newest first
+func findTextPods(ctx context.Context, clientSet kubernetes.Interface, namespace, selector string) (podNames []string, err error) {
+	pods, err := clientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pod in %s with label %s", namespace, selector)
+	}
+
+	ready := make([]corev1.Pod, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		if isPodReady(&pod) {
+			ready = append(ready, pod)
+		}
+	}
+	if len(ready) == 0 {
+		return nil, fmt.Errorf("none of the %d pods in %s with label %s is ready", len(pods.Items), namespace, selector)
+	}
+
+	// newer pods are more likely to stick around, e.g. during a rollout
+	sort.SliceStable(ready, func(i, j int) bool {
+		return ready[j].CreationTimestamp.Before(&ready[i].CreationTimestamp)
+	})
+	for _, pod := range ready {
+		podNames = append(podNames, pod.Name)
+	}
+	return podNames, nil
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podForward is an established port-forward to a pod
+type podForward struct {
+	Pod       string
+	LocalPort uint16
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// Addr returns the local address of the port-forward
+func (f *podForward) Addr() string {
+	return fmt.Sprintf("127.0.0.1:%d", f.LocalPort)
+}
+
+// Done is closed when the port-forward has ended
+func (f *podForward) Done() <-chan struct{} {
+	return f.done
+}
+
+// Close stops the port-forward
+func (f *podForward) Close() {
+	f.stopOnce.Do(func() { close(f.stop) })
+}
+
+// ForwardPort establishes a TCP port forwarding to a Kubernetes pod. The local port is assigned by the OS.
+func forwardPort(ctx context.Context, config *rest.Config, clientSet kubernetes.Interface, namespace, pod, port string) (*podForward, error) {
+	roundTripper, upgrader, err := spdy.RoundTripperFor(config)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL := clientSet.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(pod).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, http.MethodPost, serverURL)
+
+	var (
+		stopChan  = make(chan struct{})
+		readyChan = make(chan struct{})
+		errOut    = new(bytes.Buffer)
+	)
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{"0:" + port}, stopChan, readyChan, io.Discard, errOut)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		done = make(chan struct{})
+		errc = make(chan error, 1)
+	)
+	go func() {
+		defer close(done)
+		errc <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case <-readyChan:
+	case err := <-errc:
+		if err == nil {
+			err = fmt.Errorf("port-forward ended prematurely: %s", strings.TrimSpace(errOut.String()))
+		}
+		return nil, err
+	case <-ctx.Done():
+		close(stopChan)
+		return nil, ctx.Err()
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil || len(ports) == 0 {
+		close(stopChan)
+		return nil, fmt.Errorf("cannot determine local port: %v", err)
+	}
+
+	return &podForward{
+		Pod:       pod,
+		LocalPort: ports[0].Local,
+		stop:      stopChan,
+		done:      done,
+	}, nil
+}
+
+// resumableStreams are the server-streaming methods which are re-opened if the connection to the server is lost.
+// Both only ever send a single request, and are safe to repeat. Note that a resumed Listen call might repeat log output.
+var resumableStreams = map[string]struct{}{
+	"/v1.TextService/Listen":    {},
+	"/v1.TextService/Subscribe": {},
+}
+
+const maxStreamResumes = 5
+
+// resumeStreamInterceptor re-opens long-running streams which fail because the connection to
+// the server was lost, e.g. because the port-forwarded pod restarted.
+func resumeStreamInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	s, err := streamer(ctx, desc, cc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := resumableStreams[method]; !ok {
+		return s, nil
+	}
+	return &resumingStream{
+		ClientStream: s,
+		ctx:          ctx,
+		desc:         desc,
+		cc:           cc,
+		method:       method,
+		streamer:     streamer,
+		opts:         opts,
+	}, nil
+}
+
+type resumingStream struct {
+	grpc.ClientStream
+
+	ctx      context.Context
+	desc     *grpc.StreamDesc
+	cc       *grpc.ClientConn
+	method   string
+	streamer grpc.Streamer
+	opts     []grpc.CallOption
+
+	req interface{}
+}
+
+func (s *resumingStream) SendMsg(m interface{}) error {
+	s.req = m
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *resumingStream) RecvMsg(m interface{}) error {
+	for attempt := 0; ; attempt++ {
+		err := s.ClientStream.RecvMsg(m)
+		if status.Code(err) != codes.Unavailable || s.req == nil || s.ctx.Err() != nil || attempt >= maxStreamResumes {
+			return err
+		}
+
+		log.WithError(err).WithField("method", s.method).Warn("lost connection to Bhojpur Text - resuming")
+		select {
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		case <-s.ctx.Done():
+			return err
+		}
+
+		ns, nerr := s.streamer(s.ctx, s.desc, s.cc, s.method, append(s.opts, grpc.WaitForReady(true))...)
+		if nerr != nil {
+			log.WithError(nerr).Debug("cannot resume stream")
+			continue
+		}
+		if nerr = ns.SendMsg(s.req); nerr != nil {
+			continue
+		}
+		if nerr = ns.CloseSend(); nerr != nil {
+			continue
+		}
+		s.ClientStream = ns
+	}
+}
@@ -0,0 +1,139 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/filterexpr"
+	"github.com/spf13/cobra"
+)
+
+var listCmdOpts struct {
+	outputOpts
+	Filter []string
+	Order  []string
+	Limit  int32
+	All    bool
+}
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists engines",
+	Long: `Lists engines, newest first unless ordered otherwise, e.g.
+
+  text list --filter phase==running --order name:asc -o wide
+  text list --filter trigger==scheduled --filter spec==index
+  text list --filter owner==alice --all
+
+Filter syntax: field==value, field!=value, field~=value (contains),
+field|=value (starts with), field=|value (ends with), field>=value,
+field<value, or just field (exists). Prefix a term with ! to negate it.
+The times created and finished compare chronologically with RFC3339
+values, e.g. created>=2022-03-01T00:00:00Z, other fields lexicographically.`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := filterexpr.Parse(listCmdOpts.Filter)
+		if err != nil {
+			return err
+		}
+		order, err := parseOrder(listCmdOpts.Order)
+		if err != nil {
+			return err
+		}
+		printer, err := listCmdOpts.enginePrinter()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		conn := dial()
+		defer conn.Close()
+		client := v1.NewTextServiceClient(conn)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		req := &v1.ListEnginesRequest{
+			Filter: filter,
+			Order:  order,
+			Limit:  listCmdOpts.Limit,
+		}
+		if listCmdOpts.All {
+			engines, err := listAllEngines(ctx, client, req)
+			if err != nil {
+				return err
+			}
+			return printEngineList(printer, engines)
+		}
+
+		resp, err := client.ListEngines(ctx, req)
+		if err != nil {
+			return err
+		}
+		if err := printEngineList(printer, resp.Result); err != nil {
+			return err
+		}
+		if int(resp.Total) > len(resp.Result) {
+			fmt.Fprintf(os.Stderr, "showing %d of %d engines, use --all or --limit to see more\n", len(resp.Result), resp.Total)
+		}
+		return nil
+	},
+}
+
+// parseOrder parses order terms of the form field[:asc|desc]. Without a direction the order is descending.
+func parseOrder(terms []string) ([]*v1.OrderExpression, error) {
+	if len(terms) == 0 {
+		return []*v1.OrderExpression{{Field: "created", Ascending: false}}, nil
+	}
+
+	res := make([]*v1.OrderExpression, 0, len(terms))
+	for _, t := range terms {
+		field, dir := t, "desc"
+		if idx := strings.LastIndex(t, ":"); idx >= 0 {
+			field, dir = t[:idx], t[idx+1:]
+		}
+		if field == "" {
+			return nil, fmt.Errorf("invalid order %q: field must not be empty", t)
+		}
+		switch dir {
+		case "asc", "desc":
+		default:
+			return nil, fmt.Errorf("invalid order %q: direction must be asc or desc", t)
+		}
+		res = append(res, &v1.OrderExpression{Field: field, Ascending: dir == "asc"})
+	}
+	return res, nil
+}
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	addOutputFlags(listCmd, &listCmdOpts.outputOpts)
+	listCmd.Flags().StringArrayVar(&listCmdOpts.Filter, "filter", nil, "selects engines using a filter term, e.g. owner==alice (can be repeated)")
+	listCmd.Flags().StringArrayVar(&listCmdOpts.Order, "order", nil, "orders engines by a field, e.g. name:asc (can be repeated, defaults to created:desc)")
+	listCmd.Flags().Int32Var(&listCmdOpts.Limit, "limit", 50, "maximum number of engines to list, or the page size with --all")
+	listCmd.Flags().BoolVar(&listCmdOpts.All, "all", false, "list all engines, fetching one page after another")
+}
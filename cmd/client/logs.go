@@ -0,0 +1,91 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+)
+
+// followLogs prints the log output of an engine until the engine is done, and returns its final status
+func followLogs(ctx context.Context, client v1.TextServiceClient, name string, out io.Writer) (*v1.EngineStatus, error) {
+	logs, err := client.Listen(ctx, &v1.ListenRequest{Name: name, Updates: true, Logs: v1.ListenRequestLogs_LOGS_UNSLICED})
+	if err != nil {
+		return nil, err
+	}
+
+	var last *v1.EngineStatus
+	for {
+		msg, err := logs.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if update := msg.GetUpdate(); update != nil {
+			last = update
+			continue
+		}
+		printLogSlice(out, msg.GetSlice())
+	}
+
+	if last != nil && isFinished(last.Phase) {
+		return last, nil
+	}
+	resp, err := client.GetEngine(ctx, &v1.GetEngineRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+func printLogSlice(out io.Writer, slice *v1.LogSliceEvent) {
+	if slice == nil {
+		return
+	}
+
+	prefix := "[" + slice.Name + "] "
+	if slice.Name == "" {
+		prefix = ""
+	}
+	switch slice.Type {
+	case v1.LogSliceType_SLICE_PHASE:
+		fmt.Fprintf(out, "--- %s\n", slice.Payload)
+	case v1.LogSliceType_SLICE_START:
+		fmt.Fprintf(out, "%sstarted\n", prefix)
+	case v1.LogSliceType_SLICE_DONE:
+		fmt.Fprintf(out, "%sdone\n", prefix)
+	case v1.LogSliceType_SLICE_FAIL:
+		fmt.Fprintf(out, "%sfailed: %s\n", prefix, slice.Payload)
+	case v1.LogSliceType_SLICE_RESULT:
+		fmt.Fprintf(out, "%sresult: %s\n", prefix, slice.Payload)
+	case v1.LogSliceType_SLICE_CONTENT:
+		for _, line := range strings.Split(strings.TrimRight(slice.Payload, "\n"), "\n") {
+			fmt.Fprintf(out, "%s%s\n", prefix, line)
+		}
+	}
+}
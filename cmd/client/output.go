@@ -0,0 +1,72 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"os"
+	"strings"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+// outputOpts are the output flags of the commands which print engines
+type outputOpts struct {
+	Output    string
+	NoHeaders bool
+}
+
+// addOutputFlags adds the -o and --no-headers flags to cmd
+func addOutputFlags(cmd *cobra.Command, opts *outputOpts) {
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "output format. One of: "+strings.Join(output.Formats, ", "))
+	cmd.Flags().BoolVar(&opts.NoHeaders, "no-headers", false, "don't print headers in table output")
+	_ = cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{output.FormatTable, output.FormatWide, output.FormatName, output.FormatJSON, output.FormatYAML, output.FormatJSONPath + "=", output.FormatGoTemplate + "="}, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	})
+}
+
+// enginePrinter returns a printer for engines in the chosen format. Commands call it before
+// talking to the server, so that invalid formats are reported right away.
+func (o *outputOpts) enginePrinter() (*output.Printer, error) {
+	f, err := output.ParseFormat(o.Output)
+	if err != nil {
+		return nil, err
+	}
+	return output.NewPrinter(f, o.NoHeaders, engineColumns, func(m proto.Message) string {
+		return m.(*v1.EngineStatus).Name
+	})
+}
+
+// isDefault returns true if no output format was chosen and commands should print their default output
+func (o *outputOpts) isDefault() bool {
+	return o.Output == ""
+}
+
+// printEngineList prints engines using p
+func printEngineList(p *output.Printer, engines []*v1.EngineStatus) error {
+	msgs := make([]proto.Message, len(engines))
+	for i, e := range engines {
+		msgs[i] = e
+	}
+	return p.PrintList(os.Stdout, msgs)
+}
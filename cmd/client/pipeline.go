@@ -0,0 +1,125 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/output"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/proto"
+)
+
+// pipelineCmd represents the pipeline command
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline",
+	Short: "Inspects pipelines, i.e. engines started together with the engines they depend on",
+}
+
+var pipelineGetCmdOpts outputOpts
+
+// pipelineStageColumns are the columns of the pipeline stage table
+var pipelineStageColumns = []output.Column{
+	{Header: "STAGE", Value: pipelineStageColumn(func(s *v1.PipelineStage) string { return s.Name })},
+	{Header: "STATE", Value: pipelineStageColumn(func(s *v1.PipelineStage) string { return formatStageState(s.State) })},
+	{Header: "ENGINE", Value: pipelineStageColumn(func(s *v1.PipelineStage) string { return s.Engine.GetName() })},
+	{Header: "PHASE", Value: pipelineStageColumn(func(s *v1.PipelineStage) string {
+		if s.Engine == nil {
+			return ""
+		}
+		return formatPhase(s.Engine.Phase)
+	})},
+	{Header: "DEPENDS ON", Value: pipelineStageColumn(func(s *v1.PipelineStage) string { return strings.Join(s.DependsOn, ",") })},
+	{Header: "DETAILS", Wide: true, Value: pipelineStageColumn(func(s *v1.PipelineStage) string { return s.Engine.GetDetails() })},
+}
+
+func pipelineStageColumn(f func(*v1.PipelineStage) string) func(proto.Message) string {
+	return func(m proto.Message) string {
+		return f(m.(*v1.PipelineStage))
+	}
+}
+
+var pipelineGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Prints the status of a pipeline and its stages",
+	Long: `Prints the status of a pipeline and its stages. In the table formats the
+stages are listed, the other formats print the whole pipeline status.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := output.ParseFormat(pipelineGetCmdOpts.Output)
+		if err != nil {
+			return err
+		}
+		printer, err := output.NewPrinter(f, pipelineGetCmdOpts.NoHeaders, pipelineStageColumns, func(m proto.Message) string {
+			return m.(*v1.PipelineStage).Name
+		})
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		conn := dial()
+		defer conn.Close()
+		client := v1.NewTextServiceClient(conn)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		resp, err := client.GetPipeline(ctx, &v1.GetPipelineRequest{Name: args[0]})
+		if err != nil {
+			return err
+		}
+		p := resp.Result
+
+		switch printer.Format.Name {
+		case output.FormatTable, output.FormatWide, output.FormatName:
+		default:
+			return printer.PrintObject(os.Stdout, p)
+		}
+
+		if pipelineGetCmdOpts.isDefault() {
+			success := "-"
+			if isFinished(p.Phase) {
+				success = fmt.Sprint(p.Success)
+			}
+			fmt.Printf("Pipeline %s of %s: %s (success: %s)\n\n", p.Name, p.Target, formatPhase(p.Phase), success)
+		}
+		stages := make([]proto.Message, len(p.Stages))
+		for i, s := range p.Stages {
+			stages[i] = s
+		}
+		return printer.PrintList(os.Stdout, stages)
+	},
+}
+
+func formatStageState(s v1.PipelineStageState) string {
+	return strings.ToLower(strings.TrimPrefix(s.String(), "STAGE_"))
+}
+
+func init() {
+	rootCmd.AddCommand(pipelineCmd)
+	pipelineCmd.AddCommand(pipelineGetCmd)
+	addOutputFlags(pipelineGetCmd, &pipelineGetCmdOpts)
+}
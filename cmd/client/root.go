@@ -21,27 +21,19 @@ package cmd
 // THE SOFTWARE.
 
 import (
-	"bytes"
-	"context"
+	"errors"
 	"fmt"
 	"io"
-	"net"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
-	"strings"
-	"sync"
+	"strconv"
 
+	"github.com/bhojpur/text/pkg/auth"
+	"github.com/bhojpur/text/pkg/tlsutil"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/tools/portforward"
-	"k8s.io/client-go/transport/spdy"
+	"google.golang.org/grpc/credentials"
 )
 
 var (
@@ -53,21 +45,28 @@ var rootCmdOpts struct {
 	Verbose          bool
 	Host             string
 	Kubeconfig       string
+	KubeContext      string
 	K8sNamespace     string
 	K8sLabelSelector string
 	K8sPodPort       string
 	DialMode         string
+	Token            string
+	InsecureToken    bool
+	TLS              bool
+	TLSConfig        tlsutil.ClientConfig
+	Context          string
 }
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "text",
 	Short: "Bhojpur Text is a data processing engine powered by Kubernetes",
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if verbose {
 			log.SetLevel(log.DebugLevel)
 			log.Debug("verbose logging enabled")
 		}
+		return applyContext(cmd)
 	},
 }
 
@@ -76,15 +75,35 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
+
+		var eerr *exitError
+		if errors.As(err, &eerr) {
+			os.Exit(eerr.Code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitError makes the client exit with a particular exit code
+type exitError struct {
+	Code int
+	Err  error
+}
+
+func (e *exitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.Err
+}
+
 type dialMode string
 
 const (
 	dialModeHost       = "host"
 	dialModeKubernetes = "kubernetes"
+	dialModeInCluster  = "in-cluster"
 )
 
 func init() {
@@ -114,16 +133,33 @@ func init() {
 	if dialMode == "" {
 		dialMode = string(dialModeHost)
 	}
+	textToken := os.Getenv("TEXT_TOKEN")
+	textInsecureToken, _ := strconv.ParseBool(os.Getenv("TEXT_INSECURE_TOKEN"))
+	textTLS, _ := strconv.ParseBool(os.Getenv("TEXT_TLS"))
 
 	rootCmd.PersistentFlags().BoolVar(&rootCmdOpts.Verbose, "verbose", false, "en/disable verbose logging")
-	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.DialMode, "dial-mode", dialMode, "dial mode that determines how we connect to Bhojpur Text. Valid values are \"host\" or \"kubernetes\" (defaults to TEXT_DIAL_MODE env var).")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.Context, "context", os.Getenv("TEXT_CONTEXT"), "client config context to use instead of the current one. Flags and env vars take precedence over the context's settings (defaults to TEXT_CONTEXT env var)")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.DialMode, "dial-mode", dialMode, "dial mode that determines how we connect to Bhojpur Text. Valid values are \"host\", \"kubernetes\" or \"in-cluster\" (defaults to TEXT_DIAL_MODE env var).")
 	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.Host, "host", textHost, "[host dial mode] Bhojpur Text host to talk to (defaults to TEXT_HOST env var)")
 	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.Kubeconfig, "kubeconfig", textKubeconfig, "[kubernetes dial mode] kubeconfig file to use (defaults to KUEBCONFIG env var)")
-	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.K8sNamespace, "k8s-namespace", textNamespace, "[kubernetes dial mode] Kubernetes namespace in which to look for the Bhojpur Text pods (defaults to TEXT_K8S_NAMESPACE env var, or configured kube context namespace)")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.KubeContext, "kube-context", os.Getenv("TEXT_KUBE_CONTEXT"), "[kubernetes dial mode] kubeconfig context to use instead of the current one (defaults to TEXT_KUBE_CONTEXT env var)")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.K8sNamespace, "k8s-namespace", textNamespace, "[kubernetes/in-cluster dial mode] Kubernetes namespace in which to look for the Bhojpur Text pods (defaults to TEXT_K8S_NAMESPACE env var, or configured kube context/service account namespace)")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.Token, "token", textToken, "bearer token used to authenticate with Bhojpur Text (defaults to TEXT_TOKEN env var)")
+	rootCmd.PersistentFlags().BoolVar(&rootCmdOpts.InsecureToken, "insecure-token", textInsecureToken, "send the bearer token even if the connection does not use TLS (defaults to TEXT_INSECURE_TOKEN env var)")
+	rootCmd.PersistentFlags().BoolVar(&rootCmdOpts.TLS, "tls", textTLS, "use TLS when connecting to Bhojpur Text (defaults to TEXT_TLS env var)")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.TLSConfig.CAFile, "tls-ca", os.Getenv("TEXT_TLS_CA"), "CA bundle used to verify the Bhojpur Text server, instead of the system roots (defaults to TEXT_TLS_CA env var)")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.TLSConfig.CertFile, "tls-cert", os.Getenv("TEXT_TLS_CERT"), "client certificate for mutual TLS (defaults to TEXT_TLS_CERT env var)")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.TLSConfig.KeyFile, "tls-key", os.Getenv("TEXT_TLS_KEY"), "client certificate key for mutual TLS (defaults to TEXT_TLS_KEY env var)")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.TLSConfig.ServerName, "tls-server-name", os.Getenv("TEXT_TLS_SERVER_NAME"), "server name used to verify the server certificate. Required in kubernetes dial mode unless the certificate is valid for localhost (defaults to TEXT_TLS_SERVER_NAME env var)")
 	// The following are such specific flags that really only matters if one doesn't use the stock helm charts.
 	// They can still be set using an env var, but there's no need to clutter the CLI with them.
 	rootCmdOpts.K8sLabelSelector = textLabelSelector
 	rootCmdOpts.K8sPodPort = textPodPort
+
+	_ = rootCmd.RegisterFlagCompletionFunc("context", completeContextNames)
+	_ = rootCmd.RegisterFlagCompletionFunc("dial-mode", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{dialModeHost, dialModeKubernetes, dialModeInCluster}, cobra.ShellCompDirectiveNoFileComp
+	})
 }
 
 type closableGrpcClientConnInterface interface {
@@ -131,63 +167,54 @@ type closableGrpcClientConnInterface interface {
 	io.Closer
 }
 
-func dial() (res closableGrpcClientConnInterface) {
-	var err error
-	switch rootCmdOpts.DialMode {
-	case dialModeHost:
-		res, err = grpc.Dial(rootCmdOpts.Host, grpc.WithInsecure())
-	case dialModeKubernetes:
-		res, err = dialKubernetes()
-	default:
-		log.Fatalf("unknown dial mode: %s", rootCmdOpts.DialMode)
-	}
-
+func dial() closableGrpcClientConnInterface {
+	res, err := tryDial()
 	if err != nil {
 		log.WithError(err).Fatal("cannot connect to Bhojpur Text server")
 	}
-	return
+	return res
 }
 
-func dialKubernetes() (closableGrpcClientConnInterface, error) {
-	kubecfg, namespace, err := getKubeconfig(rootCmdOpts.Kubeconfig)
-	if err != nil {
-		return nil, fmt.Errorf("cannot load kubeconfig %s: %w", rootCmdOpts.Kubeconfig, err)
-	}
-	if rootCmdOpts.K8sNamespace != "" {
-		namespace = rootCmdOpts.K8sNamespace
-	}
-
-	clientSet, err := kubernetes.NewForConfig(kubecfg)
+// tryDial connects to the Bhojpur Text server like dial, but returns errors instead of exiting
+func tryDial() (res closableGrpcClientConnInterface, err error) {
+	opts, err := dialOptions()
 	if err != nil {
 		return nil, err
 	}
 
-	pod, err := findTextPod(clientSet, namespace, rootCmdOpts.K8sLabelSelector)
-	if err != nil {
-		return nil, fmt.Errorf("cannot find Bhojpur Text pod: %w", err)
+	switch rootCmdOpts.DialMode {
+	case dialModeHost:
+		res, err = grpc.Dial(rootCmdOpts.Host, opts...)
+	case dialModeKubernetes:
+		res, err = dialKubernetes(opts)
+	case dialModeInCluster:
+		res, err = dialInCluster(opts)
+	default:
+		return nil, fmt.Errorf("unknown dial mode: %s", rootCmdOpts.DialMode)
 	}
+	return
+}
 
-	localPort, err := findFreeLocalPort()
-
-	ctx, cancel := context.WithCancel(context.Background())
-	readychan, errchan := forwardPort(ctx, kubecfg, namespace, pod, fmt.Sprintf("%d:%s", localPort, rootCmdOpts.K8sPodPort))
-	select {
-	case err := <-errchan:
-		cancel()
-		return nil, err
-	case <-readychan:
+// dialOptions returns the gRPC dial options shared by all dial modes. The bearer token is only
+// sent over plaintext connections if --insecure-token is set.
+func dialOptions() ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	if rootCmdOpts.TLS {
+		tlsConfig, err := rootCmdOpts.TLSConfig.TLSConfig()
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS configuration: %w", err)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
 	}
-
-	res, err := grpc.Dial(fmt.Sprintf("localhost:%d", localPort), grpc.WithInsecure())
-	if err != nil {
-		cancel()
-		return nil, fmt.Errorf("cannot dial forwarded connection: %w", err)
+	if rootCmdOpts.Token != "" {
+		if !rootCmdOpts.TLS && !rootCmdOpts.InsecureToken {
+			return nil, fmt.Errorf("refusing to send the bearer token over a plaintext connection: use --tls, or --insecure-token if the connection is secured otherwise")
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(auth.BearerToken(rootCmdOpts.Token, !rootCmdOpts.InsecureToken)))
 	}
-
-	return closableConn{
-		ClientConnInterface: res,
-		Closer:              func() error { cancel(); return nil },
-	}, nil
+	return opts, nil
 }
 
 type closableConn struct {
@@ -198,108 +225,3 @@ type closableConn struct {
 func (c closableConn) Close() error {
 	return c.Closer()
 }
-
-func findFreeLocalPort() (int, error) {
-	const (
-		start = 30000
-		end   = 60000
-	)
-	for p := start; p <= end; p++ {
-		l, err := net.Listen("tcp", fmt.Sprintf(":%d", p))
-		if err == nil {
-			l.Close()
-			return p, nil
-		}
-	}
-	return 0, fmt.Errorf("no free local port found")
-}
-
-// GetKubeconfig loads kubernetes connection config from a kubeconfig file
-func getKubeconfig(kubeconfig string) (res *rest.Config, namespace string, err error) {
-	cfg := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
-		&clientcmd.ConfigOverrides{},
-	)
-	namespace, _, err = cfg.Namespace()
-	if err != nil {
-		return nil, "", err
-	}
-
-	res, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-	if err != nil {
-		return nil, namespace, err
-	}
-
-	return res, namespace, nil
-}
-
-// findTextPod returns the first pod we found for a particular component
-func findTextPod(clientSet kubernetes.Interface, namespace, selector string) (podName string, err error) {
-	pods, err := clientSet.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{
-		LabelSelector: selector,
-	})
-	if err != nil {
-		return "", err
-	}
-	if len(pods.Items) == 0 {
-		return "", fmt.Errorf("no pod in %s with label component=%s", namespace, selector)
-	}
-	return pods.Items[0].Name, nil
-}
-
-// ForwardPort establishes a TCP port forwarding to a Kubernetes pod
-func forwardPort(ctx context.Context, config *rest.Config, namespace, pod, port string) (readychan chan struct{}, errchan chan error) {
-	errchan = make(chan error, 1)
-	readychan = make(chan struct{}, 1)
-
-	roundTripper, upgrader, err := spdy.RoundTripperFor(config)
-	if err != nil {
-		errchan <- err
-		return
-	}
-
-	path := fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/portforward", namespace, pod)
-	hostIP := strings.TrimLeft(config.Host, "https://")
-	serverURL := url.URL{Scheme: "https", Path: path, Host: hostIP}
-	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: roundTripper}, http.MethodPost, &serverURL)
-
-	stopChan := make(chan struct{}, 1)
-	fwdReadyChan := make(chan struct{}, 1)
-	out, errOut := new(bytes.Buffer), new(bytes.Buffer)
-	forwarder, err := portforward.New(dialer, []string{port}, stopChan, fwdReadyChan, out, errOut)
-	if err != nil {
-		panic(err)
-	}
-
-	var once sync.Once
-	go func() {
-		err := forwarder.ForwardPorts()
-		if err != nil {
-			errchan <- err
-		}
-		once.Do(func() { close(readychan) })
-	}()
-
-	go func() {
-		select {
-		case <-readychan:
-			// we're out of here
-		case <-ctx.Done():
-			close(stopChan)
-		}
-	}()
-
-	go func() {
-		for range fwdReadyChan {
-		}
-
-		if errOut.Len() != 0 {
-			errchan <- fmt.Errorf(errOut.String())
-			return
-		}
-
-		once.Do(func() { close(readychan) })
-	}()
-
-	return
-}
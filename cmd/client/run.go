@@ -0,0 +1,250 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/parsers"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var runCmdOpts struct {
+	outputOpts
+	Annotations []string
+	WaitUntil   string
+	NameSuffix  string
+	Sideload    string
+	Follow      bool
+}
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run <repo>@<ref> <engine-path>",
+	Short: "Starts an engine from a repository",
+	Long: `Starts an engine from its specification in a repository, e.g.
+
+  text run github.com/bhojpur/text@main .text/index.yaml -a lang=en
+
+The repository is given as host/owner/repo, or owner/repo for GitHub.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeRunArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		repo, err := parseRepository(args[0])
+		if err != nil {
+			return err
+		}
+		annotations, err := parseAnnotations(runCmdOpts.Annotations)
+		if err != nil {
+			return err
+		}
+		req := &v1.StartEngineRequest{
+			Metadata: &v1.EngineMetadata{
+				Repository:  repo,
+				Trigger:     v1.EngineTrigger_TRIGGER_MANUAL,
+				Annotations: annotations,
+			},
+			EnginePath: args[1],
+			NameSuffix: runCmdOpts.NameSuffix,
+		}
+		if runCmdOpts.WaitUntil != "" {
+			waitUntil, err := parseWaitUntil(runCmdOpts.WaitUntil, time.Now())
+			if err != nil {
+				return err
+			}
+			req.WaitUntil = timestamppb.New(waitUntil)
+		}
+		if runCmdOpts.Sideload != "" {
+			req.Sideload, err = readSideload(runCmdOpts.Sideload)
+			if err != nil {
+				return fmt.Errorf("cannot read sideload %s: %w", runCmdOpts.Sideload, err)
+			}
+		}
+		printer, err := runCmdOpts.enginePrinter()
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		conn := dial()
+		defer conn.Close()
+		client := v1.NewTextServiceClient(conn)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		resp, err := client.StartEngine(ctx, req)
+		cancel()
+		if err != nil {
+			return err
+		}
+		name := resp.Status.Name
+		if runCmdOpts.isDefault() {
+			fmt.Println(name)
+		} else if err := printer.PrintObject(os.Stdout, resp.Status); err != nil {
+			return err
+		}
+		if !runCmdOpts.Follow {
+			return nil
+		}
+
+		status, err := followLogs(context.Background(), client, name, os.Stdout)
+		if err != nil {
+			return err
+		}
+		if !status.Conditions.GetSuccess() {
+			return &exitError{Code: waitExitFailed, Err: fmt.Errorf("engine %s failed", name)}
+		}
+		return nil
+	},
+}
+
+// parseRepository parses a repository reference of the form [host/]owner/repo@ref
+func parseRepository(s string) (*v1.Repository, error) {
+	idx := strings.LastIndex(s, "@")
+	if idx < 0 {
+		return nil, fmt.Errorf("invalid repository %q: expected <repo>@<ref>", s)
+	}
+	path, ref := s[:idx], s[idx+1:]
+	if ref == "" {
+		return nil, fmt.Errorf("invalid repository %q: ref must not be empty", s)
+	}
+
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	switch len(segs) {
+	case 2:
+		segs = append([]string{"github.com"}, segs...)
+	case 3:
+	default:
+		return nil, fmt.Errorf("invalid repository %q: expected [host/]owner/repo", s)
+	}
+	for _, seg := range segs {
+		if seg == "" {
+			return nil, fmt.Errorf("invalid repository %q: expected [host/]owner/repo", s)
+		}
+	}
+	return &v1.Repository{Host: segs[0], Owner: segs[1], Repo: segs[2], Ref: ref}, nil
+}
+
+// parseAnnotations parses key=value pairs into annotations
+func parseAnnotations(kvs []string) ([]*v1.Annotation, error) {
+	res := make([]*v1.Annotation, 0, len(kvs))
+	for _, kv := range kvs {
+		key, value, err := parsers.ParseKeyValueOpt(kv)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			return nil, fmt.Errorf("annotation key must not be empty: %s", kv)
+		}
+		res = append(res, &v1.Annotation{Key: key, Value: value})
+	}
+	return res, nil
+}
+
+// parseWaitUntil parses either an RFC3339 timestamp or a duration relative to now
+func parseWaitUntil(s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid wait until %q: expected RFC3339 time or duration, e.g. 30m", s)
+	}
+	if d < 0 {
+		return time.Time{}, fmt.Errorf("invalid wait until %q: duration must not be negative", s)
+	}
+	return now.Add(d), nil
+}
+
+// readSideload reads the sideload file. Directories are sent as gzipped tar archive.
+func readSideload(fn string) ([]byte, error) {
+	stat, err := os.Stat(fn)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return os.ReadFile(fn)
+	}
+
+	var (
+		buf = new(bytes.Buffer)
+		gz  = gzip.NewWriter(buf)
+		tw  = tar.NewWriter(gz)
+	)
+	err = filepath.Walk(fn, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(fn, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().StringArrayVarP(&runCmdOpts.Annotations, "annotation", "a", nil, "adds an annotation to the engine, e.g. -a lang=en (can be repeated)")
+	runCmd.Flags().StringVar(&runCmdOpts.WaitUntil, "wait-until", "", "delays the start of the engine until an RFC3339 time, or for a duration, e.g. 30m")
+	runCmd.Flags().StringVar(&runCmdOpts.NameSuffix, "name-suffix", "", "suffix appended to the engine name")
+	runCmd.Flags().StringVar(&runCmdOpts.Sideload, "sideload", "", "file or directory which is made available to the engine on top of the repository content")
+	addOutputFlags(runCmd, &runCmdOpts.outputOpts)
+	runCmd.Flags().BoolVarP(&runCmdOpts.Follow, "follow", "f", false, "streams the engine's logs after start and exits with its success condition")
+	_ = runCmd.RegisterFlagCompletionFunc("annotation", completeRunAnnotations)
+}
@@ -0,0 +1,194 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/filterexpr"
+	"github.com/spf13/cobra"
+)
+
+var stopCmdOpts struct {
+	outputOpts
+	Filter []string
+	DryRun bool
+}
+
+// stopCmd represents the stop command
+var stopCmd = &cobra.Command{
+	Use:   "stop <name>...",
+	Short: "Stops one or more engines",
+	Long: `Stops one or more engines.
+
+Names can be glob patterns, e.g. "nightly-*". Engines can also be selected
+using filter terms, e.g. --filter owner==alice --filter spec==nightly.
+Filter syntax: field==value, field!=value, field~=value (contains),
+field|=value (starts with), field=|value (ends with), field>=value,
+field<value, or just field (exists). Prefix a term with ! to negate it.
+The times created and finished compare chronologically with RFC3339
+values, e.g. created>=2022-03-01T00:00:00Z, other fields lexicographically.
+
+When selecting engines by pattern or filter, engines which are done already
+are left alone.
+
+With -o the engines which were stopped, or would be stopped with --dry-run,
+are printed in that format once all of them have been handled.`,
+	ValidArgsFunction: completeEngineNames(true, 0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && len(stopCmdOpts.Filter) == 0 {
+			return fmt.Errorf("requires at least one engine name or --filter")
+		}
+		printer, err := stopCmdOpts.enginePrinter()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		conn := dial()
+		defer conn.Close()
+		client := v1.NewTextServiceClient(conn)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		names, err := selectEngines(ctx, client, args, stopCmdOpts.Filter)
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return fmt.Errorf("no engine matches")
+		}
+
+		var (
+			failed  int
+			stopped []string
+		)
+		for _, name := range names {
+			if stopCmdOpts.DryRun {
+				stopped = append(stopped, name)
+				if stopCmdOpts.isDefault() {
+					fmt.Printf("%s would be stopped\n", name)
+				}
+				continue
+			}
+			_, err := client.StopEngine(ctx, &v1.StopEngineRequest{Name: name})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: cannot stop: %v\n", name, err)
+				failed++
+				continue
+			}
+			stopped = append(stopped, name)
+			if stopCmdOpts.isDefault() {
+				fmt.Printf("%s stopped\n", name)
+			}
+		}
+		if !stopCmdOpts.isDefault() {
+			engines := make([]*v1.EngineStatus, 0, len(stopped))
+			for _, name := range stopped {
+				resp, err := client.GetEngine(ctx, &v1.GetEngineRequest{Name: name})
+				if err != nil {
+					return err
+				}
+				engines = append(engines, resp.Result)
+			}
+			if err := printEngineList(printer, engines); err != nil {
+				return err
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed to stop %d of %d engines", failed, len(names))
+		}
+		return nil
+	},
+}
+
+// selectEngines resolves engine names, glob patterns and filters to the names of the engines
+// they match. Literal names are used as-is, patterns and filters select among the engines
+// which are not done yet.
+func selectEngines(ctx context.Context, client v1.TextServiceClient, args []string, filter []string) ([]string, error) {
+	var (
+		names    []string
+		patterns []string
+	)
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "*?[") {
+			if _, err := path.Match(arg, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", arg, err)
+			}
+			patterns = append(patterns, arg)
+			continue
+		}
+		names = append(names, arg)
+	}
+	if len(patterns) == 0 && len(filter) == 0 {
+		return names, nil
+	}
+
+	fexprs, err := filterexpr.Parse(filter)
+	if err != nil {
+		return nil, err
+	}
+	fexprs = append(fexprs, &v1.FilterExpression{Terms: []*v1.FilterTerm{
+		{Field: "phase", Value: formatPhase(v1.EnginePhase_PHASE_DONE), Operation: v1.FilterOp_OP_EQUALS, Negate: true},
+	}})
+	engines, err := listAllEngines(ctx, client, &v1.ListEnginesRequest{Filter: fexprs})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(names))
+	for _, n := range names {
+		seen[n] = struct{}{}
+	}
+	for _, e := range engines {
+		if _, exists := seen[e.Name]; exists {
+			continue
+		}
+		if len(patterns) > 0 && !matchesAny(e.Name, patterns) {
+			continue
+		}
+		seen[e.Name] = struct{}{}
+		names = append(names, e.Name)
+	}
+	return names, nil
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	rootCmd.AddCommand(stopCmd)
+	stopCmd.Flags().StringArrayVar(&stopCmdOpts.Filter, "filter", nil, "selects engines using a filter term, e.g. owner==alice (can be repeated)")
+	stopCmd.Flags().BoolVar(&stopCmdOpts.DryRun, "dry-run", false, "prints the engines which would be stopped without stopping them")
+	addOutputFlags(stopCmd, &stopCmdOpts.outputOpts)
+}
@@ -0,0 +1,527 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/output"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/spf13/cobra"
+)
+
+// maxSliceLines is the number of log lines kept per slice in the engine view
+const maxSliceLines = 1000
+
+// uiCmd represents the ui command
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Starts an interactive terminal dashboard of all engines",
+	Long: `Starts an interactive terminal dashboard of all engines.
+
+Engine list:
+  enter    show engine details and logs
+  o / O    sort by next column / reverse sort order
+  s        stop the selected engine
+  r        replay the selected engine
+  q        quit
+
+Engine details:
+  enter    expand/collapse a log slice
+  e / c    expand/collapse all log slices
+  s / r    stop/replay the engine
+  esc      back to the engine list`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		conn := dial()
+		defer conn.Close()
+
+		ui := newEngineUI(v1.NewTextServiceClient(conn))
+		return ui.Run()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
+
+// uiColumns are the engine table columns shown in the dashboard
+var uiColumns = func() []output.Column {
+	var res []output.Column
+	for _, c := range engineColumns {
+		if !c.Wide {
+			res = append(res, c)
+		}
+	}
+	return res
+}()
+
+// uiSortOrder defines the sort order of columns whose values don't sort alphabetically
+var uiSortOrder = map[string]func(a, b *v1.EngineStatus) bool{
+	"PHASE": func(a, b *v1.EngineStatus) bool { return phaseOrder[a.Phase] < phaseOrder[b.Phase] },
+	"CREATED": func(a, b *v1.EngineStatus) bool {
+		return a.Metadata.GetCreated().AsTime().Before(b.Metadata.GetCreated().AsTime())
+	},
+}
+
+var phaseColors = map[v1.EnginePhase]tcell.Color{
+	v1.EnginePhase_PHASE_WAITING:   tcell.ColorGray,
+	v1.EnginePhase_PHASE_PREPARING: tcell.ColorYellow,
+	v1.EnginePhase_PHASE_STARTING:  tcell.ColorYellow,
+	v1.EnginePhase_PHASE_RUNNING:   tcell.ColorBlue,
+	v1.EnginePhase_PHASE_CLEANUP:   tcell.ColorGray,
+}
+
+// engineUI is the terminal dashboard. All fields are only accessed from the tview event loop,
+// except for the mutex-protected engines which the subscription updates.
+type engineUI struct {
+	client v1.TextServiceClient
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	app    *tview.Application
+	pages  *tview.Pages
+	table  *tview.Table
+	status *tview.TextView
+
+	mu      sync.Mutex
+	engines map[string]*v1.EngineStatus
+
+	sortColumn int
+	sortDesc   bool
+
+	detail *engineDetailView
+}
+
+func newEngineUI(client v1.TextServiceClient) *engineUI {
+	ctx, cancel := context.WithCancel(context.Background())
+	ui := &engineUI{
+		client:     client,
+		ctx:        ctx,
+		cancel:     cancel,
+		app:        tview.NewApplication(),
+		pages:      tview.NewPages(),
+		table:      tview.NewTable(),
+		status:     tview.NewTextView().SetDynamicColors(true),
+		engines:    make(map[string]*v1.EngineStatus),
+		sortColumn: 1,
+	}
+
+	ui.table.SetSelectable(true, false).SetFixed(1, 0).SetBorder(true).SetTitle(" Bhojpur Text engines ")
+	ui.table.SetSelectedFunc(func(row, column int) {
+		if name := ui.selectedEngine(); name != "" {
+			ui.showDetail(name)
+		}
+	})
+	ui.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'q':
+			ui.app.Stop()
+		case 'o':
+			ui.sortColumn = (ui.sortColumn + 1) % len(uiColumns)
+			ui.renderTable()
+		case 'O':
+			ui.sortDesc = !ui.sortDesc
+			ui.renderTable()
+		case 's':
+			ui.confirmStop(ui.selectedEngine())
+		case 'r':
+			ui.replay(ui.selectedEngine())
+		default:
+			return event
+		}
+		return nil
+	})
+
+	list := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(ui.table, 0, 1, true).
+		AddItem(ui.status, 1, 0, false)
+	ui.pages.AddPage("engines", list, true, true)
+	ui.app.SetRoot(ui.pages, true)
+	return ui
+}
+
+// Run runs the dashboard until the user quits
+func (ui *engineUI) Run() error {
+	defer ui.cancel()
+
+	ctx, cancel := context.WithTimeout(ui.ctx, 30*time.Second)
+	engines, err := listAllEngines(ctx, ui.client, &v1.ListEnginesRequest{})
+	cancel()
+	if err != nil {
+		return err
+	}
+	for _, e := range engines {
+		ui.engines[e.Name] = e
+	}
+	ui.renderTable()
+	ui.setStatus("[gray]enter: details  o/O: sort  s: stop  r: replay  q: quit")
+
+	go ui.subscribe()
+	return ui.app.Run()
+}
+
+// subscribe keeps the engine table up to date until the UI stops
+func (ui *engineUI) subscribe() {
+	for {
+		sub, err := ui.client.Subscribe(ui.ctx, &v1.SubscribeRequest{})
+		for err == nil {
+			var msg *v1.SubscribeResponse
+			msg, err = sub.Recv()
+			if err != nil {
+				break
+			}
+			ui.mu.Lock()
+			ui.engines[msg.Result.Name] = msg.Result
+			ui.mu.Unlock()
+
+			ui.app.QueueUpdateDraw(func() {
+				ui.renderTable()
+				if ui.detail != nil && ui.detail.name == msg.Result.Name {
+					ui.detail.setEngine(msg.Result)
+				}
+			})
+		}
+		if ui.ctx.Err() != nil {
+			return
+		}
+
+		ui.app.QueueUpdateDraw(func() {
+			ui.setStatus(fmt.Sprintf("[red]lost subscription: %v - retrying", err))
+		})
+		select {
+		case <-ui.ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func (ui *engineUI) sortedEngines() []*v1.EngineStatus {
+	ui.mu.Lock()
+	res := make([]*v1.EngineStatus, 0, len(ui.engines))
+	for _, e := range ui.engines {
+		res = append(res, e)
+	}
+	ui.mu.Unlock()
+
+	col := uiColumns[ui.sortColumn]
+	less := uiSortOrder[col.Header]
+	if less == nil {
+		less = func(a, b *v1.EngineStatus) bool { return col.Value(a) < col.Value(b) }
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if ui.sortDesc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		// newest engines first among equals
+		return res[i].Metadata.GetCreated().AsTime().After(res[j].Metadata.GetCreated().AsTime())
+	})
+	return res
+}
+
+func (ui *engineUI) renderTable() {
+	selected := ui.selectedEngine()
+
+	ui.table.Clear()
+	for c, col := range uiColumns {
+		title := col.Header
+		if c == ui.sortColumn {
+			if ui.sortDesc {
+				title += " ↓"
+			} else {
+				title += " ↑"
+			}
+		}
+		ui.table.SetCell(0, c, tview.NewTableCell(title).SetSelectable(false).SetTextColor(tcell.ColorYellow).SetExpansion(1))
+	}
+
+	for r, e := range ui.sortedEngines() {
+		color := tcell.ColorWhite
+		if c, ok := phaseColors[e.Phase]; ok {
+			color = c
+		} else if isFinished(e.Phase) && !e.Conditions.GetSuccess() {
+			color = tcell.ColorRed
+		} else if isFinished(e.Phase) {
+			color = tcell.ColorGreen
+		}
+		for c, col := range uiColumns {
+			cell := tview.NewTableCell(tview.Escape(col.Value(e))).SetTextColor(color).SetExpansion(1)
+			if c == 0 {
+				cell.SetReference(e.Name)
+			}
+			ui.table.SetCell(r+1, c, cell)
+		}
+		if e.Name == selected {
+			ui.table.Select(r+1, 0)
+		}
+	}
+}
+
+func (ui *engineUI) selectedEngine() string {
+	row, _ := ui.table.GetSelection()
+	cell := ui.table.GetCell(row, 0)
+	if cell == nil {
+		return ""
+	}
+	name, _ := cell.GetReference().(string)
+	return name
+}
+
+func (ui *engineUI) setStatus(msg string) {
+	ui.status.SetText(msg)
+	if ui.detail != nil {
+		ui.detail.status.SetText(msg)
+	}
+}
+
+func (ui *engineUI) confirmStop(name string) {
+	if name == "" {
+		return
+	}
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Stop engine %s?", name)).
+		AddButtons([]string{"Stop", "Cancel"}).
+		SetDoneFunc(func(idx int, label string) {
+			ui.pages.RemovePage("confirm")
+			if label != "Stop" {
+				return
+			}
+			go func() {
+				ctx, cancel := context.WithTimeout(ui.ctx, 10*time.Second)
+				defer cancel()
+				_, err := ui.client.StopEngine(ctx, &v1.StopEngineRequest{Name: name})
+				ui.app.QueueUpdateDraw(func() {
+					if err != nil {
+						ui.setStatus(fmt.Sprintf("[red]cannot stop %s: %v", tview.Escape(name), err))
+						return
+					}
+					ui.setStatus(fmt.Sprintf("[green]stopped %s", tview.Escape(name)))
+				})
+			}()
+		})
+	ui.pages.AddPage("confirm", modal, false, true)
+}
+
+func (ui *engineUI) replay(name string) {
+	if name == "" {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(ui.ctx, 30*time.Second)
+		defer cancel()
+		resp, err := ui.client.StartFromPreviousEngine(ctx, &v1.StartFromPreviousEngineRequest{PreviousEngine: name})
+		ui.app.QueueUpdateDraw(func() {
+			if err != nil {
+				ui.setStatus(fmt.Sprintf("[red]cannot replay %s: %v", tview.Escape(name), err))
+				return
+			}
+			ui.setStatus(fmt.Sprintf("[green]replayed %s as %s", tview.Escape(name), tview.Escape(resp.Status.Name)))
+		})
+	}()
+}
+
+func (ui *engineUI) showDetail(name string) {
+	ui.mu.Lock()
+	engine := ui.engines[name]
+	ui.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(ui.ctx)
+	d := newEngineDetailView(name, cancel)
+	d.setEngine(engine)
+	d.tree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape, event.Rune() == 'q':
+			ui.closeDetail()
+		case event.Rune() == 'e':
+			d.setAllExpanded(true)
+		case event.Rune() == 'c':
+			d.setAllExpanded(false)
+		case event.Rune() == 's':
+			ui.confirmStop(name)
+		case event.Rune() == 'r':
+			ui.replay(name)
+		default:
+			return event
+		}
+		return nil
+	})
+	d.status.SetText("[gray]enter: expand/collapse  e/c: expand/collapse all  s: stop  r: replay  esc: back")
+
+	ui.detail = d
+	ui.pages.AddAndSwitchToPage("engine", d.root, true)
+	go ui.listen(ctx, d)
+}
+
+func (ui *engineUI) closeDetail() {
+	if ui.detail == nil {
+		return
+	}
+	ui.detail.cancel()
+	ui.detail = nil
+	ui.pages.RemovePage("engine")
+	ui.pages.SwitchToPage("engines")
+}
+
+// listen streams the engine's log slices into the detail view
+func (ui *engineUI) listen(ctx context.Context, d *engineDetailView) {
+	logs, err := ui.client.Listen(ctx, &v1.ListenRequest{Name: d.name, Updates: true, Logs: v1.ListenRequestLogs_LOGS_RAW})
+	for err == nil {
+		var msg *v1.ListenResponse
+		msg, err = logs.Recv()
+		if err != nil {
+			break
+		}
+		ui.app.QueueUpdateDraw(func() {
+			if update := msg.GetUpdate(); update != nil {
+				d.setEngine(update)
+				return
+			}
+			d.addSlice(msg.GetSlice())
+		})
+	}
+	if err == io.EOF || ctx.Err() != nil {
+		return
+	}
+	ui.app.QueueUpdateDraw(func() {
+		d.status.SetText(fmt.Sprintf("[red]cannot listen to %s: %v", tview.Escape(d.name), err))
+	})
+}
+
+// engineDetailView shows the status of an engine and its log slices as a collapsible tree
+type engineDetailView struct {
+	name   string
+	cancel context.CancelFunc
+
+	root   *tview.Flex
+	header *tview.TextView
+	tree   *tview.TreeView
+	status *tview.TextView
+
+	slices map[string]*tview.TreeNode
+}
+
+func newEngineDetailView(name string, cancel context.CancelFunc) *engineDetailView {
+	d := &engineDetailView{
+		name:   name,
+		cancel: cancel,
+		header: tview.NewTextView(),
+		tree:   tview.NewTreeView(),
+		status: tview.NewTextView().SetDynamicColors(true),
+		slices: make(map[string]*tview.TreeNode),
+	}
+	d.header.SetBorder(true).SetTitle(" " + name + " ")
+
+	root := tview.NewTreeNode(name).SetSelectable(false)
+	d.tree.SetRoot(root).SetTopLevel(1)
+	d.tree.SetBorder(true).SetTitle(" Logs ")
+	d.tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+
+	d.root = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(d.header, 12, 0, false).
+		AddItem(d.tree, 0, 1, true).
+		AddItem(d.status, 1, 0, false)
+	return d
+}
+
+func (d *engineDetailView) setEngine(status *v1.EngineStatus) {
+	if status == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := renderEngineStatus(&buf, status); err != nil {
+		d.header.SetText(err.Error())
+		return
+	}
+	d.header.SetText(buf.String())
+}
+
+func (d *engineDetailView) addSlice(evt *v1.LogSliceEvent) {
+	if evt == nil {
+		return
+	}
+
+	root := d.tree.GetRoot()
+	if evt.Type == v1.LogSliceType_SLICE_PHASE {
+		root.AddChild(tview.NewTreeNode("── " + evt.Payload).SetColor(tcell.ColorYellow).SetSelectable(false))
+		return
+	}
+
+	name := evt.Name
+	if name == "" {
+		name = "(unsliced)"
+	}
+	node, ok := d.slices[name]
+	if !ok {
+		node = tview.NewTreeNode("▸ " + name).SetReference(name).SetExpanded(false).SetColor(tcell.ColorBlue)
+		d.slices[name] = node
+		root.AddChild(node)
+		if d.tree.GetCurrentNode() == nil {
+			d.tree.SetCurrentNode(node)
+		}
+	}
+
+	switch evt.Type {
+	case v1.LogSliceType_SLICE_DONE:
+		node.SetText("✓ " + name).SetColor(tcell.ColorGreen)
+	case v1.LogSliceType_SLICE_FAIL:
+		node.SetText("✗ " + name).SetColor(tcell.ColorRed)
+		node.AddChild(tview.NewTreeNode(evt.Payload).SetColor(tcell.ColorRed).SetSelectable(false))
+		node.SetExpanded(true)
+	case v1.LogSliceType_SLICE_ABANDONED:
+		node.SetText("- " + name).SetColor(tcell.ColorGray)
+	case v1.LogSliceType_SLICE_RESULT:
+		node.AddChild(tview.NewTreeNode("result: " + evt.Payload).SetColor(tcell.ColorYellow).SetSelectable(false))
+	case v1.LogSliceType_SLICE_CONTENT:
+		for _, line := range strings.Split(strings.TrimRight(evt.Payload, "\n"), "\n") {
+			node.AddChild(tview.NewTreeNode(line).SetSelectable(false))
+		}
+		if children := node.GetChildren(); len(children) > maxSliceLines {
+			node.SetChildren(children[len(children)-maxSliceLines:])
+		}
+	}
+}
+
+func (d *engineDetailView) setAllExpanded(expanded bool) {
+	for _, node := range d.slices {
+		node.SetExpanded(expanded)
+	}
+}
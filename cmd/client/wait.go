@@ -0,0 +1,194 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var waitCmdOpts struct {
+	outputOpts
+	For     string
+	Timeout time.Duration
+}
+
+// Exit codes of the wait command
+const (
+	waitExitFailed  = 1
+	waitExitTimeout = 2
+)
+
+// waitCmd represents the wait command
+var waitCmd = &cobra.Command{
+	Use:   "wait <name>",
+	Short: "Waits for an engine to reach a phase",
+	Long: `Waits for an engine to reach a phase, e.g.
+
+  text wait my-engine --for phase=done --timeout 10m
+
+Once the engine has reached (or passed) the phase, the command exits with
+the engine's success condition: 0 if the engine is still running or has
+succeeded, 1 if it has failed. If the timeout expires first, the command
+exits with 2.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeEngineNames(false, 1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, err := parseWaitCondition(waitCmdOpts.For)
+		if err != nil {
+			return err
+		}
+		printer, err := waitCmdOpts.enginePrinter()
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		conn := dial()
+		defer conn.Close()
+		client := v1.NewTextServiceClient(conn)
+
+		ctx := context.Background()
+		if waitCmdOpts.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, waitCmdOpts.Timeout)
+			defer cancel()
+		}
+
+		status, err := waitForPhase(ctx, client, args[0], target)
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return &exitError{Code: waitExitTimeout, Err: fmt.Errorf("timed out waiting for %s to reach phase %s", args[0], formatPhase(target))}
+		}
+		if err != nil {
+			return err
+		}
+
+		if isFinished(status.Phase) && !status.Conditions.GetSuccess() {
+			msg := fmt.Sprintf("engine %s failed", status.Name)
+			if status.Details != "" {
+				msg += ": " + status.Details
+			}
+			return &exitError{Code: waitExitFailed, Err: errors.New(msg)}
+		}
+		if !waitCmdOpts.isDefault() {
+			return printer.PrintObject(os.Stdout, status)
+		}
+		fmt.Printf("%s reached phase %s\n", status.Name, formatPhase(status.Phase))
+		return nil
+	},
+}
+
+// parseWaitCondition parses a condition of the form phase=<phase>
+func parseWaitCondition(cond string) (v1.EnginePhase, error) {
+	segs := strings.SplitN(cond, "=", 2)
+	if len(segs) != 2 || strings.TrimSpace(segs[0]) != "phase" {
+		return 0, fmt.Errorf("invalid condition %q: expected phase=<phase>", cond)
+	}
+	return parsePhase(segs[1])
+}
+
+// waitForPhase listens to engine updates until the engine has reached or passed the target phase
+func waitForPhase(ctx context.Context, client v1.TextServiceClient, name string, target v1.EnginePhase) (*v1.EngineStatus, error) {
+	// we start listening before we get the engine, so that we can't miss an update in between
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	updates, err := client.Listen(ctx, &v1.ListenRequest{Name: name, Updates: true, Logs: v1.ListenRequestLogs_LOGS_DISABLED})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.GetEngine(ctx, &v1.GetEngineRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	if hasReachedPhase(resp.Result.Phase, target) {
+		return resp.Result, nil
+	}
+
+	for {
+		msg, err := updates.Recv()
+		if err == io.EOF {
+			// the server closes the stream once the engine is done
+			resp, err := client.GetEngine(ctx, &v1.GetEngineRequest{Name: name})
+			if err != nil {
+				return nil, err
+			}
+			if hasReachedPhase(resp.Result.Phase, target) {
+				return resp.Result, nil
+			}
+			return nil, fmt.Errorf("engine %s stopped sending updates in phase %s", name, formatPhase(resp.Result.Phase))
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		status := msg.GetUpdate()
+		if status == nil {
+			continue
+		}
+		log.WithField("phase", formatPhase(status.Phase)).Debug("engine update")
+		if hasReachedPhase(status.Phase, target) {
+			return status, nil
+		}
+	}
+}
+
+// phaseOrder ranks the phases in the order an engine goes through them
+var phaseOrder = map[v1.EnginePhase]int{
+	v1.EnginePhase_PHASE_UNKNOWN:   0,
+	v1.EnginePhase_PHASE_WAITING:   1,
+	v1.EnginePhase_PHASE_PREPARING: 2,
+	v1.EnginePhase_PHASE_STARTING:  3,
+	v1.EnginePhase_PHASE_RUNNING:   4,
+	v1.EnginePhase_PHASE_DONE:      5,
+	v1.EnginePhase_PHASE_CLEANUP:   6,
+}
+
+func hasReachedPhase(current, target v1.EnginePhase) bool {
+	if current == target {
+		return true
+	}
+	if current == v1.EnginePhase_PHASE_UNKNOWN {
+		return false
+	}
+	return phaseOrder[current] >= phaseOrder[target]
+}
+
+func isFinished(p v1.EnginePhase) bool {
+	return p == v1.EnginePhase_PHASE_DONE || p == v1.EnginePhase_PHASE_CLEANUP
+}
+
+func init() {
+	rootCmd.AddCommand(waitCmd)
+	waitCmd.Flags().StringVar(&waitCmdOpts.For, "for", "phase=done", "condition to wait for, e.g. phase=running")
+	waitCmd.Flags().DurationVar(&waitCmdOpts.Timeout, "timeout", 0, "maximum time to wait, e.g. 10m. Waits forever if zero.")
+	_ = waitCmd.RegisterFlagCompletionFunc("for", completeWaitCondition)
+	addOutputFlags(waitCmd, &waitCmdOpts.outputOpts)
+}
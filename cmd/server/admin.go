@@ -0,0 +1,48 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/audit"
+	"github.com/bhojpur/text/pkg/retention"
+)
+
+// adminService implements the TextAdmin API. All of its calls require the admin role, hence
+// they are denied while calls are not authenticated.
+type adminService struct {
+	v1.UnimplementedTextAdminServer
+
+	Audit     *audit.Logger
+	Retention *retention.Collector
+}
+
+// GetRetentionReport lists the engines the retention policy would remove right now
+func (s *adminService) GetRetentionReport(ctx context.Context, req *v1.GetRetentionReportRequest) (*v1.GetRetentionReportResponse, error) {
+	return s.Retention.GetRetentionReport(ctx, req)
+}
+
+// ListAuditEvents lists the audit log
+func (s *adminService) ListAuditEvents(ctx context.Context, req *v1.ListAuditEventsRequest) (*v1.ListAuditEventsResponse, error) {
+	return s.Audit.ListAuditEvents(ctx, req)
+}
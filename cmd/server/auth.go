@@ -0,0 +1,166 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/auth"
+	"github.com/bhojpur/text/pkg/serverconfig"
+	"github.com/bhojpur/text/pkg/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// newAuthenticator returns the chain of authenticators configured in cfg
+func newAuthenticator(cfg serverconfig.Auth) (auth.Authenticator, error) {
+	var res auth.Chain
+	if cfg.TokenFile != "" {
+		a, err := auth.LoadTokenFile(cfg.TokenFile)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	if cfg.OIDC != nil {
+		a, err := auth.NewOIDCAuthenticator(*cfg.OIDC)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	if cfg.ClientCert {
+		res = append(res, auth.ClientCertAuthenticator{})
+	}
+	return res, nil
+}
+
+// newAuthInterceptor returns the interceptor which authenticates and authorizes calls as configured in cfg.
+// Engine ownership is looked up in the engine store.
+func newAuthInterceptor(cfg serverconfig.Auth, engines store.Store) (*auth.Interceptor, error) {
+	authn, err := newAuthenticator(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &auth.Interceptor{
+		Authenticator: authn,
+		Public:        healthMethods,
+		Authorizer: &auth.Authorizer{
+			AdminRole: cfg.AdminRole,
+			OwnerOf: func(ctx context.Context, name string) (string, error) {
+				e, err := engines.Get(ctx, name)
+				if err != nil {
+					return "", err
+				}
+				return e.Metadata.GetOwner(), nil
+			},
+		},
+	}, nil
+}
+
+// healthMethods are the grpc.health.v1 methods, which load balancers and clients call without credentials
+var healthMethods = []string{
+	"/" + healthpb.Health_ServiceDesc.ServiceName + "/Check",
+	"/" + healthpb.Health_ServiceDesc.ServiceName + "/Watch",
+}
+
+// serverAuth authenticates and authorizes calls with the auth settings in effect, which change
+// when the config is reloaded. Without an authenticator calls are not authenticated, but the
+// TextAdmin service is denied, as it requires the admin role.
+type serverAuth struct {
+	Engines store.Store
+
+	mu      sync.RWMutex
+	current *auth.Interceptor
+}
+
+// Set applies the auth settings
+func (a *serverAuth) Set(cfg serverconfig.Auth) error {
+	var ic *auth.Interceptor
+	if cfg.Enabled() {
+		var err error
+		ic, err = newAuthInterceptor(cfg, a.Engines)
+		if err != nil {
+			return err
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.current = ic
+	return nil
+}
+
+func (a *serverAuth) interceptor() *auth.Interceptor {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.current
+}
+
+// Authenticate implements auth.Authenticator, e.g. for the audit log
+func (a *serverAuth) Authenticate(ctx context.Context) (*auth.Identity, error) {
+	ic := a.interceptor()
+	if ic == nil {
+		return nil, auth.ErrNoCredentials
+	}
+	return ic.Authenticator.Authenticate(ctx)
+}
+
+// UnaryServerInterceptor returns the interceptor for unary calls
+func (a *serverAuth) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ic := a.interceptor()
+		if ic == nil {
+			if err := unauthenticated(info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}
+		return ic.UnaryServerInterceptor()(ctx, req, info, handler)
+	}
+}
+
+// StreamServerInterceptor returns the interceptor for streaming calls
+func (a *serverAuth) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ic := a.interceptor()
+		if ic == nil {
+			if err := unauthenticated(info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}
+		return ic.StreamServerInterceptor()(srv, ss, info, handler)
+	}
+}
+
+// unauthenticated decides on calls while no authenticator is configured
+func unauthenticated(method string) error {
+	if strings.HasPrefix(method, "/"+v1.TextAdmin_ServiceDesc.ServiceName+"/") {
+		return status.Errorf(codes.PermissionDenied, "%s requires the admin role, but auth configures no authenticator", method)
+	}
+	return nil
+}
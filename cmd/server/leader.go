@@ -0,0 +1,62 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/bhojpur/text/pkg/leader"
+	"github.com/bhojpur/text/pkg/serverconfig"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// newElector returns the leader elector configured in cfg, or nil if the server runs as a single replica
+func newElector(cfg *serverconfig.Config, db *sql.DB) (leader.Elector, error) {
+	le := cfg.LeaderElection
+	switch le.Kind {
+	case serverconfig.LeaderElectionKubernetes:
+		clientSet, err := newKubernetesClient(le.Kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		return &leader.KubernetesElector{
+			ClientSet: clientSet,
+			Namespace: le.Namespace,
+			Name:      le.LeaseName,
+		}, nil
+	case serverconfig.LeaderElectionPostgres:
+		return &leader.PostgresElector{DB: db, Key: le.LockKey}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// newKubernetesClient connects to the API server for kubernetes leader election. An empty
+// kubeconfig path falls back to the in-cluster config.
+func newKubernetesClient(kubeconfig string) (kubernetes.Interface, error) {
+	kubecfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load kubeconfig for leader election: %w", err)
+	}
+	return kubernetes.NewForConfig(kubecfg)
+}
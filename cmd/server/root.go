@@ -24,21 +24,37 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/bhojpur/text/pkg/serverconfig"
+	"github.com/bhojpur/text/pkg/tlsutil"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpcreflection "google.golang.org/grpc/reflection"
 )
 
-var verbose bool
+var (
+	verbose    bool
+	configFile string
+	tlsConfig  tlsutil.ServerConfig
+	reflection bool
+)
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "text",
 	Short: "Bhojpur Text is a data processing engine powered by Kubernetes",
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if verbose {
 			log.SetLevel(log.DebugLevel)
 			log.Debug("verbose logging enabled")
 		}
+		if tlsConfig.Enabled() || tlsConfig.ClientCAFile != "" {
+			if _, err := tlsConfig.TLSConfig(); err != nil {
+				return err
+			}
+		}
+		return nil
 	},
 
 	// Uncomment the following line if your bare application
@@ -57,4 +73,59 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "en/disable verbose logging")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", os.Getenv("TEXT_SERVER_CONFIG"), "server config file (defaults to TEXT_SERVER_CONFIG env var)")
+	rootCmd.PersistentFlags().StringVar(&tlsConfig.CertFile, "tls-cert", "", "TLS certificate the gRPC server presents. Serves plaintext if empty.")
+	rootCmd.PersistentFlags().StringVar(&tlsConfig.KeyFile, "tls-key", "", "key of the TLS certificate")
+	rootCmd.PersistentFlags().StringVar(&tlsConfig.ClientCAFile, "tls-client-ca", "", "CA bundle used to verify client certificates (enables mutual TLS)")
+	rootCmd.PersistentFlags().BoolVar(&tlsConfig.RequireClientCert, "tls-require-client-cert", false, "reject clients which do not present a certificate signed by the client CA")
+	rootCmd.PersistentFlags().BoolVar(&reflection, "grpc-reflection", true, "serve gRPC server reflection, e.g. for grpcurl")
+}
+
+// loadConfig loads the server config file. The TLS flags take precedence over the file.
+// The server reloads the config with loadConfig, too, so that the flags keep precedence.
+func loadConfig() (*serverconfig.Config, error) {
+	cfg, err := serverconfig.Load(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := rootCmd.PersistentFlags()
+	if flags.Changed("tls-cert") {
+		cfg.TLS.CertFile = tlsConfig.CertFile
+	}
+	if flags.Changed("tls-key") {
+		cfg.TLS.KeyFile = tlsConfig.KeyFile
+	}
+	if flags.Changed("tls-client-ca") {
+		cfg.TLS.ClientCAFile = tlsConfig.ClientCAFile
+	}
+	if flags.Changed("tls-require-client-cert") {
+		cfg.TLS.RequireClientCert = tlsConfig.RequireClientCert
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// grpcServerOptions returns the gRPC server options derived from the TLS settings of the config
+func grpcServerOptions(tls tlsutil.ServerConfig) ([]grpc.ServerOption, error) {
+	if !tls.Enabled() {
+		return nil, nil
+	}
+	cfg, err := tls.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(cfg))}, nil
+}
+
+// registerReflection registers the server reflection service if --grpc-reflection is set. The
+// reflection service looks up the services of srv when it is asked, so the order of registration
+// does not matter.
+func registerReflection(srv *grpc.Server) {
+	if !reflection {
+		return
+	}
+	grpcreflection.Register(srv)
 }
@@ -0,0 +1,464 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/audit"
+	"github.com/bhojpur/text/pkg/executor"
+	"github.com/bhojpur/text/pkg/health"
+	"github.com/bhojpur/text/pkg/history"
+	"github.com/bhojpur/text/pkg/leader"
+	"github.com/bhojpur/text/pkg/metrics"
+	"github.com/bhojpur/text/pkg/names"
+	"github.com/bhojpur/text/pkg/notify"
+	"github.com/bhojpur/text/pkg/pipeline"
+	"github.com/bhojpur/text/pkg/retention"
+	"github.com/bhojpur/text/pkg/retry"
+	"github.com/bhojpur/text/pkg/schedule"
+	"github.com/bhojpur/text/pkg/serverconfig"
+	"github.com/bhojpur/text/pkg/store"
+	"github.com/bhojpur/text/pkg/tracing"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Starts the Bhojpur Text server",
+	Long: `Starts the Bhojpur Text server. It serves the gRPC API on listen.grpc, using TLS
+if tls.certFile and tls.keyFile are set, and keeps the engines in the store.
+Prometheus metrics are served on /metrics of listen.http, health checks on
+/healthz and /readyz as well as with the grpc.health.v1 service. The checks cover
+the store, the executor workdir and, with kubernetes leader election, the API
+server. If tracing.file
+is set, the spans of all calls and of the engines are appended to it.
+Engines run the command of their spec in specDir as a process in
+executor.workdir, at most as many at a time as limits permit. Failed engines are
+retried according to the retries policy of their spec. Engine updates are
+published to the subscribers of every replica. With leaderElection only the
+leader runs engines, starts the engines of the schedules, advances pipelines and
+removes finished engines, including their working directories and logs,
+according to the retention policy. Pipelines are run from the dependsOn fields
+of the specs.
+Calls are authenticated and authorized if auth configures an authenticator.
+Calls which start or stop engines are recorded in the audit log. The TextAdmin
+service is denied unless calls are authenticated, as it requires the admin role.
+
+The server reloads the config file on SIGHUP (see "text config") and stops
+gracefully on SIGINT or SIGTERM.`,
+	Args: cobra.ExactArgs(0),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return serve(ctx, cfg)
+	},
+}
+
+// shutdownTimeout is the time calls in progress get to finish when the server stops
+const shutdownTimeout = 10 * time.Second
+
+// serve runs the server until the context is canceled
+func serve(ctx context.Context, cfg *serverconfig.Config) error {
+	db, err := sql.Open("postgres", cfg.Store.DSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	engines := &store.SQLStore{DB: db}
+	if err := engines.Migrate(ctx); err != nil {
+		return fmt.Errorf("cannot create engine store: %w", err)
+	}
+	hub := &notify.Hub{}
+	m, err := metrics.New()
+	if err != nil {
+		return fmt.Errorf("cannot create metrics: %w", err)
+	}
+	specs, graph, err := loadSpecs(cfg.SpecDir)
+	if err != nil {
+		return err
+	}
+	if err := checkScheduledSpecs(cfg.Schedules, specs); err != nil {
+		return err
+	}
+	nameGenerator, err := names.NewGenerator(names.WithDNS1123())
+	if err != nil {
+		return err
+	}
+	local := &executor.Local{Workdir: cfg.Executor.Workdir}
+	manager := executor.NewManager(engines, local, specs, cfg.Limits)
+	retrier := retry.NewRetrier(cfg.Retries)
+	manager.Retrier = retrier
+	service := &textService{Engines: engines, Manager: manager, Names: nameGenerator, Hub: hub, Metrics: m}
+
+	pipelineStore := &pipeline.SQLStore{DB: db}
+	if err := pipelineStore.Migrate(ctx); err != nil {
+		return fmt.Errorf("cannot create pipeline store: %w", err)
+	}
+	service.Pipelines = pipeline.NewRunner(graph, pipeline.StarterFunc(service.startStage))
+	service.Pipelines.Store = pipelineStore
+
+	historyStore := &history.SQLStore{DB: db}
+	if err := historyStore.Migrate(ctx); err != nil {
+		return fmt.Errorf("cannot create engine history: %w", err)
+	}
+	service.History = history.NewRecorder(historyStore)
+
+	// engines leave their working directory and log file in the executor workdir
+	collector := &retention.Collector{
+		Policy:  cfg.Retention.Policy(),
+		Engines: engines,
+		Logs:    retention.RemoverFunc(local.RemoveLogs),
+		Spool:   retention.DirRemover(local.EngineDir()),
+	}
+
+	scheduleState := &schedule.SQLState{DB: db}
+	if err := scheduleState.Migrate(ctx); err != nil {
+		return fmt.Errorf("cannot create schedule state: %w", err)
+	}
+	scheduler, err := schedule.NewScheduler(cfg.Schedules, schedule.StarterFunc(func(ctx context.Context, spec string, md *v1.EngineMetadata) error {
+		_, err := service.startSpec(ctx, spec, md)
+		return err
+	}), scheduleState)
+	if err != nil {
+		return err
+	}
+
+	auditStore := &audit.SQLStore{DB: db}
+	if err := auditStore.Migrate(ctx); err != nil {
+		return fmt.Errorf("cannot create audit log: %w", err)
+	}
+	auditLog := &audit.Logger{Store: auditStore}
+
+	authn := &serverAuth{Engines: engines}
+	if err := authn.Set(cfg.Auth); err != nil {
+		return fmt.Errorf("cannot set up authentication: %w", err)
+	}
+	if !cfg.Auth.Enabled() {
+		log.Warn("no authenticator configured - calls are not authenticated and the TextAdmin service is denied")
+	}
+	auditLog.Authenticator = authn
+
+	tp, err := newTracerProvider(cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tp.Shutdown(context.Background()); err != nil {
+			log.WithError(err).Warn("cannot flush traces")
+		}
+	}()
+	lifecycle := tracing.NewLifecycle(tp)
+	manager.Lifecycle = lifecycle
+
+	// the tracing, metrics and audit interceptors run first, so that calls which fail authorization are recorded too
+	var (
+		unary = []grpc.UnaryServerInterceptor{
+			tracing.UnaryServerInterceptor(tp),
+			m.UnaryServerInterceptor(),
+			auditLog.UnaryServerInterceptor(),
+			authn.UnaryServerInterceptor(),
+		}
+		stream = []grpc.StreamServerInterceptor{
+			tracing.StreamServerInterceptor(tp),
+			m.StreamServerInterceptor(),
+			auditLog.StreamServerInterceptor(),
+			authn.StreamServerInterceptor(),
+		}
+	)
+
+	opts, err := grpcServerOptions(cfg.TLS)
+	if err != nil {
+		return err
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	srv := grpc.NewServer(opts...)
+	v1.RegisterTextServiceServer(srv, service)
+	v1.RegisterTextAdminServer(srv, &adminService{Audit: auditLog, Retention: collector})
+	registerReflection(srv)
+
+	// the executor creates the directories on demand, but they must be writable from the start
+	for _, dir := range []string{local.EngineDir(), local.LogDir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("executor.workdir: %w", err)
+		}
+	}
+	checks := map[string]health.Checker{
+		"store":    health.PingCheck(db),
+		"executor": health.WritableDirCheck(local.EngineDir()),
+		"logs":     health.WritableDirCheck(local.LogDir()),
+	}
+	if le := cfg.LeaderElection; le.Kind == serverconfig.LeaderElectionKubernetes {
+		clientSet, err := newKubernetesClient(le.Kubeconfig)
+		if err != nil {
+			return err
+		}
+		checks["kubernetes"] = health.LeaseCheck(clientSet, le.Namespace, le.LeaseName)
+	}
+	// the monitor reports the services registered so far, hence it's registered last
+	monitor := health.NewMonitor(checks)
+	monitor.Register(srv)
+
+	lis, err := net.Listen("tcp", cfg.Listen.GRPC)
+	if err != nil {
+		return err
+	}
+	httpLis, err := net.Listen("tcp", cfg.Listen.HTTP)
+	if err != nil {
+		lis.Close()
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	healthHandler := monitor.Handler()
+	mux.Handle("/healthz", healthHandler)
+	mux.Handle("/readyz", healthHandler)
+	httpSrv := &http.Server{Handler: mux}
+	defer httpSrv.Close()
+
+	errc := make(chan error, 4)
+	go func() { errc <- srv.Serve(lis) }()
+	go func() {
+		if err := httpSrv.Serve(httpLis); err != http.ErrServerClosed {
+			errc <- err
+		}
+	}()
+	log.WithField("address", cfg.Listen.GRPC).WithField("tls", cfg.TLS.Enabled()).Info("serving gRPC API")
+	log.WithField("address", cfg.Listen.HTTP).Info("serving metrics and health checks")
+
+	// background tasks run until serve returns
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	run := func(task func(ctx context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := task(ctx); err != nil {
+				errc <- err
+			}
+		}()
+	}
+
+	run(func(ctx context.Context) error {
+		monitor.Run(ctx)
+		return nil
+	})
+
+	// every replica publishes the engine updates of all replicas to its subscribers
+	leaderStatus := leader.NewStatus(leader.DefaultIdentity())
+	listener := &notify.Listener{
+		DSN:  cfg.Store.DSN,
+		Load: engines.Get,
+		Resync: func(ctx context.Context) ([]*v1.EngineStatus, error) {
+			unfinished := []*v1.FilterExpression{{Terms: []*v1.FilterTerm{{Field: "phase", Value: "done", Negate: true}}}}
+			res, _, err := engines.Find(ctx, unfinished, nil, 0, 0)
+			return res, err
+		},
+		Hub: hub,
+		// only the leader runs and traces engines, records history and advances pipelines, all others read them from the store
+		OnUpdate: func(ctx context.Context, e *v1.EngineStatus) {
+			m.ObserveEngine(e)
+			if !leaderStatus.IsLeader() {
+				return
+			}
+			manager.Update(ctx, e)
+			lifecycle.Observe(e)
+			if _, err := service.History.Record(ctx, e); err != nil {
+				log.WithError(err).WithField("name", e.Name).Warn("cannot record engine history")
+			}
+			service.Pipelines.Update(ctx, e)
+		},
+		OnDelete: func(ctx context.Context, name string) {
+			m.ForgetEngine(name)
+			lifecycle.Forget(name)
+			if !leaderStatus.IsLeader() {
+				return
+			}
+			if err := service.History.Forget(ctx, name); err != nil {
+				log.WithError(err).WithField("name", name).Warn("cannot remove engine history")
+			}
+		},
+	}
+	run(func(ctx context.Context) error {
+		if err := listener.Run(ctx); err != nil {
+			return fmt.Errorf("cannot listen for engine updates: %w", err)
+		}
+		return nil
+	})
+
+	// SIGHUP reloads the config
+	reloader := serverconfig.NewReloader(configFile, cfg)
+	reloader.Load = loadConfig
+	reloader.OnReload = func(cfg *serverconfig.Config, changed []string) error {
+		specs, graph, err := loadSpecs(cfg.SpecDir)
+		if err != nil {
+			return err
+		}
+		if err := checkScheduledSpecs(cfg.Schedules, specs); err != nil {
+			return err
+		}
+		if err := authn.Set(cfg.Auth); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+		manager.SetSpecs(specs)
+		service.Pipelines.SetGraph(graph)
+		if err := scheduler.SetSchedules(cfg.Schedules); err != nil {
+			return fmt.Errorf("schedules: %w", err)
+		}
+		collector.SetPolicy(cfg.Retention.Policy())
+		manager.SetLimits(cfg.Limits)
+		retrier.SetPolicies(cfg.Retries)
+		return nil
+	}
+	run(func(ctx context.Context) error {
+		reloader.Run(ctx)
+		return nil
+	})
+
+	// only the leader runs the singleton tasks
+	elector, err := newElector(cfg, db)
+	if err != nil {
+		return err
+	}
+	tasks := leader.Tasks(manager.Run, collector.Start, scheduler.Run)
+	callbacks := leaderStatus.Track(leader.Callbacks{
+		OnStartedLeading: func(ctx context.Context) {
+			// the previous leader may have advanced the pipelines
+			if err := service.Pipelines.Load(ctx); err != nil {
+				log.WithError(err).Warn("cannot load pipelines")
+			}
+			tasks(ctx)
+		},
+		OnNewLeader: func(identity string) {
+			log.WithField("leader", identity).Info("new leader elected")
+		},
+	})
+	run(func(ctx context.Context) error {
+		if elector == nil {
+			// without leader election this is the only replica
+			callbacks.OnStartedLeading(ctx)
+			return nil
+		}
+		if err := elector.Run(ctx, callbacks); err != nil {
+			return fmt.Errorf("cannot take part in leader election: %w", err)
+		}
+		return nil
+	})
+
+	select {
+	case <-ctx.Done():
+	case err := <-errc:
+		srv.Stop()
+		return err
+	}
+
+	// streams like Subscribe only end when the client goes away, hence graceful stops are bounded
+	log.Info("shutting down")
+	monitor.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancelShutdown()
+	if err := httpSrv.Shutdown(shutdownCtx); err != nil {
+		log.WithError(err).Warn("cannot stop HTTP server gracefully")
+	}
+	select {
+	case <-stopped:
+	case <-shutdownCtx.Done():
+		srv.Stop()
+	}
+	return nil
+}
+
+// newTracerProvider creates the tracer provider of the server. Without an exporter spans are dropped.
+func newTracerProvider(cfg serverconfig.Tracing) (*sdktrace.TracerProvider, error) {
+	if !cfg.Enabled() {
+		return sdktrace.NewTracerProvider(), nil
+	}
+	exporter, err := tracing.NewFileExporter(cfg.File)
+	if err != nil {
+		return nil, fmt.Errorf("tracing.file: %w", err)
+	}
+	return tracing.NewProvider(exporter, "text-server"), nil
+}
+
+// loadSpecs loads the engine specs in dir, and the pipeline graph their dependencies form.
+// Without a spec directory there are no specs.
+func loadSpecs(dir string) (executor.Specs, pipeline.Graph, error) {
+	if dir == "" {
+		return executor.Specs{}, pipeline.Graph{}, nil
+	}
+	specs, err := executor.LoadSpecs(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("specDir: %w", err)
+	}
+	graph := make(pipeline.Graph, len(specs))
+	for name, spec := range specs {
+		graph[name] = spec.DependsOn
+	}
+	if err := graph.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("specDir: %w", err)
+	}
+	return specs, graph, nil
+}
+
+// checkScheduledSpecs makes sure that the schedules only start specs of the spec directory
+func checkScheduledSpecs(schedules []schedule.Schedule, specs executor.Specs) error {
+	err := schedule.CheckSpecs(schedules, func(spec string) bool {
+		_, ok := specs[spec]
+		return ok
+	})
+	if err != nil {
+		return fmt.Errorf("schedules: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,141 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/executor"
+	"github.com/bhojpur/text/pkg/names"
+	"github.com/bhojpur/text/pkg/pipeline"
+	"github.com/bhojpur/text/pkg/queue"
+	"github.com/bhojpur/text/pkg/store"
+	"google.golang.org/protobuf/proto"
+)
+
+// notifyingStore passes every change on in order, like the notify listener does on the leader
+type notifyingStore struct {
+	store.MemoryStore
+	updates chan *v1.EngineStatus
+}
+
+func (s *notifyingStore) Store(ctx context.Context, status *v1.EngineStatus) error {
+	if err := s.MemoryStore.Store(ctx, status); err != nil {
+		return err
+	}
+	s.updates <- proto.Clone(status).(*v1.EngineStatus)
+	return nil
+}
+
+func TestServePipeline(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	specs := executor.Specs{
+		"extract": {Command: []string{"sh", "-c", `echo '{"type":"url","payload":"https://example.com"}' > "$TEXT_RESULTS"`}},
+		"index": {
+			DependsOn: []string{"extract"},
+			Command:   []string{"sh", "-c", `echo "$TEXT_ANNOTATIONS" | grep -q 'input.extract.url":"https://example.com'`},
+		},
+	}
+	graph := pipeline.Graph{"extract": nil, "index": {"extract"}}
+
+	engines := &notifyingStore{updates: make(chan *v1.EngineStatus, 100)}
+	nameGenerator, err := names.NewGenerator(names.WithDNS1123())
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := executor.NewManager(engines, &executor.Local{Workdir: t.TempDir()}, specs, queue.Limits{})
+	service := &textService{Engines: engines, Manager: manager, Names: nameGenerator}
+	service.Pipelines = pipeline.NewRunner(graph, pipeline.StarterFunc(service.startStage))
+	service.Pipelines.Store = &pipeline.MemoryStore{}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		manager.Run(ctx)
+		done <- struct{}{}
+	}()
+	go func() {
+		defer func() { done <- struct{}{} }()
+		for {
+			select {
+			case e := <-engines.updates:
+				manager.Update(ctx, e)
+				service.Pipelines.Update(ctx, e)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	defer func() {
+		cancel()
+		<-done
+		<-done
+	}()
+
+	resp, err := service.StartEngine(ctx, &v1.StartEngineRequest{EnginePath: ".text/index.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec := resp.Status.Metadata.EngineSpecName; spec != "extract" {
+		t.Errorf("expected the first stage to start, got spec %s", spec)
+	}
+	var name string
+	for _, a := range resp.Status.Metadata.Annotations {
+		if a.Key == pipeline.AnnotationPipeline {
+			name = a.Value
+		}
+	}
+	if name == "" {
+		t.Fatalf("expected the engine to belong to a pipeline, got %v", resp.Status.Metadata.Annotations)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		p, err := service.GetPipeline(ctx, &v1.GetPipelineRequest{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Result.Phase == v1.EnginePhase_PHASE_DONE {
+			if !p.Result.Success {
+				t.Errorf("expected the pipeline to succeed, got %v", p.Result)
+			}
+			for _, s := range p.Result.Stages {
+				if s.State != v1.PipelineStageState_STAGE_SUCCEEDED {
+					t.Errorf("expected stage %s to succeed, got %v", s.Name, s.State)
+				}
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pipeline did not finish: %v", p.Result)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// dependent specs only run as part of a pipeline
+	if _, err := service.StartEngine(ctx, &v1.StartEngineRequest{EnginePath: "index", NameSuffix: "nightly"}); err == nil {
+		t.Error("expected pipelines with a name suffix to be rejected")
+	}
+}
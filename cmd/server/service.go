@@ -0,0 +1,323 @@
+package cmd
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/auth"
+	"github.com/bhojpur/text/pkg/executor"
+	"github.com/bhojpur/text/pkg/history"
+	"github.com/bhojpur/text/pkg/metrics"
+	"github.com/bhojpur/text/pkg/names"
+	"github.com/bhojpur/text/pkg/notify"
+	"github.com/bhojpur/text/pkg/pagination"
+	"github.com/bhojpur/text/pkg/pipeline"
+	"github.com/bhojpur/text/pkg/retry"
+	"github.com/bhojpur/text/pkg/store"
+	"github.com/bhojpur/text/pkg/tracing"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxNameRetries limits the attempts to find an engine name which is not taken
+const maxNameRetries = 5
+
+// textService implements the TextService API on top of the engine store. Engines are created
+// in the store by every replica and run by the executor manager of the leader.
+type textService struct {
+	v1.UnimplementedTextServiceServer
+
+	Engines store.Store
+	// Manager runs the engines on the leader, and knows the specs engines are started from
+	Manager *executor.Manager
+	// Names generates engine names
+	Names *names.Generator
+	// Hub delivers the engine updates of all replicas to Subscribe
+	Hub *notify.Hub
+	// Metrics counts the open subscriptions
+	Metrics *metrics.Metrics
+	// History records the engine updates on the leader
+	History *history.Recorder
+	// Pipelines runs the pipelines on the leader, and stores them for all replicas
+	Pipelines *pipeline.Runner
+}
+
+// GetEngine returns the status of an engine
+func (s *textService) GetEngine(ctx context.Context, req *v1.GetEngineRequest) (*v1.GetEngineResponse, error) {
+	e, err := s.Engines.Get(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.GetEngineResponse{Result: e}, nil
+}
+
+// ListEngines lists the engines of the store
+func (s *textService) ListEngines(ctx context.Context, req *v1.ListEnginesRequest) (*v1.ListEnginesResponse, error) {
+	engines, _, err := s.Engines.Find(ctx, nil, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return pagination.List(engines, req)
+}
+
+// Subscribe streams the updates of the engines matching the filter until the client goes away
+func (s *textService) Subscribe(req *v1.SubscribeRequest, srv v1.TextService_SubscribeServer) error {
+	sub := s.Hub.Subscribe(req.Filter)
+	defer sub.Close()
+	defer s.Metrics.TrackSubscriber("Subscribe")()
+
+	for {
+		select {
+		case <-srv.Context().Done():
+			return nil
+		case e, ok := <-sub.C:
+			if !ok {
+				if sub.Dropped() {
+					return status.Error(codes.ResourceExhausted, "subscription did not keep up with the updates - please subscribe again")
+				}
+				return nil
+			}
+			if err := srv.Send(&v1.SubscribeResponse{Result: e}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetEngineHistory returns the phase transitions and condition changes of an engine
+func (s *textService) GetEngineHistory(ctx context.Context, req *v1.GetEngineHistoryRequest) (*v1.GetEngineHistoryResponse, error) {
+	return s.History.GetEngineHistory(ctx, req)
+}
+
+// GetPipeline returns the status of a pipeline
+func (s *textService) GetPipeline(ctx context.Context, req *v1.GetPipelineRequest) (*v1.GetPipelineResponse, error) {
+	return s.Pipelines.GetPipeline(ctx, req)
+}
+
+// startSpec starts an engine from one of the server's specs, e.g. on a schedule
+func (s *textService) startSpec(ctx context.Context, spec string, md *v1.EngineMetadata) (*v1.EngineStatus, error) {
+	return s.start(ctx, spec, md, "", nil)
+}
+
+// startStage starts the engine of a pipeline stage. Unlike startSpec it never starts a pipeline
+// of its own.
+func (s *textService) startStage(ctx context.Context, spec string, md *v1.EngineMetadata) (*v1.EngineStatus, error) {
+	return s.createEngine(ctx, spec, md, "", nil)
+}
+
+// start starts an engine of the spec, or a pipeline if the spec depends on others. Pipelines
+// start right away and with generated names; the engine of their first stage is returned.
+func (s *textService) start(ctx context.Context, spec string, md *v1.EngineMetadata, nameSuffix string, waitUntil *timestamppb.Timestamp) (*v1.EngineStatus, error) {
+	sp, ok := s.Manager.Spec(spec)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "spec %s does not exist", spec)
+	}
+	if len(sp.DependsOn) == 0 || s.Pipelines == nil {
+		return s.createEngine(ctx, spec, md, nameSuffix, waitUntil)
+	}
+	if nameSuffix != "" || waitUntil != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "spec %s starts a pipeline, which supports neither name suffixes nor start times", spec)
+	}
+
+	// all stages, including those the leader starts later on, belong to the trace of this call
+	if md == nil {
+		md = &v1.EngineMetadata{}
+	} else {
+		md = proto.Clone(md).(*v1.EngineMetadata)
+	}
+	tracing.Annotate(ctx, md)
+	p, err := s.Pipelines.Start(ctx, spec, md)
+	if err != nil {
+		return nil, err
+	}
+	var details []string
+	for _, stage := range p.Stages {
+		if stage.Engine == nil {
+			continue
+		}
+		if stage.Engine.Name != "" {
+			return stage.Engine, nil
+		}
+		details = append(details, stage.Name+": "+stage.Engine.Details)
+	}
+	return nil, status.Errorf(codes.Internal, "pipeline %s has not started any stage: %s", p.Name, strings.Join(details, ", "))
+}
+
+// createEngine creates an engine of the spec in the store, for the leader to run it. Engines
+// with a start time in the future wait until then.
+func (s *textService) createEngine(ctx context.Context, spec string, md *v1.EngineMetadata, nameSuffix string, waitUntil *timestamppb.Timestamp) (*v1.EngineStatus, error) {
+	if _, ok := s.Manager.Spec(spec); !ok {
+		return nil, status.Errorf(codes.NotFound, "spec %s does not exist", spec)
+	}
+	if md == nil {
+		md = &v1.EngineMetadata{}
+	} else {
+		md = proto.Clone(md).(*v1.EngineMetadata)
+	}
+	// the engine continues the trace of the call which started it
+	tracing.Annotate(ctx, md)
+	now := time.Now()
+	md.EngineSpecName = spec
+	md.Created = timestamppb.New(now)
+	md.Finished = nil
+
+	name, err := s.engineName(ctx, spec, nameSuffix)
+	if err != nil {
+		return nil, err
+	}
+	e := &v1.EngineStatus{
+		Name:       name,
+		Metadata:   md,
+		Phase:      v1.EnginePhase_PHASE_PREPARING,
+		Conditions: &v1.EngineConditions{},
+	}
+	if waitUntil != nil && waitUntil.AsTime().After(now) {
+		e.Phase = v1.EnginePhase_PHASE_WAITING
+		e.Conditions.WaitUntil = waitUntil
+		e.Details = "waiting until " + waitUntil.AsTime().Format(time.RFC3339)
+	}
+	if err := s.Engines.Store(ctx, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// engineName finds a name for an engine of the spec which is not taken
+func (s *textService) engineName(ctx context.Context, spec, suffix string) (string, error) {
+	for retry := 0; retry < maxNameRetries; retry++ {
+		n, err := s.Names.Name(retry)
+		if err != nil {
+			return "", status.Errorf(codes.Internal, "cannot generate engine name: %v", err)
+		}
+		name := spec + "-" + n
+		if suffix != "" {
+			name += "-" + suffix
+		}
+		_, err = s.Engines.Get(ctx, name)
+		if status.Code(err) == codes.NotFound {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", status.Errorf(codes.ResourceExhausted, "cannot find an engine name which is not taken")
+}
+
+// StartEngine starts an engine of one of the server's specs. The engine path names the spec,
+// e.g. .text/index.yaml starts the spec index. The repository is recorded in the metadata,
+// but not fetched: engines run the commands of the specs in the server's spec directory.
+func (s *textService) StartEngine(ctx context.Context, req *v1.StartEngineRequest) (*v1.StartEngineResponse, error) {
+	if req.EnginePath == "" {
+		return nil, status.Error(codes.InvalidArgument, "engine path is required")
+	}
+	if len(req.EngineYaml) > 0 || len(req.Sideload) > 0 {
+		return nil, status.Error(codes.Unimplemented, "this server runs the specs of its spec directory, engine YAML and sideloads are not supported")
+	}
+	if strings.ContainsAny(req.NameSuffix, "/ ") {
+		return nil, status.Error(codes.InvalidArgument, "name suffix must not contain slashes or spaces")
+	}
+
+	md := req.Metadata
+	if md == nil {
+		md = &v1.EngineMetadata{}
+	} else {
+		md = proto.Clone(md).(*v1.EngineMetadata)
+	}
+	if id := auth.IdentityFromContext(ctx); id != nil {
+		md.Owner = id.Name
+	}
+
+	e, err := s.start(ctx, executor.SpecName(req.EnginePath), md, req.NameSuffix, req.WaitUntil)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.StartEngineResponse{Status: e}, nil
+}
+
+// StartFromPreviousEngine starts an engine of the same spec and with the same metadata as a previous one
+func (s *textService) StartFromPreviousEngine(ctx context.Context, req *v1.StartFromPreviousEngineRequest) (*v1.StartEngineResponse, error) {
+	prev, err := s.Engines.Get(ctx, req.PreviousEngine)
+	if err != nil {
+		return nil, err
+	}
+
+	md := proto.Clone(prev.Metadata).(*v1.EngineMetadata)
+	annotations := md.Annotations[:0]
+	for _, a := range md.Annotations {
+		// the new engine has a history of its own, and starts a pipeline of its own if the spec has dependencies
+		if a.Key == executor.AnnotationStopRequested || strings.HasPrefix(a.Key, retry.AnnotationAttemptPrefix) ||
+			a.Key == pipeline.AnnotationPipeline || a.Key == pipeline.AnnotationStage || strings.HasPrefix(a.Key, pipeline.AnnotationInputPrefix) {
+			continue
+		}
+		annotations = append(annotations, a)
+	}
+	md.Annotations = annotations
+	if id := auth.IdentityFromContext(ctx); id != nil {
+		md.Owner = id.Name
+	}
+
+	e, err := s.start(ctx, md.EngineSpecName, md, "", req.WaitUntil)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.StartEngineResponse{Status: e}, nil
+}
+
+// StopEngine asks the leader to stop an engine. Engines which have not started yet never start.
+func (s *textService) StopEngine(ctx context.Context, req *v1.StopEngineRequest) (*v1.StopEngineResponse, error) {
+	e, err := s.Engines.Get(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if e.Phase == v1.EnginePhase_PHASE_DONE {
+		return nil, status.Errorf(codes.FailedPrecondition, "engine %s is done already", req.Name)
+	}
+	if executor.StopRequested(e) {
+		return &v1.StopEngineResponse{}, nil
+	}
+
+	if e.Metadata == nil {
+		e.Metadata = &v1.EngineMetadata{}
+	}
+	e.Metadata.Annotations = append(e.Metadata.Annotations, &v1.Annotation{Key: executor.AnnotationStopRequested, Value: "true"})
+	if err := s.Engines.Store(ctx, e); err != nil {
+		return nil, err
+	}
+	return &v1.StopEngineResponse{}, nil
+}
+
+// StartLocalEngine is not supported, as engines run the specs of the server's spec directory
+func (s *textService) StartLocalEngine(srv v1.TextService_StartLocalEngineServer) error {
+	return status.Error(codes.Unimplemented, "this server runs the specs of its spec directory, local engines are not supported")
+}
+
+// Listen is not supported. Engine logs are only available on the leader which runs the engine,
+// use Subscribe to follow engine updates.
+func (s *textService) Listen(req *v1.ListenRequest, srv v1.TextService_ListenServer) error {
+	return status.Error(codes.Unimplemented, "listening to engines is not supported, use Subscribe to follow engine updates")
+}
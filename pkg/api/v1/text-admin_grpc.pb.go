@@ -0,0 +1,141 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// TextAdminClient is the client API for TextAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type TextAdminClient interface {
+	// GetRetentionReport returns the engines the retention policy would remove, without removing them.
+	GetRetentionReport(ctx context.Context, in *GetRetentionReportRequest, opts ...grpc.CallOption) (*GetRetentionReportResponse, error)
+	// ListAuditEvents searches the audit log of state-changing calls.
+	ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error)
+}
+
+type textAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTextAdminClient(cc grpc.ClientConnInterface) TextAdminClient {
+	return &textAdminClient{cc}
+}
+
+func (c *textAdminClient) GetRetentionReport(ctx context.Context, in *GetRetentionReportRequest, opts ...grpc.CallOption) (*GetRetentionReportResponse, error) {
+	out := new(GetRetentionReportResponse)
+	err := c.cc.Invoke(ctx, "/v1.TextAdmin/GetRetentionReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *textAdminClient) ListAuditEvents(ctx context.Context, in *ListAuditEventsRequest, opts ...grpc.CallOption) (*ListAuditEventsResponse, error) {
+	out := new(ListAuditEventsResponse)
+	err := c.cc.Invoke(ctx, "/v1.TextAdmin/ListAuditEvents", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TextAdminServer is the server API for TextAdmin service.
+// All implementations must embed UnimplementedTextAdminServer
+// for forward compatibility
+type TextAdminServer interface {
+	// GetRetentionReport returns the engines the retention policy would remove, without removing them.
+	GetRetentionReport(context.Context, *GetRetentionReportRequest) (*GetRetentionReportResponse, error)
+	// ListAuditEvents searches the audit log of state-changing calls.
+	ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error)
+	mustEmbedUnimplementedTextAdminServer()
+}
+
+// UnimplementedTextAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedTextAdminServer struct {
+}
+
+func (UnimplementedTextAdminServer) GetRetentionReport(context.Context, *GetRetentionReportRequest) (*GetRetentionReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRetentionReport not implemented")
+}
+func (UnimplementedTextAdminServer) ListAuditEvents(context.Context, *ListAuditEventsRequest) (*ListAuditEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAuditEvents not implemented")
+}
+func (UnimplementedTextAdminServer) mustEmbedUnimplementedTextAdminServer() {}
+
+// UnsafeTextAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TextAdminServer will
+// result in compilation errors.
+type UnsafeTextAdminServer interface {
+	mustEmbedUnimplementedTextAdminServer()
+}
+
+func RegisterTextAdminServer(s grpc.ServiceRegistrar, srv TextAdminServer) {
+	s.RegisterService(&TextAdmin_ServiceDesc, srv)
+}
+
+func _TextAdmin_GetRetentionReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRetentionReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextAdminServer).GetRetentionReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.TextAdmin/GetRetentionReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextAdminServer).GetRetentionReport(ctx, req.(*GetRetentionReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TextAdmin_ListAuditEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAuditEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextAdminServer).ListAuditEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.TextAdmin/ListAuditEvents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextAdminServer).ListAuditEvents(ctx, req.(*ListAuditEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TextAdmin_ServiceDesc is the grpc.ServiceDesc for TextAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TextAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "v1.TextAdmin",
+	HandlerType: (*TextAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetRetentionReport",
+			Handler:    _TextAdmin_GetRetentionReport_Handler,
+		},
+		{
+			MethodName: "ListAuditEvents",
+			Handler:    _TextAdmin_ListAuditEvents_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "text-admin.proto",
+}
@@ -41,6 +41,10 @@ type TextServiceClient interface {
 	Listen(ctx context.Context, in *ListenRequest, opts ...grpc.CallOption) (TextService_ListenClient, error)
 	// StopEngine stops a currently running Engine
 	StopEngine(ctx context.Context, in *StopEngineRequest, opts ...grpc.CallOption) (*StopEngineResponse, error)
+	// GetPipeline retrieves the status of all stages of a pipeline
+	GetPipeline(ctx context.Context, in *GetPipelineRequest, opts ...grpc.CallOption) (*GetPipelineResponse, error)
+	// GetEngineHistory retrieves the phase transitions and condition changes of an Engine, oldest first
+	GetEngineHistory(ctx context.Context, in *GetEngineHistoryRequest, opts ...grpc.CallOption) (*GetEngineHistoryResponse, error)
 }
 
 type textServiceClient struct {
@@ -194,6 +198,24 @@ func (c *textServiceClient) StopEngine(ctx context.Context, in *StopEngineReques
 	return out, nil
 }
 
+func (c *textServiceClient) GetPipeline(ctx context.Context, in *GetPipelineRequest, opts ...grpc.CallOption) (*GetPipelineResponse, error) {
+	out := new(GetPipelineResponse)
+	err := c.cc.Invoke(ctx, "/v1.TextService/GetPipeline", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *textServiceClient) GetEngineHistory(ctx context.Context, in *GetEngineHistoryRequest, opts ...grpc.CallOption) (*GetEngineHistoryResponse, error) {
+	out := new(GetEngineHistoryResponse)
+	err := c.cc.Invoke(ctx, "/v1.TextService/GetEngineHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TextServiceServer is the server API for TextService service.
 // All implementations must embed UnimplementedTextServiceServer
 // for forward compatibility
@@ -221,6 +243,10 @@ type TextServiceServer interface {
 	Listen(*ListenRequest, TextService_ListenServer) error
 	// StopEngine stops a currently running Engine
 	StopEngine(context.Context, *StopEngineRequest) (*StopEngineResponse, error)
+	// GetPipeline retrieves the status of all stages of a pipeline
+	GetPipeline(context.Context, *GetPipelineRequest) (*GetPipelineResponse, error)
+	// GetEngineHistory retrieves the phase transitions and condition changes of an Engine, oldest first
+	GetEngineHistory(context.Context, *GetEngineHistoryRequest) (*GetEngineHistoryResponse, error)
 	mustEmbedUnimplementedTextServiceServer()
 }
 
@@ -252,6 +278,12 @@ func (UnimplementedTextServiceServer) Listen(*ListenRequest, TextService_ListenS
 func (UnimplementedTextServiceServer) StopEngine(context.Context, *StopEngineRequest) (*StopEngineResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method StopEngine not implemented")
 }
+func (UnimplementedTextServiceServer) GetPipeline(context.Context, *GetPipelineRequest) (*GetPipelineResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPipeline not implemented")
+}
+func (UnimplementedTextServiceServer) GetEngineHistory(context.Context, *GetEngineHistoryRequest) (*GetEngineHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEngineHistory not implemented")
+}
 func (UnimplementedTextServiceServer) mustEmbedUnimplementedTextServiceServer() {}
 
 // UnsafeTextServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -423,6 +455,42 @@ func _TextService_StopEngine_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TextService_GetPipeline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPipelineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextServiceServer).GetPipeline(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.TextService/GetPipeline",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextServiceServer).GetPipeline(ctx, req.(*GetPipelineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TextService_GetEngineHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEngineHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TextServiceServer).GetEngineHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.TextService/GetEngineHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TextServiceServer).GetEngineHistory(ctx, req.(*GetEngineHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TextService_ServiceDesc is the grpc.ServiceDesc for TextService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -450,6 +518,14 @@ var TextService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "StopEngine",
 			Handler:    _TextService_StopEngine_Handler,
 		},
+		{
+			MethodName: "GetPipeline",
+			Handler:    _TextService_GetPipeline_Handler,
+		},
+		{
+			MethodName: "GetEngineHistory",
+			Handler:    _TextService_GetEngineHistory_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -0,0 +1,222 @@
+package audit
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package audit keeps an append-only log of the calls which change the state of the
+// Bhojpur Text server, i.e. start or stop engines: who did what, to which engine, and how it ended.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/auth"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Methods are the full method names of the calls which are audited
+var Methods = []string{
+	auth.MethodStartEngine,
+	auth.MethodStartLocalEngine,
+	auth.MethodStartFromPreviousEngine,
+	auth.MethodStopEngine,
+}
+
+// Anonymous is recorded as identity of unauthenticated callers
+const Anonymous = "anonymous"
+
+// DefaultLimit is the number of events ListAuditEvents returns if the request sets no limit
+const DefaultLimit = 50
+
+// Logger records audited calls in the store
+type Logger struct {
+	Store Store
+
+	// Authenticator establishes the caller's identity if the call does not carry one yet, i.e. if the
+	// audit interceptors run before the authentication interceptors. This way calls which fail
+	// authorization are attributed to the caller, too. Optional.
+	Authenticator auth.Authenticator
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// UnaryServerInterceptor returns the interceptor recording unary calls
+func (l *Logger) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !isAudited(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		digest := sha256.New()
+		writeDigest(digest, req)
+		engine := requestEngine(req)
+
+		resp, err := handler(ctx, req)
+		if e := responseEngine(resp); e != "" {
+			engine = e
+		}
+		l.record(ctx, info.FullMethod, engine, digest, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns the interceptor recording streaming calls, i.e. StartLocalEngine.
+// The digest covers all messages the client sent.
+func (l *Logger) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !isAudited(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		s := &auditedStream{ServerStream: ss, digest: sha256.New()}
+		err := handler(srv, s)
+		l.record(ss.Context(), info.FullMethod, s.engine, s.digest, err)
+		return err
+	}
+}
+
+type auditedStream struct {
+	grpc.ServerStream
+	digest hash.Hash
+	engine string
+}
+
+func (s *auditedStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		writeDigest(s.digest, m)
+		if e := requestEngine(m); e != "" {
+			s.engine = e
+		}
+	}
+	return err
+}
+
+func (s *auditedStream) SendMsg(m interface{}) error {
+	if e := responseEngine(m); e != "" {
+		s.engine = e
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+func (l *Logger) record(ctx context.Context, method, engine string, digest hash.Hash, err error) {
+	now := time.Now
+	if l.Now != nil {
+		now = l.Now
+	}
+	s, _ := status.FromError(err)
+	evt := &v1.AuditEvent{
+		Time:          timestamppb.New(now()),
+		Identity:      l.identify(ctx),
+		Method:        method,
+		Engine:        engine,
+		RequestDigest: "sha256:" + hex.EncodeToString(digest.Sum(nil)),
+		Code:          s.Code().String(),
+		Message:       s.Message(),
+	}
+
+	// the call has happened already - failing to record it must not fail the call, but must not go unnoticed either
+	if err := l.Store.Append(ctx, evt); err != nil {
+		log.WithError(err).WithField("method", method).WithField("identity", evt.Identity).WithField("engine", engine).Error("cannot record audit event")
+	}
+}
+
+func (l *Logger) identify(ctx context.Context) string {
+	if id := auth.IdentityFromContext(ctx); id != nil {
+		return id.Name
+	}
+	if l.Authenticator != nil {
+		if id, err := l.Authenticator.Authenticate(ctx); err == nil && id != nil {
+			return id.Name
+		}
+	}
+	return Anonymous
+}
+
+// ListAuditEvents implements the TextAdmin RPC of the same name
+func (l *Logger) ListAuditEvents(ctx context.Context, req *v1.ListAuditEventsRequest) (*v1.ListAuditEventsResponse, error) {
+	if req.Start < 0 || req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "start and limit must not be negative")
+	}
+	if err := ValidateFilter(req.Filter); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = DefaultLimit
+	}
+
+	events, total, err := l.Store.Find(ctx, req.Filter, int(req.Start), limit)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot query audit log: %v", err)
+	}
+	return &v1.ListAuditEventsResponse{Total: int32(total), Result: events}, nil
+}
+
+func isAudited(method string) bool {
+	for _, m := range Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// writeDigest adds the deterministic encoding of a request message to the digest
+func writeDigest(h hash.Hash, m interface{}) {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return
+	}
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return
+	}
+	_, _ = h.Write(b)
+}
+
+// requestEngine returns the name of the engine a request refers to, if any
+func requestEngine(req interface{}) string {
+	switch r := req.(type) {
+	case *v1.StopEngineRequest:
+		return r.Name
+	case *v1.StartFromPreviousEngineRequest:
+		return r.PreviousEngine
+	}
+	return ""
+}
+
+// responseEngine returns the name of the engine a call started, if any
+func responseEngine(resp interface{}) string {
+	if r, ok := resp.(*v1.StartEngineResponse); ok {
+		return r.GetStatus().GetName()
+	}
+	return ""
+}
@@ -0,0 +1,257 @@
+package audit
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/auth"
+	"github.com/bhojpur/text/pkg/filterexpr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type authenticatorFunc func(ctx context.Context) (*auth.Identity, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context) (*auth.Identity, error) {
+	return f(ctx)
+}
+
+func newTestLogger() (*Logger, *MemoryStore) {
+	store := &MemoryStore{}
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &Logger{Store: store, Now: func() time.Time { return now }}, store
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	l, store := newTestLogger()
+	intercept := l.UnaryServerInterceptor()
+
+	ctx := auth.WithIdentity(context.Background(), &auth.Identity{Name: "alice"})
+	stop := &grpc.UnaryServerInfo{FullMethod: auth.MethodStopEngine}
+	_, _ = intercept(ctx, &v1.StopEngineRequest{Name: "index.1"}, stop, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &v1.StopEngineResponse{}, nil
+	})
+	_, _ = intercept(ctx, &v1.StopEngineRequest{Name: "index.2"}, stop, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.PermissionDenied, "not yours")
+	})
+
+	start := &grpc.UnaryServerInfo{FullMethod: auth.MethodStartEngine}
+	_, _ = intercept(ctx, &v1.StartEngineRequest{EnginePath: "index.yaml"}, start, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &v1.StartEngineResponse{Status: &v1.EngineStatus{Name: "index.3"}}, nil
+	})
+
+	// read-only calls are not audited
+	get := &grpc.UnaryServerInfo{FullMethod: "/v1.TextService/GetEngine"}
+	_, _ = intercept(ctx, &v1.GetEngineRequest{Name: "index.1"}, get, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return &v1.GetEngineResponse{}, nil
+	})
+
+	events, total, _ := store.Find(context.Background(), nil, 0, 0)
+	if total != 3 {
+		t.Fatalf("expected three events, got %d", total)
+	}
+
+	var act []string
+	for _, e := range events {
+		act = append(act, strings.Join([]string{e.Identity, e.Method, e.Engine, e.Code, e.Message}, " "))
+		if !strings.HasPrefix(e.RequestDigest, "sha256:") || len(e.RequestDigest) != len("sha256:")+64 {
+			t.Errorf("unexpected digest %q", e.RequestDigest)
+		}
+		if !e.Time.AsTime().Equal(l.Now()) {
+			t.Errorf("unexpected time %v", e.Time.AsTime())
+		}
+	}
+	exp := []string{
+		"alice /v1.TextService/StartEngine index.3 OK ",
+		"alice /v1.TextService/StopEngine index.2 PermissionDenied not yours",
+		"alice /v1.TextService/StopEngine index.1 OK ",
+	}
+	if !reflect.DeepEqual(act, exp) {
+		t.Errorf("unexpected events:\n%s\nexpected:\n%s", strings.Join(act, "\n"), strings.Join(exp, "\n"))
+	}
+	if events[1].RequestDigest == events[2].RequestDigest {
+		t.Error("expected different requests to have different digests")
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	l, store := newTestLogger()
+	info := &grpc.UnaryServerInfo{FullMethod: auth.MethodStopEngine}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	_, _ = l.UnaryServerInterceptor()(context.Background(), &v1.StopEngineRequest{}, info, handler)
+	l.Authenticator = authenticatorFunc(func(ctx context.Context) (*auth.Identity, error) {
+		return &auth.Identity{Name: "bob"}, nil
+	})
+	_, _ = l.UnaryServerInterceptor()(context.Background(), &v1.StopEngineRequest{}, info, handler)
+
+	events, _, _ := store.Find(context.Background(), nil, 0, 0)
+	if len(events) != 2 || events[0].Identity != "bob" || events[1].Identity != Anonymous {
+		t.Errorf("unexpected events %v", events)
+	}
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	reqs []*v1.StartLocalEngineRequest
+	resp interface{}
+}
+
+func (s *fakeStream) Context() context.Context {
+	return auth.WithIdentity(context.Background(), &auth.Identity{Name: "carol"})
+}
+
+func (s *fakeStream) RecvMsg(m interface{}) error {
+	req := s.reqs[0]
+	s.reqs = s.reqs[1:]
+	proto.Merge(m.(*v1.StartLocalEngineRequest), req)
+	return nil
+}
+
+func (s *fakeStream) SendMsg(m interface{}) error {
+	s.resp = m
+	return nil
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	l, store := newTestLogger()
+	ss := &fakeStream{reqs: []*v1.StartLocalEngineRequest{
+		{Content: &v1.StartLocalEngineRequest_Metadata{Metadata: &v1.EngineMetadata{Owner: "carol"}}},
+		{Content: &v1.StartLocalEngineRequest_ApplicationTarDone{ApplicationTarDone: true}},
+	}}
+	info := &grpc.StreamServerInfo{FullMethod: auth.MethodStartLocalEngine, IsClientStream: true}
+	err := l.StreamServerInterceptor()(nil, ss, info, func(srv interface{}, stream grpc.ServerStream) error {
+		for i := 0; i < 2; i++ {
+			var req v1.StartLocalEngineRequest
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+		}
+		return stream.SendMsg(&v1.StartEngineResponse{Status: &v1.EngineStatus{Name: "local.1"}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, _, _ := store.Find(context.Background(), nil, 0, 0)
+	if len(events) != 1 || events[0].Identity != "carol" || events[0].Engine != "local.1" || events[0].Code != "OK" {
+		t.Errorf("unexpected events %v", events)
+	}
+}
+
+func TestListAuditEvents(t *testing.T) {
+	l, store := newTestLogger()
+	ctx := context.Background()
+	for _, e := range []*v1.AuditEvent{
+		{Time: timestamppb.New(time.Date(2022, 2, 28, 23, 0, 0, 0, time.UTC)), Identity: "alice", Method: auth.MethodStartEngine, Engine: "index.1", Code: "OK"},
+		{Time: timestamppb.New(time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)), Identity: "bob", Method: auth.MethodStopEngine, Engine: "index.1", Code: "PermissionDenied"},
+		{Time: timestamppb.New(time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC)), Identity: "alice", Method: auth.MethodStopEngine, Engine: "index.1", Code: "OK"},
+	} {
+		_ = store.Append(ctx, e)
+	}
+
+	filter, _ := filterexpr.Parse([]string{"method=|/StopEngine", "code==OK"})
+	resp, err := l.ListAuditEvents(ctx, &v1.ListAuditEventsRequest{Filter: filter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 || resp.Result[0].Id != 3 {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	or := []*v1.FilterExpression{{Terms: []*v1.FilterTerm{{Field: "identity", Value: "bob"}, {Field: "code", Value: "OK"}}}}
+	resp, _ = l.ListAuditEvents(ctx, &v1.ListAuditEventsRequest{Filter: or, Start: 1, Limit: 1})
+	if resp.Total != 3 || len(resp.Result) != 1 || resp.Result[0].Id != 2 {
+		t.Errorf("unexpected response %v", resp)
+	}
+
+	filter, _ = filterexpr.Parse([]string{"time>=2022-03-01T00:00:00Z", "time<2022-03-02T00:00:00Z"})
+	resp, err = l.ListAuditEvents(ctx, &v1.ListAuditEventsRequest{Filter: filter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Total != 1 || resp.Result[0].Id != 2 {
+		t.Errorf("unexpected response to time range %v", resp)
+	}
+
+	filter, _ = filterexpr.Parse([]string{"time|=2022-03"})
+	if _, err := l.ListAuditEvents(ctx, &v1.ListAuditEventsRequest{Filter: filter}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for time prefix, got %v", err)
+	}
+
+	filter, _ = filterexpr.Parse([]string{"phase==done"})
+	if _, err := l.ListAuditEvents(ctx, &v1.ListAuditEventsRequest{Filter: filter}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for unknown field, got %v", err)
+	}
+}
+
+func TestWhereClause(t *testing.T) {
+	filter := []*v1.FilterExpression{
+		{Terms: []*v1.FilterTerm{
+			{Field: "identity", Value: "alice"},
+			{Field: "identity", Value: "bo_b", Operation: v1.FilterOp_OP_STARTS_WITH},
+		}},
+		{Terms: []*v1.FilterTerm{{Field: "engine", Operation: v1.FilterOp_OP_EXISTS, Negate: true}}},
+		{Terms: []*v1.FilterTerm{{Field: "digest", Value: "50%", Operation: v1.FilterOp_OP_CONTAINS}}},
+	}
+	where, args, err := whereClause(filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := " WHERE (identity = $1 OR identity LIKE $2) AND (NOT (engine <> '')) AND (request_digest LIKE $3)"; where != exp {
+		t.Errorf("unexpected where clause:\n%s\nexpected:\n%s", where, exp)
+	}
+	if exp := []interface{}{"alice", `bo\_b%`, `%50\%%`}; !reflect.DeepEqual(args, exp) {
+		t.Errorf("unexpected args %v, expected %v", args, exp)
+	}
+
+	if where, _, _ := whereClause(nil); where != "" {
+		t.Errorf("expected no where clause without filter, got %q", where)
+	}
+	if _, _, err := whereClause([]*v1.FilterExpression{{Terms: []*v1.FilterTerm{{Field: "message"}}}}); err == nil {
+		t.Error("expected error for unknown field")
+	}
+
+	from := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	timeRange, _ := filterexpr.Parse([]string{"time>=2022-03-01T00:00:00Z", "time<2022-03-02T00:00:00Z"})
+	where, args, err = whereClause(timeRange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := " WHERE (time >= $1) AND (NOT (time >= $2))"; where != exp {
+		t.Errorf("unexpected where clause:\n%s\nexpected:\n%s", where, exp)
+	}
+	if exp := []interface{}{from, from.Add(24 * time.Hour)}; !reflect.DeepEqual(args, exp) {
+		t.Errorf("unexpected args %v, expected %v", args, exp)
+	}
+	if _, _, err := whereClause([]*v1.FilterExpression{{Terms: []*v1.FilterTerm{{Field: "time", Value: "2022-03-01"}}}}); err == nil {
+		t.Error("expected error for time compared for equality")
+	}
+}
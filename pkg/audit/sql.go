@@ -0,0 +1,189 @@
+package audit
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SQLStore stores audit events in the audit_log table of a PostgreSQL database
+type SQLStore struct {
+	DB *sql.DB
+}
+
+// columns maps the filter fields to the columns of the audit_log table
+var columns = map[string]string{
+	"time":     "time",
+	"identity": "identity",
+	"method":   "method",
+	"engine":   "engine",
+	"digest":   "request_digest",
+	"code":     "code",
+}
+
+// Migrate creates the audit_log table if it does not exist. The table rejects updates and
+// deletes, so that the log stays append-only even for other users of the database.
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id             BIGSERIAL PRIMARY KEY,
+			time           TIMESTAMPTZ NOT NULL,
+			identity       TEXT NOT NULL,
+			method         TEXT NOT NULL,
+			engine         TEXT NOT NULL,
+			request_digest TEXT NOT NULL,
+			code           TEXT NOT NULL,
+			message        TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS audit_log_engine ON audit_log (engine);
+		CREATE INDEX IF NOT EXISTS audit_log_identity ON audit_log (identity);
+		CREATE INDEX IF NOT EXISTS audit_log_time ON audit_log (time);
+		CREATE OR REPLACE RULE audit_log_no_update AS ON UPDATE TO audit_log DO INSTEAD NOTHING;
+		CREATE OR REPLACE RULE audit_log_no_delete AS ON DELETE TO audit_log DO INSTEAD NOTHING;
+	`)
+	return err
+}
+
+// Append implements Store
+func (s *SQLStore) Append(ctx context.Context, evt *v1.AuditEvent) error {
+	return s.DB.QueryRowContext(ctx,
+		`INSERT INTO audit_log (time, identity, method, engine, request_digest, code, message) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		evt.Time.AsTime(), evt.Identity, evt.Method, evt.Engine, evt.RequestDigest, evt.Code, evt.Message,
+	).Scan(&evt.Id)
+}
+
+// Find implements Store
+func (s *SQLStore) Find(ctx context.Context, filter []*v1.FilterExpression, start, limit int) ([]*v1.AuditEvent, int, error) {
+	where, args, err := whereClause(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	err = s.DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM audit_log"+where, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT id, time, identity, method, engine, request_digest, code, message FROM audit_log" + where + " ORDER BY id DESC"
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if start > 0 {
+		args = append(args, start)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var res []*v1.AuditEvent
+	for rows.Next() {
+		var (
+			evt v1.AuditEvent
+			t   time.Time
+		)
+		err := rows.Scan(&evt.Id, &t, &evt.Identity, &evt.Method, &evt.Engine, &evt.RequestDigest, &evt.Code, &evt.Message)
+		if err != nil {
+			return nil, 0, err
+		}
+		evt.Time = timestamppb.New(t)
+		res = append(res, &evt)
+	}
+	return res, total, rows.Err()
+}
+
+// whereClause translates the filter into an SQL WHERE clause with positional arguments.
+// Terms of an expression are ORed, expressions are ANDed.
+func whereClause(filter []*v1.FilterExpression) (string, []interface{}, error) {
+	var (
+		exprs []string
+		args  []interface{}
+	)
+	for _, expr := range filter {
+		if len(expr.Terms) == 0 {
+			continue
+		}
+
+		terms := make([]string, 0, len(expr.Terms))
+		for _, term := range expr.Terms {
+			col, ok := columns[term.Field]
+			if !ok {
+				return "", nil, fmt.Errorf("cannot filter audit events by %q", term.Field)
+			}
+			var value interface{} = term.Value
+			if term.Field == timeField {
+				t, err := parseTime(term)
+				if err != nil {
+					return "", nil, err
+				}
+				value = t
+			}
+
+			var cond string
+			switch term.Operation {
+			case v1.FilterOp_OP_EXISTS:
+				cond = col + " <> ''"
+			case v1.FilterOp_OP_EQUALS:
+				args = append(args, value)
+				cond = fmt.Sprintf("%s = $%d", col, len(args))
+			case v1.FilterOp_OP_GREATER_EQUAL:
+				args = append(args, value)
+				cond = fmt.Sprintf("%s >= $%d", col, len(args))
+			case v1.FilterOp_OP_STARTS_WITH:
+				args = append(args, escapeLike(term.Value)+"%")
+				cond = fmt.Sprintf("%s LIKE $%d", col, len(args))
+			case v1.FilterOp_OP_ENDS_WITH:
+				args = append(args, "%"+escapeLike(term.Value))
+				cond = fmt.Sprintf("%s LIKE $%d", col, len(args))
+			case v1.FilterOp_OP_CONTAINS:
+				args = append(args, "%"+escapeLike(term.Value)+"%")
+				cond = fmt.Sprintf("%s LIKE $%d", col, len(args))
+			default:
+				return "", nil, fmt.Errorf("unsupported filter operation %v", term.Operation)
+			}
+			if term.Negate {
+				cond = "NOT (" + cond + ")"
+			}
+			terms = append(terms, cond)
+		}
+		exprs = append(exprs, "("+strings.Join(terms, " OR ")+")")
+	}
+	if len(exprs) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(exprs, " AND "), args, nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern. Backslash is PostgreSQL's default escape character.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
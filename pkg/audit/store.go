@@ -0,0 +1,177 @@
+package audit
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Store persists audit events. Stores are append-only: there is no way to change or remove an event.
+type Store interface {
+	// Append adds an event to the log and assigns its ID
+	Append(ctx context.Context, evt *v1.AuditEvent) error
+
+	// Find returns the events matching the filter, newest first, and the total number of matching events
+	Find(ctx context.Context, filter []*v1.FilterExpression, start, limit int) (events []*v1.AuditEvent, total int, err error)
+}
+
+// fields maps the filter fields to the values of an event
+var fields = map[string]func(*v1.AuditEvent) string{
+	"identity": func(e *v1.AuditEvent) string { return e.Identity },
+	"method":   func(e *v1.AuditEvent) string { return e.Method },
+	"engine":   func(e *v1.AuditEvent) string { return e.Engine },
+	"digest":   func(e *v1.AuditEvent) string { return e.RequestDigest },
+	"code":     func(e *v1.AuditEvent) string { return e.Code },
+}
+
+// timeField is the filter field of the event time. It only supports the >= and < operators,
+// i.e. OP_GREATER_EQUAL, with RFC3339 values, e.g. time>=2022-03-01T00:00:00Z.
+const timeField = "time"
+
+// ValidateFilter returns an error if the filter refers to a field events do not have
+func ValidateFilter(filter []*v1.FilterExpression) error {
+	for _, expr := range filter {
+		for _, term := range expr.Terms {
+			if term.Field == timeField {
+				if _, err := parseTime(term); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, ok := fields[term.Field]; !ok {
+				return fmt.Errorf("cannot filter audit events by %q: supported fields are time, identity, method, engine, digest and code", term.Field)
+			}
+		}
+	}
+	return nil
+}
+
+// parseTime returns the time a term on the time field compares against
+func parseTime(term *v1.FilterTerm) (time.Time, error) {
+	if term.Operation != v1.FilterOp_OP_GREATER_EQUAL {
+		return time.Time{}, fmt.Errorf("audit events can only be filtered by time using >= and <")
+	}
+	t, err := time.Parse(time.RFC3339, term.Value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected RFC3339, e.g. 2022-03-01T00:00:00Z", term.Value)
+	}
+	return t, nil
+}
+
+// Match returns true if the event matches all filter expressions. A filter expression matches
+// if any of its terms matches, like filters of ListEngines. A field exists if it is not empty.
+func Match(evt *v1.AuditEvent, filter []*v1.FilterExpression) bool {
+	for _, expr := range filter {
+		if len(expr.Terms) == 0 {
+			continue
+		}
+
+		var matched bool
+		for _, term := range expr.Terms {
+			if matchTerm(evt, term) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func matchTerm(evt *v1.AuditEvent, term *v1.FilterTerm) bool {
+	if term.Field == timeField {
+		t, err := parseTime(term)
+		if err != nil {
+			return false
+		}
+		return !evt.Time.AsTime().Before(t) != term.Negate
+	}
+
+	field, ok := fields[term.Field]
+	if !ok {
+		return false
+	}
+
+	val := field(evt)
+	var res bool
+	switch term.Operation {
+	case v1.FilterOp_OP_EQUALS:
+		res = val == term.Value
+	case v1.FilterOp_OP_STARTS_WITH:
+		res = strings.HasPrefix(val, term.Value)
+	case v1.FilterOp_OP_ENDS_WITH:
+		res = strings.HasSuffix(val, term.Value)
+	case v1.FilterOp_OP_CONTAINS:
+		res = strings.Contains(val, term.Value)
+	case v1.FilterOp_OP_EXISTS:
+		res = val != ""
+	case v1.FilterOp_OP_GREATER_EQUAL:
+		res = val >= term.Value
+	}
+	return res != term.Negate
+}
+
+// MemoryStore keeps audit events in memory, e.g. for tests or installations without a database
+type MemoryStore struct {
+	mu     sync.RWMutex
+	events []*v1.AuditEvent
+}
+
+// Append implements Store
+func (s *MemoryStore) Append(ctx context.Context, evt *v1.AuditEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	evt.Id = int64(len(s.events) + 1)
+	s.events = append(s.events, proto.Clone(evt).(*v1.AuditEvent))
+	return nil
+}
+
+// Find implements Store
+func (s *MemoryStore) Find(ctx context.Context, filter []*v1.FilterExpression, start, limit int) ([]*v1.AuditEvent, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var (
+		res   []*v1.AuditEvent
+		total int
+	)
+	for i := len(s.events) - 1; i >= 0; i-- {
+		evt := s.events[i]
+		if !Match(evt, filter) {
+			continue
+		}
+		if total >= start && (limit <= 0 || len(res) < limit) {
+			res = append(res, proto.Clone(evt).(*v1.AuditEvent))
+		}
+		total++
+	}
+	return res, total, nil
+}
@@ -0,0 +1,119 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Identity describes an authenticated caller
+type Identity struct {
+	// Name is the user name of the caller. Engines started by this caller carry this name as owner.
+	Name string
+	// Roles are the roles/groups the caller holds
+	Roles []string
+	// Method names the authentication method that established this identity, e.g. "token"
+	Method string
+}
+
+// HasRole returns true if the identity holds the role
+func (id *Identity) HasRole(role string) bool {
+	if id == nil || role == "" {
+		return false
+	}
+	for _, r := range id.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrNoCredentials is returned by an authenticator if the request carries no credentials
+// it understands. In a chain of authenticators this makes the next authenticator try.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authenticator establishes the identity of a caller
+type Authenticator interface {
+	// Authenticate returns the identity of the caller. If the request carries no credentials
+	// this authenticator understands, ErrNoCredentials is returned.
+	Authenticate(ctx context.Context) (*Identity, error)
+}
+
+// Chain tries each authenticator in turn until one of them finds credentials
+type Chain []Authenticator
+
+// Authenticate implements Authenticator
+func (c Chain) Authenticate(ctx context.Context) (*Identity, error) {
+	for _, a := range c {
+		id, err := a.Authenticate(ctx)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return id, nil
+	}
+	return nil, ErrNoCredentials
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a new context carrying the identity
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext returns the identity of the caller, or nil if the call is unauthenticated
+func IdentityFromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(identityKey{}).(*Identity)
+	return id
+}
+
+// bearerToken extracts the bearer token from the gRPC authorization metadata
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ErrNoCredentials
+	}
+	vals := md.Get("authorization")
+	if len(vals) == 0 {
+		return "", ErrNoCredentials
+	}
+
+	const prefix = "bearer "
+	val := vals[0]
+	if len(val) < len(prefix) || !strings.EqualFold(val[:len(prefix)], prefix) {
+		return "", ErrNoCredentials
+	}
+	token := strings.TrimSpace(val[len(prefix):])
+	if token == "" {
+		return "", status.Error(codes.Unauthenticated, "empty bearer token")
+	}
+	return token, nil
+}
@@ -0,0 +1,240 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+func withToken(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestParseTokens(t *testing.T) {
+	a, err := ParseTokens(strings.NewReader(`
+# comment
+secret1,alice
+secret2,bob,"admin,dev"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := a.Authenticate(withToken("secret2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Name != "bob" || !id.HasRole("admin") || !id.HasRole("dev") {
+		t.Errorf("unexpected identity %+v", id)
+	}
+
+	_, err = a.Authenticate(withToken("wrong"))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+	_, err = a.Authenticate(context.Background())
+	if err != ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+
+	for _, invalid := range []string{"token-only", "secret,alice\nsecret,bob", ",alice"} {
+		if _, err := ParseTokens(strings.NewReader(invalid)); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestClientCertAuthenticator(t *testing.T) {
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "alice", Organization: []string{"admin"}}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}},
+	})
+
+	id, err := ClientCertAuthenticator{}.Authenticate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Name != "alice" || !id.HasRole("admin") {
+		t.Errorf("unexpected identity %+v", id)
+	}
+
+	_, err = ClientCertAuthenticator{}.Authenticate(context.Background())
+	if err != ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: "k1", Algorithm: string(jose.RS256), Use: "sig"}}}
+	fc, _ := json.Marshal(jwks)
+	fn := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(fn, fc, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := NewOIDCAuthenticator(OIDCConfig{Issuer: "https://issuer", Audience: "text", JWKSFile: fn})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", "k1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(iss string, exp time.Time) string {
+		tkn, err := jwt.Signed(signer).Claims(jwt.Claims{
+			Issuer:   iss,
+			Subject:  "alice",
+			Audience: jwt.Audience{"text"},
+			Expiry:   jwt.NewNumericDate(exp),
+		}).Claims(map[string]interface{}{"groups": []string{"admin"}}).CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tkn
+	}
+
+	id, err := a.Authenticate(withToken(sign("https://issuer", time.Now().Add(time.Hour))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Name != "alice" || !id.HasRole("admin") {
+		t.Errorf("unexpected identity %+v", id)
+	}
+
+	_, err = a.Authenticate(withToken(sign("https://other", time.Now().Add(time.Hour))))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated for wrong issuer, got %v", err)
+	}
+	_, err = a.Authenticate(withToken(sign("https://issuer", time.Now().Add(-time.Hour))))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated for expired token, got %v", err)
+	}
+	_, err = a.Authenticate(withToken("not-a-jwt"))
+	if err != ErrNoCredentials {
+		t.Errorf("expected ErrNoCredentials, got %v", err)
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	tokens, err := ParseTokens(strings.NewReader("alice-token,alice\nbob-token,bob\nadmin-token,root,admin\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	owners := map[string]string{"engine-1": "alice"}
+	i := &Interceptor{
+		Authenticator: Chain{ClientCertAuthenticator{}, tokens},
+		Authorizer: &Authorizer{
+			AdminRole: "admin",
+			OwnerOf: func(ctx context.Context, name string) (string, error) {
+				owner, ok := owners[name]
+				if !ok {
+					return "", status.Error(codes.NotFound, "not found")
+				}
+				return owner, nil
+			},
+		},
+		Public: []string{"/grpc.health.v1.Health/Check"},
+	}
+	intercept := i.UnaryServerInterceptor()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return IdentityFromContext(ctx), nil
+	}
+	call := func(ctx context.Context, method string, req interface{}) (interface{}, error) {
+		return intercept(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+	}
+
+	tests := []struct {
+		Name   string
+		Ctx    context.Context
+		Method string
+		Req    interface{}
+		Code   codes.Code
+	}{
+		{"no credentials", context.Background(), "/v1.TextService/ListEngines", &v1.ListEnginesRequest{}, codes.Unauthenticated},
+		{"public method", context.Background(), "/grpc.health.v1.Health/Check", nil, codes.OK},
+		{"read", withToken("bob-token"), "/v1.TextService/ListEngines", &v1.ListEnginesRequest{}, codes.OK},
+		{"owner stops", withToken("alice-token"), MethodStopEngine, &v1.StopEngineRequest{Name: "engine-1"}, codes.OK},
+		{"other stops", withToken("bob-token"), MethodStopEngine, &v1.StopEngineRequest{Name: "engine-1"}, codes.PermissionDenied},
+		{"admin stops", withToken("admin-token"), MethodStopEngine, &v1.StopEngineRequest{Name: "engine-1"}, codes.OK},
+		{"stop unknown", withToken("bob-token"), MethodStopEngine, &v1.StopEngineRequest{Name: "engine-2"}, codes.NotFound},
+		{"other replays", withToken("bob-token"), MethodStartFromPreviousEngine, &v1.StartFromPreviousEngineRequest{PreviousEngine: "engine-1"}, codes.PermissionDenied},
+		{"owner replays", withToken("alice-token"), MethodStartFromPreviousEngine, &v1.StartFromPreviousEngineRequest{PreviousEngine: "engine-1"}, codes.OK},
+		{"start on behalf", withToken("bob-token"), MethodStartEngine, &v1.StartEngineRequest{Metadata: &v1.EngineMetadata{Owner: "alice"}}, codes.PermissionDenied},
+		{"admin starts on behalf", withToken("admin-token"), MethodStartEngine, &v1.StartEngineRequest{Metadata: &v1.EngineMetadata{Owner: "alice"}}, codes.OK},
+		{"other lists audit events", withToken("bob-token"), MethodListAuditEvents, &v1.ListAuditEventsRequest{}, codes.PermissionDenied},
+		{"admin lists audit events", withToken("admin-token"), MethodListAuditEvents, &v1.ListAuditEventsRequest{}, codes.OK},
+		{"other gets retention report", withToken("bob-token"), MethodGetRetentionReport, &v1.GetRetentionReportRequest{}, codes.PermissionDenied},
+		{"admin gets retention report", withToken("admin-token"), MethodGetRetentionReport, &v1.GetRetentionReportRequest{}, codes.OK},
+		{"unknown admin call", withToken("admin-token"), "/v1.TextAdmin/DropEverything", nil, codes.PermissionDenied},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			_, err := call(test.Ctx, test.Method, test.Req)
+			if code := status.Code(err); code != test.Code {
+				t.Errorf("expected %v, got %v (%v)", test.Code, code, err)
+			}
+		})
+	}
+
+	req := &v1.StartEngineRequest{}
+	res, err := call(withToken("bob-token"), MethodStartEngine, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Metadata.GetOwner() != "bob" {
+		t.Errorf("expected owner to be set to bob, got %q", req.Metadata.GetOwner())
+	}
+	if id := res.(*Identity); id.Name != "bob" {
+		t.Errorf("expected identity bob in context, got %+v", id)
+	}
+
+	// without an admin role nobody may use the admin service
+	i.Authorizer.AdminRole = ""
+	if _, err := call(withToken("admin-token"), MethodListAuditEvents, &v1.ListAuditEventsRequest{}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied without admin role, got %v", err)
+	}
+}
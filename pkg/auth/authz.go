@@ -0,0 +1,158 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"strings"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Full method names of the calls subject to per-owner authorization
+const (
+	MethodStartLocalEngine        = "/v1.TextService/StartLocalEngine"
+	MethodStartFromPreviousEngine = "/v1.TextService/StartFromPreviousEngine"
+	MethodStartEngine             = "/v1.TextService/StartEngine"
+	MethodStopEngine              = "/v1.TextService/StopEngine"
+)
+
+// adminServicePrefix prefixes the full method names of the TextAdmin service
+const adminServicePrefix = "/v1.TextAdmin/"
+
+// Full method names of the calls which require the admin role
+const (
+	MethodGetRetentionReport = "/v1.TextAdmin/GetRetentionReport"
+	MethodListAuditEvents    = "/v1.TextAdmin/ListAuditEvents"
+)
+
+// OwnerLookupFunc returns the owner of an engine. Implementations must return an error
+// with status code NotFound if the engine does not exist.
+type OwnerLookupFunc func(ctx context.Context, engineName string) (owner string, err error)
+
+// Authorizer implements the per-owner authorization rules:
+//   - engines are owned by the identity that started them,
+//   - only the owner can stop or replay an engine,
+//   - callers holding the admin role can stop or replay any engine, and start engines on behalf of others,
+//   - only callers holding the admin role can use the TextAdmin service, and
+//     TextAdmin calls without a rule of their own are denied to everyone,
+//   - all other calls are permitted to any authenticated caller.
+type Authorizer struct {
+	// AdminRole is the role which exempts a caller from ownership checks
+	AdminRole string
+	// OwnerOf looks up the owner of an existing engine
+	OwnerOf OwnerLookupFunc
+}
+
+// AuthorizeRequest checks if the identity is allowed to issue the request to the method.
+// For requests starting an engine the metadata owner is set to the caller's identity if empty.
+func (a *Authorizer) AuthorizeRequest(ctx context.Context, id *Identity, method string, req interface{}) error {
+	switch method {
+	case MethodGetRetentionReport, MethodListAuditEvents:
+		return a.authorizeAdmin(id, method)
+	case MethodStopEngine:
+		r, ok := req.(*v1.StopEngineRequest)
+		if !ok {
+			return status.Error(codes.Internal, "unexpected request type")
+		}
+		return a.authorizeOwner(ctx, id, r.Name, "stop")
+	case MethodStartFromPreviousEngine:
+		r, ok := req.(*v1.StartFromPreviousEngineRequest)
+		if !ok {
+			return status.Error(codes.Internal, "unexpected request type")
+		}
+		return a.authorizeOwner(ctx, id, r.PreviousEngine, "replay")
+	case MethodStartEngine:
+		r, ok := req.(*v1.StartEngineRequest)
+		if !ok {
+			return status.Error(codes.Internal, "unexpected request type")
+		}
+		if r.Metadata == nil {
+			r.Metadata = &v1.EngineMetadata{}
+		}
+		return a.authorizeStart(id, r.Metadata)
+	case MethodStartLocalEngine:
+		r, ok := req.(*v1.StartLocalEngineRequest)
+		if !ok {
+			return status.Error(codes.Internal, "unexpected request type")
+		}
+		if md := r.GetMetadata(); md != nil {
+			return a.authorizeStart(id, md)
+		}
+	default:
+		if strings.HasPrefix(method, adminServicePrefix) {
+			// admin calls are denied unless they have a rule of their own
+			return status.Errorf(codes.PermissionDenied, "%s is not permitted", method)
+		}
+	}
+	return nil
+}
+
+func (a *Authorizer) isAdmin(id *Identity) bool {
+	return a.AdminRole != "" && id.HasRole(a.AdminRole)
+}
+
+func (a *Authorizer) authorizeAdmin(id *Identity, method string) error {
+	if a.AdminRole == "" {
+		return status.Errorf(codes.PermissionDenied, "%s requires the admin role, but none is configured", method)
+	}
+	if !id.HasRole(a.AdminRole) {
+		return status.Errorf(codes.PermissionDenied, "%s requires the %s role", method, a.AdminRole)
+	}
+	return nil
+}
+
+func (a *Authorizer) authorizeOwner(ctx context.Context, id *Identity, engine, action string) error {
+	if a.isAdmin(id) {
+		return nil
+	}
+	if engine == "" {
+		return status.Error(codes.InvalidArgument, "engine name is required")
+	}
+	if a.OwnerOf == nil {
+		return status.Error(codes.PermissionDenied, "cannot determine engine ownership")
+	}
+
+	owner, err := a.OwnerOf(ctx, engine)
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return err
+		}
+		return status.Errorf(codes.Internal, "cannot determine owner of %s: %v", engine, err)
+	}
+	if owner != id.Name {
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed to %s engine %s owned by %s", id.Name, action, engine, owner)
+	}
+	return nil
+}
+
+func (a *Authorizer) authorizeStart(id *Identity, md *v1.EngineMetadata) error {
+	if md.Owner == "" {
+		md.Owner = id.Name
+		return nil
+	}
+	if md.Owner != id.Name && !a.isAdmin(id) {
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed to start engines on behalf of %s", id.Name, md.Owner)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// Interceptor authenticates and authorizes calls to the gRPC services
+type Interceptor struct {
+	// Authenticator establishes the caller's identity
+	Authenticator Authenticator
+	// Authorizer enforces ownership rules. If nil, all authenticated calls are permitted.
+	Authorizer *Authorizer
+	// Public lists full method names which can be called without authentication, e.g. health checks
+	Public []string
+}
+
+// UnaryServerInterceptor returns the interceptor for unary calls
+func (i *Interceptor) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if i.isPublic(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		id, err := i.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ctx = WithIdentity(ctx, id)
+		if i.Authorizer != nil {
+			err = i.Authorizer.AuthorizeRequest(ctx, id, info.FullMethod, req)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns the interceptor for streaming calls
+func (i *Interceptor) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if i.isPublic(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		id, err := i.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authorizedStream{
+			ServerStream: ss,
+			ctx:          WithIdentity(ss.Context(), id),
+			id:           id,
+			method:       info.FullMethod,
+			authz:        i.Authorizer,
+		})
+	}
+}
+
+func (i *Interceptor) isPublic(method string) bool {
+	for _, m := range i.Public {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Interceptor) authenticate(ctx context.Context) (*Identity, error) {
+	if i.Authenticator == nil {
+		return nil, status.Error(codes.Unauthenticated, "no authenticator configured")
+	}
+	id, err := i.Authenticator.Authenticate(ctx)
+	if errors.Is(err, ErrNoCredentials) {
+		return nil, status.Error(codes.Unauthenticated, "credentials required")
+	}
+	if err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return id, nil
+}
+
+// authorizedStream carries the caller's identity and authorizes every received message,
+// e.g. the metadata of a StartLocalEngine call.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	id     *Identity
+	method string
+	authz  *Authorizer
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *authorizedStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err != nil || s.authz == nil {
+		return err
+	}
+	return s.authz.AuthorizeRequest(s.ctx, s.id, s.method, m)
+}
+
+// BearerToken produces per-RPC credentials which send the token as bearer token.
+// If requireTLS is true the token is only ever sent over a secure connection.
+func BearerToken(token string, requireTLS bool) credentials.PerRPCCredentials {
+	return bearerTokenCredentials{token: token, requireTLS: requireTLS}
+}
+
+type bearerTokenCredentials struct {
+	token      string
+	requireTLS bool
+}
+
+func (c bearerTokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+func (c bearerTokenCredentials) RequireTransportSecurity() bool {
+	return c.requireTLS
+}
@@ -0,0 +1,160 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	jose "gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+)
+
+// OIDCConfig configures the validation of OIDC ID tokens
+type OIDCConfig struct {
+	// Issuer is the expected "iss" claim
+	Issuer string `json:"issuer" yaml:"issuer"`
+	// Audience is the expected "aud" claim, usually the client ID
+	Audience string `json:"audience" yaml:"audience"`
+	// JWKSFile is the path to a local JSON Web Key Set file containing the issuer's signing keys
+	JWKSFile string `json:"jwksFile" yaml:"jwksFile"`
+	// UsernameClaim names the claim used as user name. Defaults to "sub".
+	UsernameClaim string `json:"usernameClaim,omitempty" yaml:"usernameClaim,omitempty"`
+	// RolesClaim names the claim containing the caller's roles. Defaults to "groups".
+	RolesClaim string `json:"rolesClaim,omitempty" yaml:"rolesClaim,omitempty"`
+}
+
+// OIDCAuthenticator authenticates callers using OIDC ID tokens passed as bearer token.
+// Tokens are validated against a local JWKS file rather than the issuer's discovery
+// endpoint, so that the server needs no connectivity to the identity provider.
+type OIDCAuthenticator struct {
+	cfg  OIDCConfig
+	keys jose.JSONWebKeySet
+	now  func() time.Time
+}
+
+// NewOIDCAuthenticator loads the JWKS file and produces a new OIDC authenticator
+func NewOIDCAuthenticator(cfg OIDCConfig) (*OIDCAuthenticator, error) {
+	if cfg.Issuer == "" {
+		return nil, fmt.Errorf("issuer is required")
+	}
+	if cfg.Audience == "" {
+		return nil, fmt.Errorf("audience is required")
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = "sub"
+	}
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "groups"
+	}
+
+	fc, err := os.ReadFile(cfg.JWKSFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read JWKS file: %w", err)
+	}
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(fc, &keys); err != nil {
+		return nil, fmt.Errorf("cannot parse JWKS file %s: %w", cfg.JWKSFile, err)
+	}
+	if len(keys.Keys) == 0 {
+		return nil, fmt.Errorf("JWKS file %s contains no keys", cfg.JWKSFile)
+	}
+
+	return &OIDCAuthenticator{cfg: cfg, keys: keys, now: time.Now}, nil
+}
+
+// Authenticate implements Authenticator
+func (a *OIDCAuthenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	raw, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tkn, err := jwt.ParseSigned(raw)
+	if err != nil {
+		// not a JWT - maybe some other authenticator understands it
+		return nil, ErrNoCredentials
+	}
+
+	key, err := a.verificationKey(tkn)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	var (
+		std    jwt.Claims
+		claims map[string]interface{}
+	)
+	if err := tkn.Claims(key, &std, &claims); err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token signature")
+	}
+	err = std.ValidateWithLeeway(jwt.Expected{
+		Issuer:   a.cfg.Issuer,
+		Audience: jwt.Audience{a.cfg.Audience},
+		Time:     a.now(),
+	}, jwt.DefaultLeeway)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+
+	name, _ := claims[a.cfg.UsernameClaim].(string)
+	if name == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "token has no %s claim", a.cfg.UsernameClaim)
+	}
+	id := &Identity{Name: name, Method: "oidc"}
+	switch roles := claims[a.cfg.RolesClaim].(type) {
+	case string:
+		id.Roles = []string{roles}
+	case []interface{}:
+		for _, r := range roles {
+			if s, ok := r.(string); ok {
+				id.Roles = append(id.Roles, s)
+			}
+		}
+	}
+	return id, nil
+}
+
+func (a *OIDCAuthenticator) verificationKey(tkn *jwt.JSONWebToken) (interface{}, error) {
+	var kid string
+	for _, h := range tkn.Headers {
+		if h.KeyID != "" {
+			kid = h.KeyID
+			break
+		}
+	}
+	if kid == "" {
+		if len(a.keys.Keys) == 1 {
+			return a.keys.Keys[0], nil
+		}
+		return nil, fmt.Errorf("token has no key ID")
+	}
+
+	keys := a.keys.Key(kid)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("unknown signing key %s", kid)
+	}
+	return keys[0], nil
+}
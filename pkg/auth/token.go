@@ -0,0 +1,119 @@
+package auth
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StaticTokenAuthenticator authenticates callers using a fixed set of bearer tokens
+type StaticTokenAuthenticator struct {
+	// tokens are indexed by their SHA256 digest so that the tokens themselves do not linger in memory
+	tokens map[[sha256.Size]byte]*Identity
+}
+
+// LoadTokenFile loads a static token file. Each line of the file is a CSV record of the form
+//
+//	token,user[,"role1,role2"]
+//
+// Empty lines and lines starting with # are ignored.
+func LoadTokenFile(fn string) (*StaticTokenAuthenticator, error) {
+	f, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	res, err := ParseTokens(f)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse token file %s: %w", fn, err)
+	}
+	return res, nil
+}
+
+// ParseTokens parses static tokens in the format expected by LoadTokenFile
+func ParseTokens(in io.Reader) (*StaticTokenAuthenticator, error) {
+	r := csv.NewReader(in)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	res := &StaticTokenAuthenticator{tokens: make(map[[sha256.Size]byte]*Identity)}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		if len(rec) < 2 {
+			return nil, fmt.Errorf("line %d: expected at least token and user", line)
+		}
+
+		token, user := strings.TrimSpace(rec[0]), strings.TrimSpace(rec[1])
+		if token == "" || user == "" {
+			return nil, fmt.Errorf("line %d: token and user must not be empty", line)
+		}
+		id := &Identity{Name: user, Method: "token"}
+		if len(rec) > 2 {
+			for _, role := range strings.Split(rec[2], ",") {
+				if role = strings.TrimSpace(role); role != "" {
+					id.Roles = append(id.Roles, role)
+				}
+			}
+		}
+
+		digest := sha256.Sum256([]byte(token))
+		if _, exists := res.tokens[digest]; exists {
+			return nil, fmt.Errorf("line %d: duplicate token", line)
+		}
+		res.tokens[digest] = id
+	}
+	return res, nil
+}
+
+// Authenticate implements Authenticator
+func (a *StaticTokenAuthenticator) Authenticate(ctx context.Context) (*Identity, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, ok := a.tokens[sha256.Sum256([]byte(token))]
+	if !ok {
+		// JWTs are bearer tokens too - let the OIDC authenticator have a go at them
+		if strings.Count(token, ".") == 2 {
+			return nil, ErrNoCredentials
+		}
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	return id, nil
+}
@@ -0,0 +1,213 @@
+package clientconfig
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/bhojpur/text/pkg/tlsutil"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the client configuration file. It holds a set of named contexts,
+// each describing how to connect to one Bhojpur Text installation.
+type Config struct {
+	// CurrentContext is the name of the context used unless another one is selected explicitly
+	CurrentContext string `json:"currentContext,omitempty"`
+	// Contexts are the known contexts
+	Contexts []Context `json:"contexts,omitempty"`
+}
+
+// Context describes how to connect to a Bhojpur Text installation.
+// Empty fields do not override the client defaults.
+type Context struct {
+	Name string `json:"name"`
+
+	// DialMode is either "host", "kubernetes" or "in-cluster"
+	DialMode string `json:"dialMode,omitempty"`
+	// Host is the Bhojpur Text host in host dial mode
+	Host string `json:"host,omitempty"`
+
+	// Kubeconfig is the kubeconfig file used in kubernetes dial mode
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// KubeContext is the kubeconfig context used in kubernetes dial mode
+	KubeContext string `json:"kubeContext,omitempty"`
+	// Namespace is the Kubernetes namespace the Bhojpur Text pods run in
+	Namespace string `json:"namespace,omitempty"`
+
+	// TLS configures the transport security
+	TLS *TLS `json:"tls,omitempty"`
+	// Token is the bearer token used to authenticate
+	Token string `json:"token,omitempty"`
+}
+
+// TLS configures the transport security of a context
+type TLS struct {
+	Enabled bool `json:"enabled"`
+	tlsutil.ClientConfig
+}
+
+// ErrNotFound is returned when a context does not exist
+var ErrNotFound = errors.New("context not found")
+
+// DefaultPath returns the location of the config file, which is $TEXT_CONFIG if set,
+// or text/config.yaml in $XDG_CONFIG_HOME (defaulting to ~/.config).
+func DefaultPath() (string, error) {
+	if fn := os.Getenv("TEXT_CONFIG"); fn != "" {
+		return fn, nil
+	}
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "text", "config.yaml"), nil
+}
+
+// Load reads the config file. A missing file yields an empty configuration.
+func Load(fn string) (*Config, error) {
+	fc, err := os.ReadFile(fn)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res Config
+	if err := yaml.UnmarshalStrict(fc, &res); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", fn, err)
+	}
+	if err := res.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %w", fn, err)
+	}
+	return &res, nil
+}
+
+// Save writes the config file. As the file may contain tokens it is only readable by the user.
+func (c *Config) Save(fn string) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	fc, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fn), 0700); err != nil {
+		return err
+	}
+
+	// write to a temp file first so that we never leave a half-written config behind
+	tmp := fn + ".tmp"
+	if err := os.WriteFile(tmp, fc, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fn)
+}
+
+// Validate checks the configuration for consistency
+func (c *Config) Validate() error {
+	names := make(map[string]struct{}, len(c.Contexts))
+	for _, ctx := range c.Contexts {
+		if ctx.Name == "" {
+			return fmt.Errorf("context name must not be empty")
+		}
+		if _, exists := names[ctx.Name]; exists {
+			return fmt.Errorf("duplicate context %s", ctx.Name)
+		}
+		names[ctx.Name] = struct{}{}
+
+		switch ctx.DialMode {
+		case "", "host", "kubernetes", "in-cluster":
+		default:
+			return fmt.Errorf("context %s: unknown dial mode %s", ctx.Name, ctx.DialMode)
+		}
+	}
+	if c.CurrentContext != "" {
+		if _, exists := names[c.CurrentContext]; !exists {
+			return fmt.Errorf("current context %s does not exist", c.CurrentContext)
+		}
+	}
+	return nil
+}
+
+// Context returns the context with the given name
+func (c *Config) Context(name string) (*Context, error) {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name == name {
+			return &c.Contexts[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Current returns the current context, or nil if there is none
+func (c *Config) Current() *Context {
+	if c.CurrentContext == "" {
+		return nil
+	}
+	res, err := c.Context(c.CurrentContext)
+	if err != nil {
+		return nil
+	}
+	return res
+}
+
+// Use makes the named context the current one
+func (c *Config) Use(name string) error {
+	if _, err := c.Context(name); err != nil {
+		return err
+	}
+	c.CurrentContext = name
+	return nil
+}
+
+// Set adds the context or replaces an existing one of the same name. Contexts are kept sorted by name.
+func (c *Config) Set(ctx Context) {
+	if existing, err := c.Context(ctx.Name); err == nil {
+		*existing = ctx
+		return
+	}
+	c.Contexts = append(c.Contexts, ctx)
+	sort.Slice(c.Contexts, func(i, j int) bool { return c.Contexts[i].Name < c.Contexts[j].Name })
+}
+
+// Delete removes the named context. If it was the current context, there is no current context afterwards.
+func (c *Config) Delete(name string) error {
+	for i := range c.Contexts {
+		if c.Contexts[i].Name != name {
+			continue
+		}
+		c.Contexts = append(c.Contexts[:i], c.Contexts[i+1:]...)
+		if c.CurrentContext == name {
+			c.CurrentContext = ""
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrNotFound, name)
+}
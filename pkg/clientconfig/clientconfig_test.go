@@ -0,0 +1,129 @@
+package clientconfig
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bhojpur/text/pkg/tlsutil"
+)
+
+func TestLoadSave(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "text", "config.yaml")
+
+	cfg, err := Load(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Contexts) != 0 || cfg.Current() != nil {
+		t.Fatalf("expected empty config for missing file, got %+v", cfg)
+	}
+
+	cfg.Set(Context{Name: "staging", Host: "staging:7777"})
+	cfg.Set(Context{
+		Name:        "prod",
+		DialMode:    "kubernetes",
+		KubeContext: "prod-cluster",
+		Namespace:   "text",
+		Token:       "secret",
+		TLS:         &TLS{Enabled: true, ClientConfig: tlsutil.ClientConfig{CAFile: "ca.pem", ServerName: "text.example.com"}},
+	})
+	if err := cfg.Use("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Save(fn); err != nil {
+		t.Fatal(err)
+	}
+
+	stat, err := os.Stat(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := stat.Mode().Perm(); perm != 0600 {
+		t.Errorf("expected config to be written with 0600, got %v", perm)
+	}
+
+	cfg, err = Load(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Contexts[0].Name != "prod" || cfg.Contexts[1].Name != "staging" {
+		t.Errorf("expected contexts to be sorted, got %+v", cfg.Contexts)
+	}
+	cur := cfg.Current()
+	if cur == nil || cur.KubeContext != "prod-cluster" || cur.TLS == nil || cur.TLS.ServerName != "text.example.com" {
+		t.Errorf("unexpected current context %+v", cur)
+	}
+
+	cfg.Set(Context{Name: "prod", Host: "prod:7777"})
+	if ctx, _ := cfg.Context("prod"); ctx.Host != "prod:7777" || ctx.Token != "" {
+		t.Errorf("expected Set to replace the context, got %+v", ctx)
+	}
+	if len(cfg.Contexts) != 2 {
+		t.Errorf("expected two contexts, got %d", len(cfg.Contexts))
+	}
+
+	if err := cfg.Delete("prod"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.CurrentContext != "" {
+		t.Errorf("expected current context to be reset, got %s", cfg.CurrentContext)
+	}
+	if err := cfg.Use("prod"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":   "contexts:\n- name: a\n  hots: foo\n",
+		"duplicate":       "contexts:\n- name: a\n- name: a\n",
+		"missing current": "currentContext: b\ncontexts:\n- name: a\n",
+		"dial mode":       "contexts:\n- name: a\n  dialMode: carrier-pigeon\n",
+		"empty name":      "contexts:\n- host: foo\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			fn := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(fn, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Load(fn); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("TEXT_CONFIG", "")
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	if fn, _ := DefaultPath(); fn != "/tmp/xdg/text/config.yaml" {
+		t.Errorf("unexpected default path %s", fn)
+	}
+	t.Setenv("TEXT_CONFIG", "/etc/text.yaml")
+	if fn, _ := DefaultPath(); fn != "/etc/text.yaml" {
+		t.Errorf("unexpected default path %s", fn)
+	}
+}
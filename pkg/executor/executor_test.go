@@ -0,0 +1,424 @@
+package executor
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/queue"
+	"github.com/bhojpur/text/pkg/retry"
+	"github.com/bhojpur/text/pkg/store"
+	"github.com/bhojpur/text/pkg/tracing"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestLoadSpecs(t *testing.T) {
+	dir := t.TempDir()
+	for fn, content := range map[string]string{
+		"index.yaml":  "command: [sh, -c, 'echo index']\nenv:\n  LANG: en\n",
+		"extract.yml": "dependsOn: [index]\ncommand: [true]\n",
+		"README.md":   "not a spec",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, fn), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	specs, err := LoadSpecs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 2 || specs["index"].Env["LANG"] != "en" || specs["extract"].DependsOn[0] != "index" {
+		t.Errorf("unexpected specs %v", specs)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("env: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSpecs(dir); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected spec without command to be rejected, got %v", err)
+	}
+}
+
+func TestSpecName(t *testing.T) {
+	tests := []struct {
+		Path        string
+		Expectation string
+	}{
+		{"index", "index"},
+		{"index.yaml", "index"},
+		{".text/index.yml", "index"},
+		{"specs/index.json", "index.json"},
+	}
+	for _, test := range tests {
+		if act := SpecName(test.Path); act != test.Expectation {
+			t.Errorf("SpecName(%q) = %q, expected %q", test.Path, act, test.Expectation)
+		}
+	}
+}
+
+func TestLocalRun(t *testing.T) {
+	l := &Local{Workdir: t.TempDir()}
+	engine := &v1.EngineStatus{
+		Name: "index-1",
+		Metadata: &v1.EngineMetadata{
+			EngineSpecName: "index",
+			Annotations:    []*v1.Annotation{{Key: "lang", Value: "en"}},
+		},
+	}
+	spec := &Spec{
+		Command: []string{"sh", "-c", `echo "running $TEXT_ENGINE_NAME in $GREETING"; echo "{\"type\":\"annotations\",\"payload\":$(echo "$TEXT_ANNOTATIONS" | sed 's/"/\\\\"/g' | sed 's/^/"/;s/$/"/')}" > "$TEXT_RESULTS"`},
+		Env:     map[string]string{"GREETING": "hello"},
+	}
+
+	out := l.Run(context.Background(), engine, spec, []string{"TRACEPARENT=00-abc"})
+	if out.Err != nil {
+		t.Fatal(out.Err)
+	}
+	if len(out.Results) != 1 || out.Results[0].Payload != `{"lang":"en"}` {
+		t.Errorf("unexpected results %v", out.Results)
+	}
+	logs, err := os.ReadFile(l.LogFile("index-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(logs) != "running index-1 in hello\n" {
+		t.Errorf("unexpected logs %q", logs)
+	}
+
+	out = l.Run(context.Background(), engine, &Spec{Command: []string{"sh", "-c", "exit 3"}}, nil)
+	if out.Err == nil || out.ExitCode != 3 {
+		t.Errorf("expected exit code 3, got %d: %v", out.ExitCode, out.Err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	out = l.Run(ctx, engine, &Spec{Command: []string{"sleep", "10"}}, nil)
+	if out.Err != context.DeadlineExceeded {
+		t.Errorf("expected a canceled engine to fail with the context's error, got %v", out.Err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := l.RemoveLogs(context.Background(), "index-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(l.LogFile("index-1")); !os.IsNotExist(err) {
+		t.Errorf("expected the logs to be removed, got %v", err)
+	}
+	if err := l.RemoveLogs(context.Background(), "../index-1"); err == nil {
+		t.Error("expected error for an invalid engine name")
+	}
+}
+
+// fakeExecutor runs engines until the test finishes them
+type fakeExecutor struct {
+	mu       sync.Mutex
+	outcomes map[string]chan Outcome
+	env      map[string][]string
+}
+
+func (f *fakeExecutor) outcome(name string) chan Outcome {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.outcomes == nil {
+		f.outcomes = make(map[string]chan Outcome)
+	}
+	c, ok := f.outcomes[name]
+	if !ok {
+		c = make(chan Outcome, 1)
+		f.outcomes[name] = c
+	}
+	return c
+}
+
+func (f *fakeExecutor) Run(ctx context.Context, engine *v1.EngineStatus, spec *Spec, env []string) Outcome {
+	f.mu.Lock()
+	if f.env == nil {
+		f.env = make(map[string][]string)
+	}
+	f.env[engine.Name] = env
+	f.mu.Unlock()
+
+	select {
+	case o := <-f.outcome(engine.Name):
+		return o
+	case <-ctx.Done():
+		return Outcome{Err: ctx.Err()}
+	}
+}
+
+func newEngine(name string, phase v1.EnginePhase) *v1.EngineStatus {
+	return &v1.EngineStatus{
+		Name:       name,
+		Metadata:   &v1.EngineMetadata{Owner: "alice", EngineSpecName: "index"},
+		Phase:      phase,
+		Conditions: &v1.EngineConditions{},
+	}
+}
+
+// notifyingStore passes every change on to the manager, like the notify listener does
+type notifyingStore struct {
+	store.MemoryStore
+	OnUpdate func(*v1.EngineStatus)
+}
+
+func (s *notifyingStore) Store(ctx context.Context, status *v1.EngineStatus) error {
+	if err := s.MemoryStore.Store(ctx, status); err != nil {
+		return err
+	}
+	if s.OnUpdate != nil {
+		go s.OnUpdate(status)
+	}
+	return nil
+}
+
+func waitFor(t *testing.T, engines store.Store, name string, cond func(*v1.EngineStatus) bool) *v1.EngineStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		e, err := engines.Get(context.Background(), name)
+		if err == nil && cond(e) {
+			return e
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("engine %s did not reach the expected state: %v", name, e)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func phase(p v1.EnginePhase) func(*v1.EngineStatus) bool {
+	return func(e *v1.EngineStatus) bool { return e.Phase == p }
+}
+
+func startManager(t *testing.T, engines *notifyingStore, exec Executor, limits queue.Limits, opts ...func(*Manager)) *Manager {
+	m := NewManager(engines, exec, Specs{"index": {Command: []string{"true"}}}, limits)
+	for _, o := range opts {
+		o(m)
+	}
+	engines.OnUpdate = func(e *v1.EngineStatus) { m.Update(context.Background(), e) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return m
+}
+
+func TestManagerLimits(t *testing.T) {
+	var (
+		engines = &notifyingStore{}
+		exec    = &fakeExecutor{}
+		ctx     = context.Background()
+	)
+	m := startManager(t, engines, exec, queue.Limits{Global: 1})
+
+	for _, name := range []string{"a", "b", "c"} {
+		if err := engines.Store(ctx, newEngine(name, v1.EnginePhase_PHASE_PREPARING)); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, engines, name, func(e *v1.EngineStatus) bool { return e.Phase == v1.EnginePhase_PHASE_RUNNING || e.Details != "" })
+	}
+	waitFor(t, engines, "c", func(e *v1.EngineStatus) bool { return strings.HasPrefix(e.Details, "queued at position 2 of 2") })
+
+	exec.outcome("a") <- Outcome{Results: []*v1.EngineResult{{Type: "url", Payload: "https://example.com"}}}
+	a := waitFor(t, engines, "a", phase(v1.EnginePhase_PHASE_DONE))
+	if !a.Conditions.Success || !a.Conditions.DidExecute || len(a.Results) != 1 || a.Metadata.Finished == nil {
+		t.Errorf("unexpected status of a finished engine: %v", a)
+	}
+	waitFor(t, engines, "b", phase(v1.EnginePhase_PHASE_RUNNING))
+
+	// raising the limit starts the queued engine right away
+	m.SetLimits(queue.Limits{Global: 2})
+	waitFor(t, engines, "c", phase(v1.EnginePhase_PHASE_RUNNING))
+}
+
+func TestManagerStop(t *testing.T) {
+	var (
+		engines = &notifyingStore{}
+		exec    = &fakeExecutor{}
+		ctx     = context.Background()
+	)
+	startManager(t, engines, exec, queue.Limits{Global: 1})
+
+	for _, name := range []string{"running", "queued"} {
+		if err := engines.Store(ctx, newEngine(name, v1.EnginePhase_PHASE_PREPARING)); err != nil {
+			t.Fatal(err)
+		}
+		waitFor(t, engines, name, func(e *v1.EngineStatus) bool { return e.Phase == v1.EnginePhase_PHASE_RUNNING || e.Details != "" })
+	}
+	waiting := newEngine("waiting", v1.EnginePhase_PHASE_WAITING)
+	waiting.Conditions.WaitUntil = timestampIn(time.Hour)
+	if err := engines.Store(ctx, waiting); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"running", "queued", "waiting"} {
+		e, err := engines.Get(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Metadata.Annotations = append(e.Metadata.Annotations, &v1.Annotation{Key: AnnotationStopRequested, Value: "true"})
+		if err := engines.Store(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+		e = waitFor(t, engines, name, phase(v1.EnginePhase_PHASE_DONE))
+		if e.Conditions.Success || e.Details != "stopped" {
+			t.Errorf("expected %s to be stopped, got %v", name, e)
+		}
+	}
+}
+
+func TestManagerResume(t *testing.T) {
+	var (
+		engines = &notifyingStore{}
+		exec    = &fakeExecutor{}
+		ctx     = context.Background()
+	)
+	// engines of a previous leader
+	for _, e := range []*v1.EngineStatus{
+		newEngine("orphaned", v1.EnginePhase_PHASE_RUNNING),
+		newEngine("queued", v1.EnginePhase_PHASE_PREPARING),
+		newEngine("done", v1.EnginePhase_PHASE_DONE),
+	} {
+		if err := engines.MemoryStore.Store(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	startManager(t, engines, exec, queue.Limits{})
+
+	orphaned := waitFor(t, engines, "orphaned", phase(v1.EnginePhase_PHASE_DONE))
+	if orphaned.Conditions.Success || !strings.Contains(orphaned.Details, "went away") {
+		t.Errorf("expected the orphaned engine to fail, got %v", orphaned)
+	}
+	waitFor(t, engines, "queued", phase(v1.EnginePhase_PHASE_RUNNING))
+	if e, _ := engines.Get(ctx, "done"); e.Phase != v1.EnginePhase_PHASE_DONE || e.Details != "" {
+		t.Errorf("expected the done engine to be left alone, got %v", e)
+	}
+}
+
+func TestManagerRetries(t *testing.T) {
+	var (
+		engines = &notifyingStore{}
+		exec    = &fakeExecutor{}
+		ctx     = context.Background()
+	)
+	startManager(t, engines, exec, queue.Limits{}, func(m *Manager) {
+		m.Retrier = retry.NewRetrier(retry.Policies{
+			Specs: map[string]retry.Policy{"index": {MaxAttempts: 2, InitialBackoff: retry.Duration(50 * time.Millisecond)}},
+		})
+	})
+
+	if err := engines.Store(ctx, newEngine("e1", v1.EnginePhase_PHASE_PREPARING)); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, engines, "e1", phase(v1.EnginePhase_PHASE_RUNNING))
+	exec.outcome("e1") <- Outcome{ExitCode: 1, Err: errors.New("exit status 1")}
+	e := waitFor(t, engines, "e1", phase(v1.EnginePhase_PHASE_WAITING))
+	if e.Conditions.FailureCount != 1 || e.Conditions.WaitUntil == nil {
+		t.Errorf("expected the engine to wait for its second attempt, got %v", e)
+	}
+
+	waitFor(t, engines, "e1", phase(v1.EnginePhase_PHASE_RUNNING))
+	exec.outcome("e1") <- Outcome{ExitCode: 1, Err: errors.New("exit status 1")}
+	e = waitFor(t, engines, "e1", phase(v1.EnginePhase_PHASE_DONE))
+	if e.Conditions.Success || e.Conditions.FailureCount != 2 {
+		t.Errorf("expected the engine to fail after two attempts, got %v", e)
+	}
+	attempts, err := retry.History(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attempts) != 2 || attempts[0].ExitCode != 1 {
+		t.Errorf("unexpected attempts %+v", attempts)
+	}
+}
+
+func TestManagerTracing(t *testing.T) {
+	var (
+		engines = &notifyingStore{}
+		exec    = &fakeExecutor{}
+		ctx     = context.Background()
+		rec     = tracetest.NewSpanRecorder()
+		tp      = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	)
+	startManager(t, engines, exec, queue.Limits{}, func(m *Manager) {
+		m.Lifecycle = tracing.NewLifecycle(tp)
+	})
+
+	// the engine was started by a traced call
+	rpcCtx, rpc := tracing.Tracer(tp).Start(ctx, "StartEngine")
+	e := newEngine("e1", v1.EnginePhase_PHASE_PREPARING)
+	tracing.Annotate(rpcCtx, e.Metadata)
+	rpc.End()
+	if err := engines.Store(ctx, e); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, engines, "e1", phase(v1.EnginePhase_PHASE_RUNNING))
+	exec.outcome("e1") <- Outcome{}
+	waitFor(t, engines, "e1", phase(v1.EnginePhase_PHASE_DONE))
+
+	var execute sdktrace.ReadOnlySpan
+	for _, s := range rec.Ended() {
+		if s.Name() == "execute" {
+			execute = s
+		}
+	}
+	if execute == nil {
+		t.Fatal("expected an execute span")
+	}
+	if execute.SpanContext().TraceID() != rpc.SpanContext().TraceID() {
+		t.Errorf("expected the execute span to continue the trace of the call")
+	}
+
+	exec.mu.Lock()
+	env := exec.env["e1"]
+	exec.mu.Unlock()
+	traceParent := tracing.EnvTraceParent + "=00-" + execute.SpanContext().TraceID().String() + "-" + execute.SpanContext().SpanID().String()
+	var found bool
+	for _, v := range env {
+		found = found || strings.HasPrefix(v, traceParent)
+	}
+	if !found {
+		t.Errorf("expected the executor to continue the execute span, got %v", env)
+	}
+}
+
+func timestampIn(d time.Duration) *timestamppb.Timestamp {
+	return timestamppb.New(time.Now().Add(d))
+}
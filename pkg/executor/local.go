@@ -0,0 +1,188 @@
+package executor
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+)
+
+// Environment variables the engine's command reads its context from
+const (
+	// EnvEngineName is the name of the engine
+	EnvEngineName = "TEXT_ENGINE_NAME"
+	// EnvEngineSpec is the name of the engine's spec
+	EnvEngineSpec = "TEXT_ENGINE_SPEC"
+	// EnvAnnotations holds the annotations of the engine as JSON object, e.g. the inputs of a pipeline stage
+	EnvAnnotations = "TEXT_ANNOTATIONS"
+	// EnvResults names the file the command writes its results to, one JSON encoded EngineResult per line
+	EnvResults = "TEXT_RESULTS"
+)
+
+// resultsFile is the file in the engine's directory results are read from
+const resultsFile = ".text-results"
+
+// Outcome is the outcome of running an engine
+type Outcome struct {
+	// Results are the results the engine wrote
+	Results []*v1.EngineResult
+	// ExitCode is the exit code of the command, zero if it did not exit on its own
+	ExitCode int
+	// Err is non-nil if the engine failed
+	Err error
+}
+
+// Local runs engines as processes of the server. Each engine runs in a directory of its own,
+// which its command may use as scratch space, and its output goes to a log file.
+type Local struct {
+	// Workdir holds the engine directories in engines/ and the logs in logs/
+	Workdir string
+}
+
+// EngineDir returns the directory of all engine directories
+func (l *Local) EngineDir() string {
+	return filepath.Join(l.Workdir, "engines")
+}
+
+// LogDir returns the directory of all engine logs
+func (l *Local) LogDir() string {
+	return filepath.Join(l.Workdir, "logs")
+}
+
+// LogFile returns the log file of an engine
+func (l *Local) LogFile(name string) string {
+	return filepath.Join(l.LogDir(), name+".log")
+}
+
+// RemoveLogs removes the log file of an engine, e.g. once retention removes the engine. Removing
+// logs which do not exist does not fail.
+func (l *Local) RemoveLogs(ctx context.Context, name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid engine name %q", name)
+	}
+	if err := os.Remove(l.LogFile(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Run runs the engine's command until it exits or the context is canceled. env is added to the
+// environment of the command, e.g. to propagate the engine's trace.
+func (l *Local) Run(ctx context.Context, engine *v1.EngineStatus, spec *Spec, env []string) Outcome {
+	dir := filepath.Join(l.EngineDir(), engine.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Outcome{Err: fmt.Errorf("cannot create engine directory: %w", err)}
+	}
+	if err := os.MkdirAll(l.LogDir(), 0755); err != nil {
+		return Outcome{Err: fmt.Errorf("cannot create log directory: %w", err)}
+	}
+	logs, err := os.Create(l.LogFile(engine.Name))
+	if err != nil {
+		return Outcome{Err: fmt.Errorf("cannot create log file: %w", err)}
+	}
+	defer logs.Close()
+
+	results := filepath.Join(dir, resultsFile)
+	if err := os.Remove(results); err != nil && !os.IsNotExist(err) {
+		return Outcome{Err: fmt.Errorf("cannot remove previous results: %w", err)}
+	}
+	annotations := make(map[string]string)
+	for _, a := range engine.Metadata.GetAnnotations() {
+		annotations[a.Key] = a.Value
+	}
+	// marshalling a map of strings cannot fail
+	annotationsJSON, _ := json.Marshal(annotations)
+
+	cmd := exec.CommandContext(ctx, spec.Command[0], spec.Command[1:]...) //nolint:gosec // G204: specs are configured by the server operator
+	cmd.Dir = dir
+	cmd.Stdout = logs
+	cmd.Stderr = logs
+	cmd.Env = append(os.Environ(),
+		EnvEngineName+"="+engine.Name,
+		EnvEngineSpec+"="+engine.Metadata.GetEngineSpecName(),
+		EnvAnnotations+"="+string(annotationsJSON),
+		EnvResults+"="+results,
+	)
+	keys := make([]string, 0, len(spec.Env))
+	for k := range spec.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+spec.Env[k])
+	}
+	cmd.Env = append(cmd.Env, env...)
+
+	var res Outcome
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			res.ExitCode = exitErr.ExitCode()
+		}
+		res.Err = err
+	}
+	if ctx.Err() != nil {
+		res.Err = ctx.Err()
+	}
+
+	var rerr error
+	res.Results, rerr = readResults(results)
+	if rerr != nil && res.Err == nil {
+		res.Err = rerr
+	}
+	return res
+}
+
+// readResults reads the results an engine wrote. Engines need not write any.
+func readResults(fn string) ([]*v1.EngineResult, error) {
+	f, err := os.Open(fn)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var res []*v1.EngineResult
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r v1.EngineResult
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("invalid result %q: %w", scanner.Text(), err)
+		}
+		res = append(res, &r)
+	}
+	return res, scanner.Err()
+}
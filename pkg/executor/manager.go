@@ -0,0 +1,409 @@
+package executor
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/queue"
+	"github.com/bhojpur/text/pkg/retry"
+	"github.com/bhojpur/text/pkg/store"
+	"github.com/bhojpur/text/pkg/tracing"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/codes"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AnnotationStopRequested marks an engine which is to stop. StopEngine sets it on any replica,
+// the leader stops the engine once it learns about the change.
+const AnnotationStopRequested = "text.bhojpur.net/stop-requested"
+
+// StopRequested returns true if the engine is to stop
+func StopRequested(status *v1.EngineStatus) bool {
+	for _, a := range status.Metadata.GetAnnotations() {
+		if a.Key == AnnotationStopRequested {
+			return true
+		}
+	}
+	return false
+}
+
+// ResumeInterval is the time between two attempts to load the unfinished engines when a replica starts leading
+const ResumeInterval = 15 * time.Second
+
+// errStopped is the failure of engines which were stopped on request
+var errStopped = errors.New("stopped")
+
+// Executor runs engines
+type Executor interface {
+	// Run runs the engine until it's done or the context is canceled
+	Run(ctx context.Context, engine *v1.EngineStatus, spec *Spec, env []string) Outcome
+}
+
+// Manager runs the engines of the store on the leader. New engines wait in a queue until the
+// concurrency limits permit them to start, waiting engines until their start time. Failed engines
+// are retried according to the retry policy of their spec. The manager
+// learns about new and stopped engines through Update, which must be called with every engine
+// status change. A Manager is safe for concurrent use.
+type Manager struct {
+	Engines  store.Store
+	Executor Executor
+	// Retrier counts the failures of engines and decides if they are retried. Optional.
+	Retrier *retry.Retrier
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+	// Lifecycle traces the execution of engines. Optional. Executors get the trace either way
+	// through their environment, see tracing.Environment.
+	Lifecycle *tracing.Lifecycle
+
+	queue *queue.Queue
+
+	mu    sync.Mutex
+	specs Specs
+	// ctx is the context of Run, nil unless this replica leads
+	ctx     context.Context
+	engines map[string]*managed
+	wg      sync.WaitGroup
+}
+
+// managed is an engine the manager is queueing, waiting for or running
+type managed struct {
+	// timer starts a waiting engine
+	timer *time.Timer
+	// cancel stops a running engine
+	cancel context.CancelFunc
+	// stopped is true once the running engine was asked to stop
+	stopped bool
+}
+
+// NewManager creates a new manager which runs the engines of the specs within the limits
+func NewManager(engines store.Store, executor Executor, specs Specs, limits queue.Limits) *Manager {
+	m := &Manager{
+		Engines:  engines,
+		Executor: executor,
+		queue:    queue.New(limits),
+		specs:    specs,
+		engines:  make(map[string]*managed),
+	}
+	// the queue explains the position of queued engines in their details
+	m.queue.OnUpdate = func(status *v1.EngineStatus) {
+		m.save(context.Background(), proto.Clone(status).(*v1.EngineStatus))
+	}
+	return m
+}
+
+// Spec returns the spec of the given name
+func (m *Manager) Spec(name string) (*Spec, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.specs[name]
+	return s, ok
+}
+
+// SetSpecs replaces the specs, e.g. when the spec directory has changed. Engines which are
+// already running keep their spec.
+func (m *Manager) SetSpecs(specs Specs) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.specs = specs
+}
+
+// SetLimits changes the concurrency limits and starts the engines the new limits permit
+func (m *Manager) SetLimits(limits queue.Limits) {
+	m.queue.SetLimits(limits)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.admit()
+}
+
+// Run runs engines until the context is canceled, i.e. while this replica leads. It takes over
+// the unfinished engines of the store, and runs the engines Update learns about. Once the context
+// is canceled Run stops the engines and returns after they have stopped. Their status is left as
+// it is, for the next leader to take over.
+func (m *Manager) Run(ctx context.Context) {
+	m.mu.Lock()
+	m.ctx = ctx
+	m.mu.Unlock()
+	defer m.stop()
+
+	for {
+		err := m.resume(ctx)
+		if err == nil {
+			break
+		}
+		log.WithError(err).Warn("cannot load unfinished engines")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ResumeInterval):
+		}
+	}
+	<-ctx.Done()
+}
+
+// resume takes over the unfinished engines of the store. Engines which were running belonged to a
+// previous leader, which went away before they finished.
+func (m *Manager) resume(ctx context.Context) error {
+	unfinished := []*v1.FilterExpression{{Terms: []*v1.FilterTerm{{Field: "phase", Value: "done", Negate: true}}}}
+	engines, _, err := m.Engines.Find(ctx, unfinished, nil, 0, 0)
+	if err != nil {
+		return err
+	}
+	for _, e := range engines {
+		m.handle(ctx, e, true)
+	}
+	return nil
+}
+
+// stop stops all engines and waits for them
+func (m *Manager) stop() {
+	m.mu.Lock()
+	m.ctx = nil
+	for name, e := range m.engines {
+		if e.timer != nil {
+			e.timer.Stop()
+		}
+		if e.cancel != nil {
+			e.cancel()
+		}
+		m.queue.Remove(name)
+	}
+	m.engines = make(map[string]*managed)
+	m.mu.Unlock()
+
+	m.wg.Wait()
+}
+
+// Update queues new engines, and stops engines which were requested to stop. It does nothing
+// unless Run is running.
+func (m *Manager) Update(ctx context.Context, status *v1.EngineStatus) {
+	m.handle(ctx, proto.Clone(status).(*v1.EngineStatus), false)
+}
+
+func (m *Manager) handle(ctx context.Context, e *v1.EngineStatus, resume bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx == nil {
+		return
+	}
+
+	me, known := m.engines[e.Name]
+	if StopRequested(e) {
+		switch {
+		case known && me.cancel != nil:
+			me.stopped = true
+			me.cancel()
+		case known:
+			if me.timer != nil {
+				me.timer.Stop()
+			}
+			m.queue.Remove(e.Name)
+			delete(m.engines, e.Name)
+			m.finish(ctx, e, Outcome{Err: errStopped}, true)
+		case e.Phase != v1.EnginePhase_PHASE_DONE:
+			m.finish(ctx, e, Outcome{Err: errStopped}, true)
+		}
+		return
+	}
+	if known {
+		return
+	}
+
+	switch e.Phase {
+	case v1.EnginePhase_PHASE_PREPARING:
+		m.enqueue(e)
+	case v1.EnginePhase_PHASE_WAITING:
+		m.wait(e)
+	case v1.EnginePhase_PHASE_STARTING, v1.EnginePhase_PHASE_RUNNING:
+		if resume {
+			m.finish(ctx, e, Outcome{Err: fmt.Errorf("the server running the engine went away")}, false)
+		}
+	}
+}
+
+// enqueue queues an engine. Must be called with m.mu held.
+func (m *Manager) enqueue(e *v1.EngineStatus) {
+	priority, err := queue.Priority(e)
+	if err != nil {
+		log.WithError(err).WithField("name", e.Name).Warn("ignoring engine priority")
+	}
+	if _, known := m.engines[e.Name]; !known {
+		m.engines[e.Name] = &managed{}
+	}
+	m.queue.Add(e, priority)
+	m.admit()
+}
+
+// wait queues an engine once its start time has come. Must be called with m.mu held.
+func (m *Manager) wait(e *v1.EngineStatus) {
+	waitUntil := e.Conditions.GetWaitUntil()
+	if waitUntil == nil {
+		m.enqueue(e)
+		return
+	}
+
+	me := &managed{}
+	m.engines[e.Name] = me
+	me.timer = time.AfterFunc(waitUntil.AsTime().Sub(m.now()), func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.engines[e.Name] != me || me.timer == nil {
+			// the engine was stopped or this replica stopped leading meanwhile
+			return
+		}
+		me.timer = nil
+		m.enqueue(e)
+	})
+}
+
+// admit starts the engines the queue admits. Must be called with m.mu held.
+func (m *Manager) admit() {
+	if m.ctx == nil {
+		return
+	}
+	for _, e := range m.queue.Admit() {
+		me, ok := m.engines[e.Name]
+		if !ok {
+			m.queue.Release(e.Name)
+			continue
+		}
+		ctx, cancel := context.WithCancel(m.ctx)
+		me.cancel = cancel
+
+		m.wg.Add(1)
+		go m.run(m.ctx, ctx, e, me)
+	}
+}
+
+// run runs an admitted engine and records its outcome
+func (m *Manager) run(leaderCtx, ctx context.Context, e *v1.EngineStatus, me *managed) {
+	defer m.wg.Done()
+	defer me.cancel()
+
+	spec, ok := m.Spec(e.Metadata.GetEngineSpecName())
+	var out Outcome
+	if ok {
+		e.Phase = v1.EnginePhase_PHASE_RUNNING
+		e.Details = ""
+		if e.Conditions == nil {
+			e.Conditions = &v1.EngineConditions{}
+		}
+		e.Conditions.DidExecute = true
+		m.save(ctx, e)
+
+		out = m.execute(ctx, e, spec)
+	} else {
+		out.Err = fmt.Errorf("spec %s does not exist", e.Metadata.GetEngineSpecName())
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queue.Release(e.Name)
+	if leaderCtx.Err() != nil {
+		// this replica stopped leading, the next leader takes over
+		return
+	}
+	delete(m.engines, e.Name)
+	m.finish(leaderCtx, e, out, me.stopped)
+	m.admit()
+}
+
+// execute runs the engine, passing its trace on to the executor
+func (m *Manager) execute(ctx context.Context, e *v1.EngineStatus, spec *Spec) Outcome {
+	if m.Lifecycle == nil {
+		return m.Executor.Run(ctx, e, spec, tracing.Environment(e.Metadata))
+	}
+
+	traceCtx, span := m.Lifecycle.Stage(e, "execute")
+	defer span.End()
+	// the executor continues the trace from the execute span
+	md := proto.Clone(e.Metadata).(*v1.EngineMetadata)
+	tracing.Annotate(traceCtx, md)
+
+	out := m.Executor.Run(ctx, e, spec, tracing.Environment(md))
+	if out.Err != nil {
+		span.SetStatus(codes.Error, out.Err.Error())
+	}
+	return out
+}
+
+// finish records the outcome of an engine. Failed engines wait for their next attempt if their
+// retry policy permits one. Must be called with m.mu held.
+func (m *Manager) finish(ctx context.Context, e *v1.EngineStatus, out Outcome, stopped bool) {
+	if e.Conditions == nil {
+		e.Conditions = &v1.EngineConditions{}
+	}
+	if e.Metadata == nil {
+		e.Metadata = &v1.EngineMetadata{}
+	}
+	if out.Err != nil && !stopped && m.Retrier != nil {
+		failure := retry.Failure{ExitCode: out.ExitCode, Message: out.Err.Error()}
+		if m.Retrier.Fail(e, failure) {
+			e.Results = out.Results
+			m.save(ctx, e)
+			m.wait(e)
+			return
+		}
+	}
+
+	e.Phase = v1.EnginePhase_PHASE_DONE
+	e.Results = out.Results
+	e.Metadata.Finished = timestamppb.New(m.now())
+	e.Conditions.Success = out.Err == nil && !stopped
+	e.Conditions.WaitUntil = nil
+	switch {
+	case stopped:
+		e.Details = "stopped"
+	case out.Err != nil:
+		e.Details = out.Err.Error()
+	default:
+		e.Details = ""
+	}
+	m.save(ctx, e)
+}
+
+// save stores an engine. StopEngine may have requested the engine to stop meanwhile, which
+// the stored status must keep.
+func (m *Manager) save(ctx context.Context, e *v1.EngineStatus) {
+	if cur, err := m.Engines.Get(ctx, e.Name); err == nil && StopRequested(cur) && !StopRequested(e) {
+		if e.Metadata == nil {
+			e.Metadata = &v1.EngineMetadata{}
+		}
+		e.Metadata.Annotations = append(e.Metadata.Annotations, &v1.Annotation{Key: AnnotationStopRequested, Value: "true"})
+	}
+	if err := m.Engines.Store(ctx, e); err != nil {
+		log.WithError(err).WithField("name", e.Name).Warn("cannot store engine")
+	}
+}
+
+func (m *Manager) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
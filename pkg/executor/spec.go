@@ -0,0 +1,99 @@
+package executor
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package executor runs engines. The leader runs the command of an engine's spec as a process
+// in a directory of its own, queues engines until the concurrency limits permit them to start
+// and records their outcome in the engine store.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Spec is an engine spec, i.e. a .yaml file of the server's spec directory
+type Spec struct {
+	// DependsOn lists the names of the specs which must succeed before the spec can start
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Command is the command the engine runs, e.g. [sh, -c, "make index"]
+	Command []string `json:"command"`
+	// Env are additional environment variables of the command
+	Env map[string]string `json:"env,omitempty"`
+}
+
+// Specs are engine specs by name
+type Specs map[string]*Spec
+
+// ParseSpec parses an engine spec
+func ParseSpec(spec []byte) (*Spec, error) {
+	var res Spec
+	if err := yaml.Unmarshal(spec, &res); err != nil {
+		return nil, err
+	}
+	if len(res.Command) == 0 {
+		return nil, fmt.Errorf("command is required")
+	}
+	return &res, nil
+}
+
+// LoadSpecs loads the engine specs of a directory. Specs are the .yaml and .yml files of the
+// directory, named after the file without its extension, e.g. index for index.yaml.
+func LoadSpecs(dir string) (Specs, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(Specs)
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), ext)
+		if _, exists := res[name]; exists {
+			return nil, fmt.Errorf("spec %s is defined twice in %s", name, dir)
+		}
+		fc, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		spec, err := ParseSpec(fc)
+		if err != nil {
+			return nil, fmt.Errorf("spec %s: %w", name, err)
+		}
+		res[name] = spec
+	}
+	return res, nil
+}
+
+// SpecName returns the name of the spec an engine path refers to, e.g. index for .text/index.yaml
+func SpecName(enginePath string) string {
+	base := filepath.Base(enginePath)
+	if ext := filepath.Ext(base); ext == ".yaml" || ext == ".yml" {
+		base = strings.TrimSuffix(base, ext)
+	}
+	return base
+}
@@ -0,0 +1,124 @@
+package filterexpr
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+)
+
+// operators maps the textual filter operators to their FilterOp. Longer operators
+// come first so that "!=" is not mistaken for "=".
+var operators = []struct {
+	Token  string
+	Op     v1.FilterOp
+	Negate bool
+}{
+	{"==", v1.FilterOp_OP_EQUALS, false},
+	{"!=", v1.FilterOp_OP_EQUALS, true},
+	{"~=", v1.FilterOp_OP_CONTAINS, false},
+	{"|=", v1.FilterOp_OP_STARTS_WITH, false},
+	{"=|", v1.FilterOp_OP_ENDS_WITH, false},
+	{">=", v1.FilterOp_OP_GREATER_EQUAL, false},
+	{"<", v1.FilterOp_OP_GREATER_EQUAL, true},
+}
+
+// Parse parses filter terms of the form
+//
+//	field==value    field equals value
+//	field!=value    field does not equal value
+//	field~=value    field contains value
+//	field|=value    field starts with value
+//	field=|value    field ends with value
+//	field>=value    field is greater than or equal to value
+//	field<value     field is less than value
+//	field           field exists
+//
+// Prefixing a term with ! negates it. Each term becomes a filter expression of its own,
+// i.e. all terms must match.
+func Parse(terms []string) ([]*v1.FilterExpression, error) {
+	res := make([]*v1.FilterExpression, 0, len(terms))
+	for _, t := range terms {
+		term, err := ParseTerm(t)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, &v1.FilterExpression{Terms: []*v1.FilterTerm{term}})
+	}
+	return res, nil
+}
+
+// ParseTerm parses a single filter term. See Parse for the syntax.
+func ParseTerm(expr string) (*v1.FilterTerm, error) {
+	expr = strings.TrimSpace(expr)
+	var negate bool
+	if strings.HasPrefix(expr, "!") {
+		negate = true
+		expr = strings.TrimPrefix(expr, "!")
+	}
+
+	var (
+		idx = -1
+		op  = operators[0]
+	)
+	for _, o := range operators {
+		i := strings.Index(expr, o.Token)
+		if i < 0 {
+			continue
+		}
+		if idx < 0 || i < idx {
+			idx, op = i, o
+		}
+	}
+	if idx < 0 {
+		if !isField(expr) {
+			return nil, fmt.Errorf("invalid filter %q: expected field<op>value", expr)
+		}
+		return &v1.FilterTerm{Field: expr, Operation: v1.FilterOp_OP_EXISTS, Negate: negate}, nil
+	}
+
+	field, value := strings.TrimSpace(expr[:idx]), strings.TrimSpace(expr[idx+len(op.Token):])
+	if !isField(field) {
+		return nil, fmt.Errorf("invalid filter %q: invalid field %q", expr, field)
+	}
+	return &v1.FilterTerm{
+		Field:     field,
+		Value:     value,
+		Operation: op.Op,
+		Negate:    negate != op.Negate,
+	}, nil
+}
+
+func isField(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '_', c == '-', c == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
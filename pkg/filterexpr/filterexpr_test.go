@@ -0,0 +1,145 @@
+package filterexpr
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestParseTerm(t *testing.T) {
+	tests := []struct {
+		Expr        string
+		Expectation *v1.FilterTerm
+	}{
+		{"phase==done", &v1.FilterTerm{Field: "phase", Value: "done", Operation: v1.FilterOp_OP_EQUALS}},
+		{" phase != done ", &v1.FilterTerm{Field: "phase", Value: "done", Operation: v1.FilterOp_OP_EQUALS, Negate: true}},
+		{"name~=nightly", &v1.FilterTerm{Field: "name", Value: "nightly", Operation: v1.FilterOp_OP_CONTAINS}},
+		{"name|=nightly-", &v1.FilterTerm{Field: "name", Value: "nightly-", Operation: v1.FilterOp_OP_STARTS_WITH}},
+		{"name=|.1", &v1.FilterTerm{Field: "name", Value: ".1", Operation: v1.FilterOp_OP_ENDS_WITH}},
+		{"!name|=nightly-", &v1.FilterTerm{Field: "name", Value: "nightly-", Operation: v1.FilterOp_OP_STARTS_WITH, Negate: true}},
+		{"!phase!=done", &v1.FilterTerm{Field: "phase", Value: "done", Operation: v1.FilterOp_OP_EQUALS}},
+		{"annotation.foo", &v1.FilterTerm{Field: "annotation.foo", Operation: v1.FilterOp_OP_EXISTS}},
+		{"time>=2022-03-01T00:00:00Z", &v1.FilterTerm{Field: "time", Value: "2022-03-01T00:00:00Z", Operation: v1.FilterOp_OP_GREATER_EQUAL}},
+		{"time<2022-03-02T00:00:00Z", &v1.FilterTerm{Field: "time", Value: "2022-03-02T00:00:00Z", Operation: v1.FilterOp_OP_GREATER_EQUAL, Negate: true}},
+		{"name==a!=b", &v1.FilterTerm{Field: "name", Value: "a!=b", Operation: v1.FilterOp_OP_EQUALS}},
+		{"repo.owner==", &v1.FilterTerm{Field: "repo.owner", Operation: v1.FilterOp_OP_EQUALS}},
+	}
+	for _, test := range tests {
+		t.Run(test.Expr, func(t *testing.T) {
+			act, err := ParseTerm(test.Expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !proto.Equal(act, test.Expectation) {
+				t.Errorf("expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "==done", "some field==x", "!"} {
+		if _, err := ParseTerm(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	res, err := Parse([]string{"phase==done", "owner==alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 2 || len(res[0].Terms) != 1 || res[1].Terms[0].Field != "owner" {
+		t.Errorf("unexpected result %v", res)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	status := &v1.EngineStatus{
+		Name:  "nightly-index.3",
+		Phase: v1.EnginePhase_PHASE_DONE,
+		Metadata: &v1.EngineMetadata{
+			Owner:          "alice",
+			EngineSpecName: "index",
+			Trigger:        v1.EngineTrigger_TRIGGER_SCHEDULED,
+			Repository:     &v1.Repository{Host: "github.com", Owner: "bhojpur", Repo: "text", Ref: "main"},
+			Annotations:    []*v1.Annotation{{Key: "lang", Value: "en"}},
+			Created:        timestamppb.New(time.Date(2022, 3, 1, 9, 30, 0, 0, time.UTC)),
+		},
+		Conditions: &v1.EngineConditions{Success: true},
+	}
+
+	tests := []struct {
+		Terms       []string
+		Expectation bool
+	}{
+		{[]string{"trigger==scheduled"}, true},
+		{[]string{"trigger==TRIGGER_SCHEDULED"}, true},
+		{[]string{"trigger==manual"}, false},
+		{[]string{"phase==done", "owner==alice"}, true},
+		{[]string{"phase==done", "owner==bob"}, false},
+		{[]string{"phase!=done"}, false},
+		{[]string{"name|=nightly-", "name=|.3", "name~=index"}, true},
+		{[]string{"repo.repo==text", "repo.ref==main"}, true},
+		{[]string{"annotation.lang==en"}, true},
+		{[]string{"annotation.lang"}, true},
+		{[]string{"!annotation.missing"}, true},
+		{[]string{"annotation.missing"}, false},
+		{[]string{"success==true"}, true},
+		{[]string{"name>=nightly", "name<nightly-z"}, true},
+		{[]string{"name<nightly"}, false},
+		// times compare chronologically, not as strings: 10:30+02:00 is before 09:30Z
+		{[]string{"created>=2022-03-01T10:30:00+02:00"}, true},
+		{[]string{"created<2022-03-01T10:30:00+02:00"}, false},
+		{[]string{"created>=2022-03-01T09:30:00.5Z"}, false},
+		{[]string{"created==2022-03-01T11:30:00+02:00"}, true},
+		{[]string{"created>=yesterday"}, false},
+		{[]string{"created"}, true},
+		{[]string{"finished"}, false},
+		{[]string{"finished>=2000-01-01T00:00:00Z"}, false},
+		{[]string{"unknown==x"}, false},
+		{nil, true},
+	}
+	for _, test := range tests {
+		filter, err := Parse(test.Terms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act := Match(status, filter); act != test.Expectation {
+			t.Errorf("Match(%v) = %v, expected %v", test.Terms, act, test.Expectation)
+		}
+	}
+
+	// terms within an expression are alternatives
+	or := []*v1.FilterExpression{{Terms: []*v1.FilterTerm{
+		{Field: "owner", Value: "bob"},
+		{Field: "owner", Value: "alice"},
+	}}}
+	if !Match(status, or) {
+		t.Error("expected any term of an expression to match")
+	}
+}
@@ -0,0 +1,175 @@
+package filterexpr
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Match returns true if the engine matches all filter expressions. A filter expression
+// matches if any of its terms matches. Supported fields are name, phase, owner, spec,
+// trigger, success, created, finished, repo.host, repo.owner, repo.repo, repo.ref,
+// repo.revision and annotation.<key>. Phases and triggers compare by their short lower
+// case name, e.g. "done" or "scheduled", as well as by their full name, e.g. PHASE_DONE.
+// The times created and finished compare chronologically with RFC3339 values.
+func Match(status *v1.EngineStatus, filter []*v1.FilterExpression) bool {
+	for _, expr := range filter {
+		if len(expr.Terms) == 0 {
+			continue
+		}
+
+		var matched bool
+		for _, term := range expr.Terms {
+			if matchTerm(status, term) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func matchTerm(status *v1.EngineStatus, term *v1.FilterTerm) bool {
+	if ts, ok := timeField(status, term.Field); ok {
+		return matchTime(ts, term) != term.Negate
+	}
+
+	vals, exists := fieldValues(status, term.Field)
+
+	var res bool
+	if term.Operation == v1.FilterOp_OP_EXISTS {
+		res = exists
+	} else {
+		for _, v := range vals {
+			if matchValue(v, term.Value, term.Operation) {
+				res = true
+				break
+			}
+		}
+	}
+	return res != term.Negate
+}
+
+// fieldValues returns the values a field can be compared against
+func fieldValues(status *v1.EngineStatus, field string) (vals []string, exists bool) {
+	md := status.Metadata
+	switch field {
+	case "name":
+		return []string{status.Name}, true
+	case "phase":
+		return enumValues(status.Phase.String(), "PHASE_"), true
+	case "trigger":
+		return enumValues(md.GetTrigger().String(), "TRIGGER_"), true
+	case "owner":
+		return []string{md.GetOwner()}, md.GetOwner() != ""
+	case "spec":
+		return []string{md.GetEngineSpecName()}, md.GetEngineSpecName() != ""
+	case "success":
+		return []string{fmt.Sprint(status.Conditions.GetSuccess())}, status.Conditions != nil
+	case "repo.host":
+		return []string{md.GetRepository().GetHost()}, md.GetRepository() != nil
+	case "repo.owner":
+		return []string{md.GetRepository().GetOwner()}, md.GetRepository() != nil
+	case "repo.repo":
+		return []string{md.GetRepository().GetRepo()}, md.GetRepository() != nil
+	case "repo.ref":
+		return []string{md.GetRepository().GetRef()}, md.GetRepository() != nil
+	case "repo.revision":
+		return []string{md.GetRepository().GetRevision()}, md.GetRepository() != nil
+	}
+
+	if key := strings.TrimPrefix(field, "annotation."); key != field {
+		for _, a := range md.GetAnnotations() {
+			if a.Key == key {
+				vals = append(vals, a.Value)
+				exists = true
+			}
+		}
+		return vals, exists
+	}
+	return nil, false
+}
+
+// timeField returns the value of a time field. ok is false if the field is no time field.
+func timeField(status *v1.EngineStatus, field string) (ts *timestamppb.Timestamp, ok bool) {
+	switch field {
+	case "created":
+		return status.Metadata.GetCreated(), true
+	case "finished":
+		return status.Metadata.GetFinished(), true
+	}
+	return nil, false
+}
+
+// matchTime compares a time field with the RFC3339 value of the term. Operations other than
+// equality and >= compare the RFC3339 representation of the time.
+func matchTime(ts *timestamppb.Timestamp, term *v1.FilterTerm) bool {
+	if term.Operation == v1.FilterOp_OP_EXISTS {
+		return ts != nil
+	}
+	if ts == nil {
+		return false
+	}
+
+	val := ts.AsTime()
+	switch term.Operation {
+	case v1.FilterOp_OP_EQUALS, v1.FilterOp_OP_GREATER_EQUAL:
+		expectation, err := time.Parse(time.RFC3339Nano, term.Value)
+		if err != nil {
+			return false
+		}
+		if term.Operation == v1.FilterOp_OP_EQUALS {
+			return val.Equal(expectation)
+		}
+		return !val.Before(expectation)
+	default:
+		return matchValue(val.Format(time.RFC3339Nano), term.Value, term.Operation)
+	}
+}
+
+func enumValues(name, prefix string) []string {
+	return []string{name, strings.ToLower(strings.TrimPrefix(name, prefix))}
+}
+
+func matchValue(val, expectation string, op v1.FilterOp) bool {
+	switch op {
+	case v1.FilterOp_OP_EQUALS:
+		return val == expectation
+	case v1.FilterOp_OP_STARTS_WITH:
+		return strings.HasPrefix(val, expectation)
+	case v1.FilterOp_OP_ENDS_WITH:
+		return strings.HasSuffix(val, expectation)
+	case v1.FilterOp_OP_CONTAINS:
+		return strings.Contains(val, expectation)
+	case v1.FilterOp_OP_GREATER_EQUAL:
+		return val >= expectation
+	default:
+		return false
+	}
+}
@@ -0,0 +1,81 @@
+package health
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Pinger is implemented by connections which can verify they are alive, e.g. *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck checks store connectivity by pinging the connection
+func PingCheck(p Pinger) Checker {
+	return CheckerFunc(p.PingContext)
+}
+
+// WritableDirCheck checks that files can be created in dir, e.g. the log store or the spool directory
+func WritableDirCheck(dir string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		f, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(f.Name())
+
+		if _, err := f.WriteString("ok"); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+}
+
+// KubernetesCheck checks that the Kubernetes executor can reach the API server and list pods in its namespace
+func KubernetesCheck(clientSet kubernetes.Interface, namespace string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		_, err := clientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{Limit: 1})
+		if err != nil {
+			return fmt.Errorf("cannot list pods in %s: %w", namespace, err)
+		}
+		return nil
+	})
+}
+
+// LeaseCheck checks that the API server can be reached for leader election, i.e. that the lease
+// can be read. A lease which does not exist yet is fine, the first leader creates it.
+func LeaseCheck(clientSet kubernetes.Interface, namespace, name string) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		_, err := clientSet.CoordinationV1().Leases(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("cannot get lease %s/%s: %w", namespace, name, err)
+		}
+		return nil
+	})
+}
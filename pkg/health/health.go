@@ -0,0 +1,262 @@
+package health
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package health reports the health of the Bhojpur Text server through the standard
+// grpc.health.v1 service and the HTTP endpoints /healthz and /readyz used by Kubernetes probes.
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// DefaultInterval is the default time between two rounds of checks
+	DefaultInterval = 10 * time.Second
+
+	// DefaultTimeout is the default time a single check may take
+	DefaultTimeout = 5 * time.Second
+)
+
+// Checker checks a dependency of the server, e.g. the store or the executor
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc implements Checker using a function
+type CheckerFunc func(ctx context.Context) error
+
+// Check calls f(ctx)
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Monitor periodically runs all checks and publishes the outcome. The server is ready once all
+// checks pass. It is live as long as the checks keep running, i.e. the monitor is not stuck.
+type Monitor struct {
+	Interval time.Duration
+	Timeout  time.Duration
+
+	server *health.Server
+	checks map[string]Checker
+
+	// services are the gRPC services whose serving status follows the readiness of the server.
+	// The empty name denotes the server as a whole.
+	services []string
+
+	mu           sync.RWMutex
+	results      map[string]error
+	lastRun      time.Time
+	shuttingDown bool
+	now          func() time.Time
+}
+
+// NewMonitor creates a monitor for the checks, keyed by their name. Until the first round of
+// checks has completed the server is reported as not serving.
+func NewMonitor(checks map[string]Checker) *Monitor {
+	m := &Monitor{
+		Interval: DefaultInterval,
+		Timeout:  DefaultTimeout,
+		server:   health.NewServer(),
+		checks:   checks,
+		services: []string{""},
+		now:      time.Now,
+	}
+	m.server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return m
+}
+
+// Register registers the grpc.health.v1 service with the gRPC server. The serving status of
+// the services registered with srv so far follows the readiness of the server, hence Register
+// must be called after all other services were registered.
+func (m *Monitor) Register(srv *grpc.Server) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if !m.readyLocked() {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	for name := range srv.GetServiceInfo() {
+		m.services = append(m.services, name)
+		m.server.SetServingStatus(name, status)
+	}
+	healthpb.RegisterHealthServer(srv, m.server)
+}
+
+// Run checks periodically until the context is canceled
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+	for {
+		m.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check runs all checks concurrently and publishes the outcome
+func (m *Monitor) Check(ctx context.Context) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error, len(m.checks))
+	)
+	for name, c := range m.checks {
+		wg.Add(1)
+		go func(name string, c Checker) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, m.Timeout)
+			defer cancel()
+			err := c.Check(ctx)
+
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, c)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.results = results
+	m.lastRun = m.now()
+	if m.shuttingDown {
+		return
+	}
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if !m.readyLocked() {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	for _, s := range m.services {
+		m.server.SetServingStatus(s, status)
+	}
+}
+
+// Shutdown marks the server as not serving for good, so that clients and load balancers
+// stop sending new requests while the server drains
+func (m *Monitor) Shutdown() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shuttingDown = true
+	m.server.Shutdown()
+}
+
+// Ready returns true if all checks passed in the last round
+func (m *Monitor) Ready() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.readyLocked()
+}
+
+func (m *Monitor) readyLocked() bool {
+	if m.shuttingDown || m.results == nil {
+		return false
+	}
+	for _, err := range m.results {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Live returns false if the checks have not completed for three intervals, e.g. because a check hangs
+func (m *Monitor) Live() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.lastRun.IsZero() {
+		// we're still starting up
+		return true
+	}
+	return m.now().Sub(m.lastRun) < 3*m.Interval
+}
+
+// Handler serves /healthz and /readyz. Both respond with 200 if the server is live or ready
+// respectively, and 503 otherwise. /readyz lists the outcome of each check if it fails, or
+// if the verbose query parameter is present.
+func (m *Monitor) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if !m.Live() {
+			http.Error(w, "checks are not running", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		ready, report := m.report()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if !ready {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprint(w, report)
+			return
+		}
+		if _, verbose := r.URL.Query()["verbose"]; verbose {
+			fmt.Fprint(w, report)
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	return mux
+}
+
+// report lists the outcome of each check in the style of the Kubernetes API server
+func (m *Monitor) report() (ready bool, report string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var b strings.Builder
+	switch {
+	case m.shuttingDown:
+		b.WriteString("[-]shutdown: server is shutting down\n")
+	case m.results == nil:
+		b.WriteString("[-]startup: checks have not run yet\n")
+	}
+	names := make([]string, 0, len(m.results))
+	for name := range m.results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := m.results[name]; err != nil {
+			fmt.Fprintf(&b, "[-]%s failed: %v\n", name, err)
+		} else {
+			fmt.Fprintf(&b, "[+]%s ok\n", name)
+		}
+	}
+	return m.readyLocked(), b.String()
+}
@@ -0,0 +1,150 @@
+package health
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func servingStatus(t *testing.T, m *Monitor, service string) healthpb.HealthCheckResponse_ServingStatus {
+	resp, err := m.server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.Status
+}
+
+func get(h http.Handler, path string) (int, string) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestMonitor(t *testing.T) {
+	var storeErr error
+	m := NewMonitor(map[string]Checker{
+		"store":    CheckerFunc(func(ctx context.Context) error { return storeErr }),
+		"logstore": WritableDirCheck(t.TempDir()),
+	})
+	h := m.Handler()
+
+	srv := grpc.NewServer()
+	v1.RegisterTextServiceServer(srv, &v1.UnimplementedTextServiceServer{})
+	m.Register(srv)
+	if _, err := m.server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "v1.TextUI"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected no status for a service which is not registered, got %v", err)
+	}
+
+	if s := servingStatus(t, m, "v1.TextService"); s != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING before the first check, got %v", s)
+	}
+	if code, _ := get(h, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz to fail before the first check, got %d", code)
+	}
+
+	m.Check(context.Background())
+	if s := servingStatus(t, m, ""); s != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected SERVING, got %v", s)
+	}
+	if code, body := get(h, "/readyz?verbose"); code != http.StatusOK || !strings.Contains(body, "[+]logstore ok") {
+		t.Errorf("unexpected /readyz response %d: %s", code, body)
+	}
+
+	storeErr = errors.New("connection refused")
+	m.Check(context.Background())
+	if s := servingStatus(t, m, "v1.TextService"); s != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING with a failing check, got %v", s)
+	}
+	if code, body := get(h, "/readyz"); code != http.StatusServiceUnavailable || !strings.Contains(body, "[-]store failed: connection refused") {
+		t.Errorf("unexpected /readyz response %d: %s", code, body)
+	}
+	if code, _ := get(h, "/healthz"); code != http.StatusOK {
+		t.Errorf("expected server to be live despite failing checks, got %d", code)
+	}
+
+	storeErr = nil
+	m.Shutdown()
+	m.Check(context.Background())
+	if m.Ready() {
+		t.Error("expected server not to be ready after shutdown")
+	}
+	if s := servingStatus(t, m, ""); s != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING after shutdown, got %v", s)
+	}
+}
+
+func TestMonitorLive(t *testing.T) {
+	now := time.Now()
+	m := NewMonitor(nil)
+	m.now = func() time.Time { return now }
+
+	if !m.Live() {
+		t.Error("expected monitor to be live during startup")
+	}
+	m.Check(context.Background())
+	now = now.Add(2 * m.Interval)
+	if !m.Live() {
+		t.Error("expected monitor to be live")
+	}
+	now = now.Add(2 * m.Interval)
+	if m.Live() {
+		t.Error("expected monitor not to be live once checks stopped running")
+	}
+	if code, _ := get(m.Handler(), "/healthz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected /healthz to fail, got %d", code)
+	}
+}
+
+func TestChecks(t *testing.T) {
+	ctx := context.Background()
+	if err := WritableDirCheck(filepath.Join(t.TempDir(), "missing")).Check(ctx); err == nil {
+		t.Error("expected missing directory not to be writable")
+	}
+	if err := KubernetesCheck(fake.NewSimpleClientset(), "default").Check(ctx); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := LeaseCheck(fake.NewSimpleClientset(), "default", "text-server").Check(ctx); err != nil {
+		t.Errorf("unexpected error for a lease which does not exist yet: %v", err)
+	}
+	unreachable := fake.NewSimpleClientset()
+	unreachable.PrependReactor("get", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	if err := LeaseCheck(unreachable, "default", "text-server").Check(ctx); err == nil {
+		t.Error("expected error if the API server cannot be reached")
+	}
+}
@@ -0,0 +1,190 @@
+package history
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package history records the phase transitions and condition changes of engines,
+// so that one can tell how long an engine spent in each phase.
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Recorder records an event whenever the phase or conditions of an engine change.
+// Updates of different engines are recorded concurrently, those of the same engine in turn.
+// All methods are safe for concurrent use.
+type Recorder struct {
+	Store Store
+	// Now returns the time of an event which the engine metadata carries no time for. Defaults to time.Now.
+	Now func() time.Time
+
+	mu    sync.Mutex
+	last  map[string]*v1.EngineHistoryEvent
+	locks map[string]*engineLock
+}
+
+type engineLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// NewRecorder creates a recorder which stores events in store
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{Store: store}
+}
+
+// Record is called with every update of an engine. It stores an event if the phase or
+// conditions differ from the last event of the engine, and returns true if it did.
+func (r *Recorder) Record(ctx context.Context, s *v1.EngineStatus) (bool, error) {
+	unlock := r.lock(s.Name)
+	defer unlock()
+
+	r.mu.Lock()
+	last, ok := r.last[s.Name]
+	r.mu.Unlock()
+	if !ok {
+		// the server might have restarted since the last event was recorded
+		events, err := r.Store.Get(ctx, s.Name)
+		if err != nil {
+			return false, err
+		}
+		if len(events) > 0 {
+			last = events[len(events)-1]
+		}
+	}
+	if last != nil && last.Phase == s.Phase && sameConditions(last.Conditions, s.Conditions) {
+		return false, nil
+	}
+
+	evt := &v1.EngineHistoryEvent{
+		Time:    r.eventTime(s, last == nil),
+		Phase:   s.Phase,
+		Details: s.Details,
+	}
+	if s.Conditions != nil {
+		evt.Conditions = proto.Clone(s.Conditions).(*v1.EngineConditions)
+	}
+	if err := r.Store.Append(ctx, s.Name, evt); err != nil {
+		return false, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last == nil {
+		r.last = make(map[string]*v1.EngineHistoryEvent)
+	}
+	if s.Phase == v1.EnginePhase_PHASE_DONE {
+		// done engines do not change anymore
+		delete(r.last, s.Name)
+	} else {
+		r.last[s.Name] = evt
+	}
+	return true, nil
+}
+
+// lock serializes the updates of an engine. The store is accessed with the engine locked only,
+// so that slow store round trips of one engine do not hold up the others.
+func (r *Recorder) lock(name string) (unlock func()) {
+	r.mu.Lock()
+	if r.locks == nil {
+		r.locks = make(map[string]*engineLock)
+	}
+	l, ok := r.locks[name]
+	if !ok {
+		l = &engineLock{}
+		r.locks[name] = l
+	}
+	l.refs++
+	r.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		r.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(r.locks, name)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// eventTime returns the time of an event. The first event of an engine happened when the engine
+// was created, and it was done when it finished, if the metadata says so. All other events are
+// stamped with the time they're recorded.
+func (r *Recorder) eventTime(s *v1.EngineStatus, first bool) *timestamppb.Timestamp {
+	if finished := s.Metadata.GetFinished(); finished != nil && s.Phase == v1.EnginePhase_PHASE_DONE {
+		return proto.Clone(finished).(*timestamppb.Timestamp)
+	}
+	if created := s.Metadata.GetCreated(); created != nil && first {
+		return proto.Clone(created).(*timestamppb.Timestamp)
+	}
+	if r.Now != nil {
+		return timestamppb.New(r.Now())
+	}
+	return timestamppb.Now()
+}
+
+// sameConditions compares conditions, treating nil like the zero value as stores might not distinguish them
+func sameConditions(a, b *v1.EngineConditions) bool {
+	if a == nil {
+		a = &v1.EngineConditions{}
+	}
+	if b == nil {
+		b = &v1.EngineConditions{}
+	}
+	return proto.Equal(a, b)
+}
+
+// Forget removes the history of an engine, e.g. once the engine was removed by retention
+func (r *Recorder) Forget(ctx context.Context, name string) error {
+	unlock := r.lock(name)
+	defer unlock()
+
+	r.mu.Lock()
+	delete(r.last, name)
+	r.mu.Unlock()
+
+	return r.Store.Delete(ctx, name)
+}
+
+// GetEngineHistory implements the TextService RPC of the same name
+func (r *Recorder) GetEngineHistory(ctx context.Context, req *v1.GetEngineHistoryRequest) (*v1.GetEngineHistoryResponse, error) {
+	if req.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	events, err := r.Store.Get(ctx, req.Name)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if len(events) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no history for engine %s", req.Name)
+	}
+	return &v1.GetEngineHistoryResponse{Result: events}, nil
+}
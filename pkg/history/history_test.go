@@ -0,0 +1,237 @@
+package history
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRecorder(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = &MemoryStore{}
+		now   = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	)
+	newRecorder := func() *Recorder {
+		r := NewRecorder(store)
+		r.Now = func() time.Time { return now }
+		return r
+	}
+	r := newRecorder()
+
+	updates := []struct {
+		Status      *v1.EngineStatus
+		Expectation bool
+	}{
+		{&v1.EngineStatus{Name: "e", Phase: v1.EnginePhase_PHASE_PREPARING}, true},
+		{&v1.EngineStatus{Name: "e", Phase: v1.EnginePhase_PHASE_PREPARING, Details: "pulling image"}, false},
+		{&v1.EngineStatus{Name: "e", Phase: v1.EnginePhase_PHASE_STARTING}, true},
+		{&v1.EngineStatus{Name: "e", Phase: v1.EnginePhase_PHASE_RUNNING, Conditions: &v1.EngineConditions{}}, true},
+		{&v1.EngineStatus{Name: "e", Phase: v1.EnginePhase_PHASE_RUNNING, Conditions: &v1.EngineConditions{FailureCount: 1}}, true},
+		{&v1.EngineStatus{Name: "e", Phase: v1.EnginePhase_PHASE_RUNNING, Conditions: &v1.EngineConditions{FailureCount: 1}}, false},
+	}
+	for i, u := range updates {
+		now = now.Add(time.Second)
+		act, err := r.Record(ctx, u.Status)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act != u.Expectation {
+			t.Errorf("update %d: recorded %v, expected %v", i, act, u.Expectation)
+		}
+	}
+
+	// a new recorder, e.g. after a restart, continues from the stored history
+	r = newRecorder()
+	if act, err := r.Record(ctx, &v1.EngineStatus{Name: "e", Phase: v1.EnginePhase_PHASE_RUNNING, Conditions: &v1.EngineConditions{FailureCount: 1}}); err != nil || act {
+		t.Errorf("expected no event after restart, got %v, %v", act, err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := r.Record(ctx, &v1.EngineStatus{Name: "e", Phase: v1.EnginePhase_PHASE_DONE, Conditions: &v1.EngineConditions{FailureCount: 1, Success: true}}); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := r.GetEngineHistory(ctx, &v1.GetEngineHistoryRequest{Name: "e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var phases []v1.EnginePhase
+	for _, evt := range resp.Result {
+		phases = append(phases, evt.Phase)
+	}
+	expected := []v1.EnginePhase{
+		v1.EnginePhase_PHASE_PREPARING,
+		v1.EnginePhase_PHASE_STARTING,
+		v1.EnginePhase_PHASE_RUNNING,
+		v1.EnginePhase_PHASE_RUNNING,
+		v1.EnginePhase_PHASE_DONE,
+	}
+	if !reflect.DeepEqual(phases, expected) {
+		t.Errorf("expected phases %v, got %v", expected, phases)
+	}
+
+	if err := r.Forget(ctx, "e"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetEngineHistory(ctx, &v1.GetEngineHistoryRequest{Name: "e"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound after Forget, got %v", err)
+	}
+}
+
+func TestRecorderTimes(t *testing.T) {
+	var (
+		ctx      = context.Background()
+		created  = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+		now      = created.Add(time.Minute)
+		finished = created.Add(time.Hour)
+		md       = &v1.EngineMetadata{Created: timestamppb.New(created), Finished: timestamppb.New(finished)}
+	)
+	r := NewRecorder(&MemoryStore{})
+	r.Now = func() time.Time { return now }
+	for _, p := range []v1.EnginePhase{v1.EnginePhase_PHASE_PREPARING, v1.EnginePhase_PHASE_RUNNING, v1.EnginePhase_PHASE_DONE} {
+		if _, err := r.Record(ctx, &v1.EngineStatus{Name: "e", Phase: p, Metadata: md}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := r.GetEngineHistory(ctx, &v1.GetEngineHistoryRequest{Name: "e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var times []time.Time
+	for _, evt := range resp.Result {
+		times = append(times, evt.Time.AsTime())
+	}
+	if exp := []time.Time{created, now, finished}; !reflect.DeepEqual(times, exp) {
+		t.Errorf("expected times %v, got %v", exp, times)
+	}
+}
+
+// blockingStore blocks Get of the engine named block until release is closed
+type blockingStore struct {
+	MemoryStore
+	block   string
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) Get(ctx context.Context, name string) ([]*v1.EngineHistoryEvent, error) {
+	if name == s.block {
+		close(s.blocked)
+		<-s.release
+	}
+	return s.MemoryStore.Get(ctx, name)
+}
+
+func TestRecorderConcurrency(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		store = &blockingStore{block: "slow", blocked: make(chan struct{}), release: make(chan struct{})}
+		r     = NewRecorder(store)
+		done  = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		if _, err := r.Record(ctx, &v1.EngineStatus{Name: "slow", Phase: v1.EnginePhase_PHASE_RUNNING}); err != nil {
+			t.Error(err)
+		}
+	}()
+	<-store.blocked
+
+	// the slow store round trip of one engine must not hold up the others
+	if ok, err := r.Record(ctx, &v1.EngineStatus{Name: "fast", Phase: v1.EnginePhase_PHASE_RUNNING}); err != nil || !ok {
+		t.Errorf("expected fast engine to be recorded, got %v, %v", ok, err)
+	}
+	close(store.release)
+	<-done
+}
+
+func TestPeriods(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *timestamppb.Timestamp { return timestamppb.New(start.Add(d)) }
+	events := []*v1.EngineHistoryEvent{
+		{Time: at(0), Phase: v1.EnginePhase_PHASE_PREPARING},
+		{Time: at(2 * time.Second), Phase: v1.EnginePhase_PHASE_STARTING},
+		{Time: at(12 * time.Second), Phase: v1.EnginePhase_PHASE_RUNNING},
+		{Time: at(20 * time.Second), Phase: v1.EnginePhase_PHASE_RUNNING, Conditions: &v1.EngineConditions{FailureCount: 1}},
+		{Time: at(time.Minute), Phase: v1.EnginePhase_PHASE_DONE},
+	}
+
+	periods := Periods(events, start.Add(time.Hour))
+	if len(periods) != 4 {
+		t.Fatalf("expected 4 periods, got %v", periods)
+	}
+	durations := Durations(periods)
+	expected := map[v1.EnginePhase]time.Duration{
+		v1.EnginePhase_PHASE_PREPARING: 2 * time.Second,
+		v1.EnginePhase_PHASE_STARTING:  10 * time.Second,
+		v1.EnginePhase_PHASE_RUNNING:   48 * time.Second,
+		v1.EnginePhase_PHASE_DONE:      0,
+	}
+	if !reflect.DeepEqual(durations, expected) {
+		t.Errorf("expected durations %v, got %v", expected, durations)
+	}
+
+	// an engine which is still running is running until now
+	periods = Periods(events[:3], start.Add(30*time.Second))
+	if act := periods[len(periods)-1].Duration(); act != 18*time.Second {
+		t.Errorf("expected the running period to last until now, got %v", act)
+	}
+
+	if act := Periods(nil, start); len(act) != 0 {
+		t.Errorf("expected no periods without events, got %v", act)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	waitUntil := timestamppb.New(time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC))
+	tests := []struct {
+		Name        string
+		Prev, Cur   *v1.EngineConditions
+		Expectation []string
+	}{
+		{"none", &v1.EngineConditions{}, nil, nil},
+		{"failure", &v1.EngineConditions{}, &v1.EngineConditions{FailureCount: 1}, []string{"failure_count=1"}},
+		{"several", &v1.EngineConditions{CanReplay: true}, &v1.EngineConditions{Success: true, DidExecute: true}, []string{"success=true", "can_replay=false", "did_execute=true"}},
+		{"wait", nil, &v1.EngineConditions{WaitUntil: waitUntil}, []string{"wait_until=2021-03-01T12:00:00Z"}},
+		{"wait over", &v1.EngineConditions{WaitUntil: waitUntil}, &v1.EngineConditions{}, []string{"wait_until=-"}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := Changes(&v1.EngineHistoryEvent{Conditions: test.Prev}, &v1.EngineHistoryEvent{Conditions: test.Cur})
+			if !reflect.DeepEqual(act, test.Expectation) {
+				t.Errorf("expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+
+	if act := Changes(nil, &v1.EngineHistoryEvent{Conditions: &v1.EngineConditions{CanReplay: true}}); !reflect.DeepEqual(act, []string{"can_replay=true"}) {
+		t.Errorf("expected set conditions of the first event to count as changed, got %v", act)
+	}
+}
@@ -0,0 +1,113 @@
+package history
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// SQLStore stores the history in the engine_history table of a PostgreSQL database
+type SQLStore struct {
+	DB *sql.DB
+}
+
+// Migrate creates the engine_history table if it does not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS engine_history (
+			id            BIGSERIAL PRIMARY KEY,
+			engine        TEXT NOT NULL,
+			time          TIMESTAMPTZ NOT NULL,
+			phase         INTEGER NOT NULL,
+			success       BOOLEAN NOT NULL,
+			failure_count INTEGER NOT NULL,
+			can_replay    BOOLEAN NOT NULL,
+			wait_until    TIMESTAMPTZ,
+			did_execute   BOOLEAN NOT NULL,
+			details       TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS engine_history_engine ON engine_history (engine, id);
+	`)
+	return err
+}
+
+// Append implements Store
+func (s *SQLStore) Append(ctx context.Context, name string, evt *v1.EngineHistoryEvent) error {
+	var (
+		c         = evt.Conditions
+		waitUntil *time.Time
+	)
+	if c.GetWaitUntil() != nil {
+		t := c.WaitUntil.AsTime()
+		waitUntil = &t
+	}
+	_, err := s.DB.ExecContext(ctx,
+		`INSERT INTO engine_history (engine, time, phase, success, failure_count, can_replay, wait_until, did_execute, details) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		name, evt.Time.AsTime(), int32(evt.Phase), c.GetSuccess(), c.GetFailureCount(), c.GetCanReplay(), waitUntil, c.GetDidExecute(), evt.Details,
+	)
+	return err
+}
+
+// Get implements Store
+func (s *SQLStore) Get(ctx context.Context, name string) ([]*v1.EngineHistoryEvent, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		`SELECT time, phase, success, failure_count, can_replay, wait_until, did_execute, details FROM engine_history WHERE engine = $1 ORDER BY id`,
+		name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*v1.EngineHistoryEvent
+	for rows.Next() {
+		var (
+			evt       v1.EngineHistoryEvent
+			c         v1.EngineConditions
+			t         time.Time
+			phase     int32
+			waitUntil sql.NullTime
+		)
+		err := rows.Scan(&t, &phase, &c.Success, &c.FailureCount, &c.CanReplay, &waitUntil, &c.DidExecute, &evt.Details)
+		if err != nil {
+			return nil, err
+		}
+		evt.Time = timestamppb.New(t)
+		evt.Phase = v1.EnginePhase(phase)
+		if waitUntil.Valid {
+			c.WaitUntil = timestamppb.New(waitUntil.Time)
+		}
+		evt.Conditions = &c
+		res = append(res, &evt)
+	}
+	return res, rows.Err()
+}
+
+// Delete implements Store
+func (s *SQLStore) Delete(ctx context.Context, name string) error {
+	_, err := s.DB.ExecContext(ctx, `DELETE FROM engine_history WHERE engine = $1`, name)
+	return err
+}
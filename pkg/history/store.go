@@ -0,0 +1,81 @@
+package history
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"sync"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Store persists the history of engines
+type Store interface {
+	// Append adds an event to the history of an engine
+	Append(ctx context.Context, name string, evt *v1.EngineHistoryEvent) error
+
+	// Get returns the history of an engine, oldest first. Engines without history have no events.
+	Get(ctx context.Context, name string) ([]*v1.EngineHistoryEvent, error)
+
+	// Delete removes the history of an engine
+	Delete(ctx context.Context, name string) error
+}
+
+// MemoryStore keeps the history in memory, e.g. for tests or installations without a database
+type MemoryStore struct {
+	mu     sync.RWMutex
+	events map[string][]*v1.EngineHistoryEvent
+}
+
+// Append implements Store
+func (s *MemoryStore) Append(ctx context.Context, name string, evt *v1.EngineHistoryEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.events == nil {
+		s.events = make(map[string][]*v1.EngineHistoryEvent)
+	}
+	s.events[name] = append(s.events[name], proto.Clone(evt).(*v1.EngineHistoryEvent))
+	return nil
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(ctx context.Context, name string) ([]*v1.EngineHistoryEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := s.events[name]
+	res := make([]*v1.EngineHistoryEvent, len(events))
+	for i, evt := range events {
+		res[i] = proto.Clone(evt).(*v1.EngineHistoryEvent)
+	}
+	return res, nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.events, name)
+	return nil
+}
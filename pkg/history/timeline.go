@@ -0,0 +1,105 @@
+package history
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+)
+
+// Period is the time an engine spent in one phase
+type Period struct {
+	Phase v1.EnginePhase
+	Start time.Time
+	End   time.Time
+}
+
+// Duration returns the length of the period
+func (p Period) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// Periods turns the events of an engine, oldest first, into the periods the engine spent in each
+// phase. Events which only changed conditions do not start a new period. The last period ends at
+// now, unless the engine is done.
+func Periods(events []*v1.EngineHistoryEvent, now time.Time) []Period {
+	var res []Period
+	for _, evt := range events {
+		t := evt.Time.AsTime()
+		if len(res) > 0 {
+			if res[len(res)-1].Phase == evt.Phase {
+				continue
+			}
+			res[len(res)-1].End = t
+		}
+		res = append(res, Period{Phase: evt.Phase, Start: t})
+	}
+	if len(res) > 0 {
+		last := &res[len(res)-1]
+		if last.Phase == v1.EnginePhase_PHASE_DONE {
+			last.End = last.Start
+		} else {
+			last.End = now
+		}
+	}
+	return res
+}
+
+// Durations sums up the time spent in each phase
+func Durations(periods []Period) map[v1.EnginePhase]time.Duration {
+	res := make(map[v1.EnginePhase]time.Duration)
+	for _, p := range periods {
+		res[p.Phase] += p.Duration()
+	}
+	return res
+}
+
+// Changes describes how the conditions of an event differ from the previous one, e.g.
+// "failure_count=1". All set conditions count as changed for the first event.
+func Changes(prev, cur *v1.EngineHistoryEvent) []string {
+	var (
+		p   = prev.GetConditions()
+		c   = cur.GetConditions()
+		res []string
+	)
+	if p.GetSuccess() != c.GetSuccess() {
+		res = append(res, fmt.Sprintf("success=%v", c.GetSuccess()))
+	}
+	if p.GetFailureCount() != c.GetFailureCount() {
+		res = append(res, fmt.Sprintf("failure_count=%d", c.GetFailureCount()))
+	}
+	if p.GetCanReplay() != c.GetCanReplay() {
+		res = append(res, fmt.Sprintf("can_replay=%v", c.GetCanReplay()))
+	}
+	if p.GetDidExecute() != c.GetDidExecute() {
+		res = append(res, fmt.Sprintf("did_execute=%v", c.GetDidExecute()))
+	}
+	if (p.GetWaitUntil() == nil) != (c.GetWaitUntil() == nil) || !p.GetWaitUntil().AsTime().Equal(c.GetWaitUntil().AsTime()) {
+		if c.GetWaitUntil() == nil {
+			res = append(res, "wait_until=-")
+		} else {
+			res = append(res, "wait_until="+c.GetWaitUntil().AsTime().Format(time.RFC3339))
+		}
+	}
+	return res
+}
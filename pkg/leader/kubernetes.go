@@ -0,0 +1,253 @@
+package leader
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// KubernetesElector holds leadership through a coordination.k8s.io Lease
+type KubernetesElector struct {
+	ClientSet kubernetes.Interface
+	// Namespace and Name identify the Lease
+	Namespace string
+	Name      string
+	// Identity identifies this replica. Defaults to DefaultIdentity().
+	Identity string
+
+	// LeaseDuration is the time other replicas wait before taking over leadership from an unresponsive leader
+	LeaseDuration time.Duration
+	// RenewDeadline is the time the leader keeps trying to renew the lease before it gives up leadership
+	RenewDeadline time.Duration
+	// RetryPeriod is the time between two attempts to acquire or renew the lease
+	RetryPeriod time.Duration
+}
+
+// Run implements Elector
+func (e *KubernetesElector) Run(ctx context.Context, cb Callbacks) error {
+	// client-go releases the lease as soon as the context of an election is canceled, or a
+	// renewal failed, without waiting for OnStartedLeading to return. The elections hence get
+	// a context of their own, which is only canceled once the tasks have stopped, and the lock
+	// waits for the tasks before it releases the lease. Otherwise the tasks would overlap
+	// with those of the next leader.
+	tasks := newLeaderTasks()
+	cfg, err := e.config(cb, tasks)
+	if err != nil {
+		return err
+	}
+
+	electionCtx, cancelElection := context.WithCancel(context.Background())
+	defer cancelElection()
+	go func() {
+		select {
+		case <-ctx.Done():
+			tasks.Stop()
+			cancelElection()
+		case <-electionCtx.Done():
+		}
+	}()
+
+	for ctx.Err() == nil {
+		le, err := leaderelection.NewLeaderElector(*cfg)
+		if err != nil {
+			return err
+		}
+		// Run returns once leadership is lost - we want to keep contending, but only once
+		// the tasks of this term have returned so that they never run twice
+		le.Run(electionCtx)
+		tasks.Wait()
+	}
+	<-electionCtx.Done()
+	return nil
+}
+
+// leaderTasks tracks the OnStartedLeading callbacks which are running
+type leaderTasks struct {
+	mu      sync.Mutex
+	idle    *sync.Cond
+	cancels map[int]context.CancelFunc
+	next    int
+	stopped bool
+}
+
+func newLeaderTasks() *leaderTasks {
+	t := &leaderTasks{cancels: make(map[int]context.CancelFunc)}
+	t.idle = sync.NewCond(&t.mu)
+	return t
+}
+
+// Start registers a callback about to run, which cancel stops. It returns false once Stop was called,
+// otherwise done must be called when the callback returns.
+func (t *leaderTasks) Start(cancel context.CancelFunc) (done func(), ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopped {
+		return nil, false
+	}
+	id := t.next
+	t.next++
+	t.cancels[id] = cancel
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.cancels, id)
+		t.idle.Broadcast()
+	}, true
+}
+
+// Wait waits for the running callbacks to return
+func (t *leaderTasks) Wait() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for len(t.cancels) > 0 {
+		t.idle.Wait()
+	}
+}
+
+// Cancel cancels the running callbacks and waits for them to return
+func (t *leaderTasks) Cancel() {
+	t.mu.Lock()
+	for _, cancel := range t.cancels {
+		cancel()
+	}
+	t.mu.Unlock()
+	t.Wait()
+}
+
+// Stop prevents further callbacks from starting, and cancels the running ones and waits for them to return
+func (t *leaderTasks) Stop() {
+	t.mu.Lock()
+	t.stopped = true
+	t.mu.Unlock()
+	t.Cancel()
+}
+
+// releasingLock stops the tasks before client-go releases the lease. client-go releases the
+// lease by writing a record without holder.
+type releasingLock struct {
+	resourcelock.Interface
+	tasks *leaderTasks
+}
+
+// Update implements resourcelock.Interface
+func (l *releasingLock) Update(ctx context.Context, ler resourcelock.LeaderElectionRecord) error {
+	if ler.HolderIdentity == "" {
+		l.tasks.Cancel()
+	}
+	return l.Interface.Update(ctx, ler)
+}
+
+func (e *KubernetesElector) config(cb Callbacks, tasks *leaderTasks) (*leaderelection.LeaderElectionConfig, error) {
+	if e.ClientSet == nil || e.Namespace == "" || e.Name == "" {
+		return nil, fmt.Errorf("kubernetes leader election requires a client, namespace and lease name")
+	}
+	identity := e.Identity
+	if identity == "" {
+		identity = DefaultIdentity()
+	}
+
+	var (
+		leaseDuration = orDefault(e.LeaseDuration, DefaultLeaseDuration)
+		renewDeadline = orDefault(e.RenewDeadline, DefaultRenewDeadline)
+		retryPeriod   = orDefault(e.RetryPeriod, DefaultRetryPeriod)
+	)
+	if leaseDuration <= renewDeadline || float64(renewDeadline) <= leaderelection.JitterFactor*float64(retryPeriod) {
+		return nil, fmt.Errorf("lease duration must exceed the renew deadline, which must exceed %.1f times the retry period", leaderelection.JitterFactor)
+	}
+
+	// client-go calls OnStoppedLeading whenever Run returns, even if it never led. It also calls
+	// OnStartedLeading in a goroutine, which might only run once leadership was lost already,
+	// i.e. after the leader context was canceled.
+	var (
+		mu      sync.Mutex
+		leading bool
+	)
+	return &leaderelection.LeaderElectionConfig{
+		Lock: &releasingLock{
+			Interface: &resourcelock.LeaseLock{
+				LeaseMeta:  metav1.ObjectMeta{Namespace: e.Namespace, Name: e.Name},
+				Client:     e.ClientSet.CoordinationV1(),
+				LockConfig: resourcelock.ResourceLockConfig{Identity: identity},
+			},
+			tasks: tasks,
+		},
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            e.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				// the tasks stop when leadership is lost, or when the tasks are stopped
+				// because Run's context is canceled or the lease is about to be released
+				taskCtx, cancel := context.WithCancel(leaderCtx)
+				defer cancel()
+
+				mu.Lock()
+				if leaderCtx.Err() != nil {
+					mu.Unlock()
+					return
+				}
+				done, ok := tasks.Start(cancel)
+				if !ok {
+					mu.Unlock()
+					return
+				}
+				leading = true
+				mu.Unlock()
+				defer done()
+
+				cb.OnStartedLeading(taskCtx)
+			},
+			OnStoppedLeading: func() {
+				mu.Lock()
+				defer mu.Unlock()
+				if !leading {
+					return
+				}
+				leading = false
+				if cb.OnStoppedLeading != nil {
+					cb.OnStoppedLeading()
+				}
+			},
+			OnNewLeader: func(identity string) {
+				if cb.OnNewLeader != nil {
+					cb.OnNewLeader(identity)
+				}
+			},
+		},
+	}, nil
+}
+
+func orDefault(d, def time.Duration) time.Duration {
+	if d == 0 {
+		return def
+	}
+	return d
+}
@@ -0,0 +1,148 @@
+package leader
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package leader elects a single leader among the replicas of the Bhojpur Text server.
+// Only the leader runs the singleton tasks, i.e. the scheduler, retention and cron triggers,
+// while all replicas serve read calls. Leadership is held through a Kubernetes Lease,
+// or a PostgreSQL advisory lock where Kubernetes is not available.
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Default timings of an election
+const (
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRenewDeadline = 10 * time.Second
+	DefaultRetryPeriod   = 2 * time.Second
+)
+
+// Callbacks are called as leadership changes
+type Callbacks struct {
+	// OnStartedLeading is called when this replica becomes the leader. The context is canceled
+	// once leadership is lost. Required.
+	OnStartedLeading func(ctx context.Context)
+	// OnStoppedLeading is called when this replica loses leadership. Optional.
+	OnStoppedLeading func()
+	// OnNewLeader is called when a replica, possibly this one, becomes the leader. Optional.
+	OnNewLeader func(identity string)
+}
+
+// Elector takes part in a leader election
+type Elector interface {
+	// Run contends for leadership until the context is canceled. A replica which loses
+	// leadership keeps contending. Leadership is released when Run returns. Run only
+	// returns an error if the election cannot take place at all, e.g. due to invalid settings.
+	Run(ctx context.Context, cb Callbacks) error
+}
+
+// DefaultIdentity identifies this replica in an election. It is the host name, i.e. the pod name in Kubernetes.
+func DefaultIdentity() string {
+	id, err := os.Hostname()
+	if err != nil || id == "" {
+		return "unknown"
+	}
+	return id
+}
+
+// Status tracks the outcome of an election, e.g. to report it in health checks.
+// All methods are safe for concurrent use.
+type Status struct {
+	mu       sync.RWMutex
+	leading  bool
+	leader   string
+	identity string
+}
+
+// NewStatus creates a status for the replica with the given identity
+func NewStatus(identity string) *Status {
+	return &Status{identity: identity}
+}
+
+// IsLeader returns true if this replica is the leader
+func (s *Status) IsLeader() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.leading
+}
+
+// Leader returns the identity of the last known leader
+func (s *Status) Leader() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.leader
+}
+
+// Track wraps the callbacks so that they keep the status up to date
+func (s *Status) Track(cb Callbacks) Callbacks {
+	return Callbacks{
+		OnStartedLeading: func(ctx context.Context) {
+			s.mu.Lock()
+			s.leading, s.leader = true, s.identity
+			s.mu.Unlock()
+			cb.OnStartedLeading(ctx)
+		},
+		OnStoppedLeading: func() {
+			s.mu.Lock()
+			s.leading = false
+			s.mu.Unlock()
+			if cb.OnStoppedLeading != nil {
+				cb.OnStoppedLeading()
+			}
+		},
+		OnNewLeader: func(identity string) {
+			s.mu.Lock()
+			s.leader = identity
+			s.mu.Unlock()
+			if cb.OnNewLeader != nil {
+				cb.OnNewLeader(identity)
+			}
+		},
+	}
+}
+
+// Tasks runs all tasks while leading and returns once all of them have returned, which they must
+// do soon after the context is canceled. Use it as OnStartedLeading, e.g.
+//
+//	elector.Run(ctx, leader.Callbacks{OnStartedLeading: leader.Tasks(scheduler.Run, collector.Start, cron.Run)})
+func Tasks(tasks ...func(ctx context.Context)) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		log.Info("became leader - starting singleton tasks")
+
+		var wg sync.WaitGroup
+		for _, t := range tasks {
+			wg.Add(1)
+			go func(t func(ctx context.Context)) {
+				defer wg.Done()
+				t(ctx)
+			}(t)
+		}
+		wg.Wait()
+
+		log.Info("singleton tasks stopped")
+	}
+}
@@ -0,0 +1,480 @@
+package leader
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestKubernetesElector(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	newElector := func(identity string) *KubernetesElector {
+		return &KubernetesElector{
+			ClientSet:     client,
+			Namespace:     "text",
+			Name:          "text-server",
+			Identity:      identity,
+			LeaseDuration: time.Second,
+			RenewDeadline: 500 * time.Millisecond,
+			RetryPeriod:   100 * time.Millisecond,
+		}
+	}
+
+	started := make(chan string, 2)
+	run := func(ctx context.Context, identity string) <-chan error {
+		errc := make(chan error, 1)
+		go func() {
+			errc <- newElector(identity).Run(ctx, Callbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					started <- identity
+					<-ctx.Done()
+				},
+			})
+		}()
+		return errc
+	}
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	defer cancelA()
+	errA := run(ctxA, "a")
+	select {
+	case id := <-started:
+		if id != "a" {
+			t.Fatalf("expected a to lead, got %s", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a did not become leader")
+	}
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	errB := run(ctxB, "b")
+	select {
+	case id := <-started:
+		t.Fatalf("%s started leading while a holds the lease", id)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	// a releases the lease on cancel, so b takes over
+	cancelA()
+	if err := <-errA; err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case id := <-started:
+		if id != "b" {
+			t.Fatalf("expected b to lead, got %s", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("b did not take over")
+	}
+	cancelB()
+	if err := <-errB; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKubernetesElectorStopsTasksBeforeRelease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	e := &KubernetesElector{
+		ClientSet:     client,
+		Namespace:     "text",
+		Name:          "text-server",
+		Identity:      "a",
+		LeaseDuration: time.Second,
+		RenewDeadline: 500 * time.Millisecond,
+		RetryPeriod:   100 * time.Millisecond,
+	}
+	holder := func() string {
+		lease, err := client.CoordinationV1().Leases("text").Get(context.Background(), "text-server", metav1.GetOptions{})
+		if err != nil || lease.Spec.HolderIdentity == nil {
+			return ""
+		}
+		return *lease.Spec.HolderIdentity
+	}
+
+	var (
+		ctx, cancel    = context.WithCancel(context.Background())
+		started        = make(chan struct{})
+		holderOnStop   string
+		stoppedLeading bool
+	)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- e.Run(ctx, Callbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				close(started)
+				<-ctx.Done()
+				// a task which takes a while to wind down
+				time.Sleep(50 * time.Millisecond)
+				holderOnStop = holder()
+			},
+			OnStoppedLeading: func() { stoppedLeading = true },
+		})
+	}()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not become leader")
+	}
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if holderOnStop != "a" {
+		t.Errorf("expected the lease to be held until the tasks stopped, holder was %q", holderOnStop)
+	}
+	if h := holder(); h != "" {
+		t.Errorf("expected the lease to be released once Run returned, holder is %q", h)
+	}
+	if !stoppedLeading {
+		t.Error("expected OnStoppedLeading to be called")
+	}
+}
+
+func TestKubernetesElectorStopsTasksOnFailedRenewal(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	var failRenewals int32
+	client.PrependReactor("update", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lease, ok := action.(k8stesting.UpdateAction).GetObject().(*coordinationv1.Lease)
+		if ok && atomic.LoadInt32(&failRenewals) == 1 && lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == "a" {
+			return true, nil, errors.New("api server unavailable")
+		}
+		return false, nil, nil
+	})
+	e := &KubernetesElector{
+		ClientSet:     client,
+		Namespace:     "text",
+		Name:          "text-server",
+		Identity:      "a",
+		LeaseDuration: time.Second,
+		RenewDeadline: 500 * time.Millisecond,
+		RetryPeriod:   100 * time.Millisecond,
+	}
+	holder := func() string {
+		lease, err := client.CoordinationV1().Leases("text").Get(context.Background(), "text-server", metav1.GetOptions{})
+		if err != nil || lease.Spec.HolderIdentity == nil {
+			return ""
+		}
+		return *lease.Spec.HolderIdentity
+	}
+
+	var (
+		ctx, cancel  = context.WithCancel(context.Background())
+		started      = make(chan struct{}, 2)
+		mu           sync.Mutex
+		running      int
+		maxRunning   int
+		holderOnStop []string
+	)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- e.Run(ctx, Callbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				started <- struct{}{}
+
+				<-ctx.Done()
+				// a task which takes a while to wind down
+				time.Sleep(200 * time.Millisecond)
+				h := holder()
+
+				mu.Lock()
+				running--
+				holderOnStop = append(holderOnStop, h)
+				mu.Unlock()
+			},
+		})
+	}()
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not become leader")
+	}
+
+	// renewals fail until the lease was released, after which the elector must contend again
+	atomic.StoreInt32(&failRenewals, 1)
+	deadline := time.Now().Add(5 * time.Second)
+	for holder() != "" {
+		if time.Now().After(deadline) {
+			t.Fatal("lease was not released after the renewals failed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	atomic.StoreInt32(&failRenewals, 0)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("did not become leader again")
+	}
+
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if maxRunning != 1 {
+		t.Errorf("expected the tasks of one term to run at a time, %d ran at once", maxRunning)
+	}
+	if len(holderOnStop) != 2 || holderOnStop[0] != "a" {
+		t.Errorf("expected the lease to be held until the tasks stopped after the failed renewal, holders were %q", holderOnStop)
+	}
+}
+
+func TestKubernetesElectorInvalid(t *testing.T) {
+	tests := []*KubernetesElector{
+		{Namespace: "text", Name: "text-server"},
+		{ClientSet: fake.NewSimpleClientset(), Name: "text-server"},
+		{ClientSet: fake.NewSimpleClientset(), Namespace: "text", Name: "text-server", LeaseDuration: time.Second, RenewDeadline: 2 * time.Second},
+	}
+	for i, e := range tests {
+		if err := e.Run(context.Background(), Callbacks{OnStartedLeading: func(context.Context) {}}); err == nil {
+			t.Errorf("%d: expected an error", i)
+		}
+	}
+}
+
+// fakeLocks is an advisory lock table shared by fake sessions
+type fakeLocks struct {
+	mu     sync.Mutex
+	holder map[int64]*fakeSession
+}
+
+type fakeSession struct {
+	locks  *fakeLocks
+	broken bool
+}
+
+func (s *fakeSession) TryLock(ctx context.Context, key int64) (bool, error) {
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+	if s.broken {
+		return false, errors.New("connection lost")
+	}
+	if h, ok := s.locks.holder[key]; ok && h != s {
+		return false, nil
+	}
+	s.locks.holder[key] = s
+	return true, nil
+}
+
+func (s *fakeSession) Ping(ctx context.Context) error {
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+	if s.broken {
+		return errors.New("connection lost")
+	}
+	return nil
+}
+
+func (s *fakeSession) Unlock(ctx context.Context, key int64) error {
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+	if s.locks.holder[key] == s {
+		delete(s.locks.holder, key)
+	}
+	return nil
+}
+
+func (s *fakeSession) Close() error {
+	s.locks.mu.Lock()
+	defer s.locks.mu.Unlock()
+	for k, h := range s.locks.holder {
+		if h == s {
+			delete(s.locks.holder, k)
+		}
+	}
+	return nil
+}
+
+// breakSession simulates the loss of the connection holding the lock
+func (l *fakeLocks) breakSession(key int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if h, ok := l.holder[key]; ok {
+		h.broken = true
+		delete(l.holder, key)
+	}
+}
+
+func TestPostgresElector(t *testing.T) {
+	locks := &fakeLocks{holder: make(map[int64]*fakeSession)}
+	newElector := func(identity string) *PostgresElector {
+		return &PostgresElector{
+			Identity:    identity,
+			RetryPeriod: 10 * time.Millisecond,
+			connect: func(ctx context.Context) (lockSession, error) {
+				return &fakeSession{locks: locks}, nil
+			},
+		}
+	}
+
+	type event struct {
+		Identity string
+		Leading  bool
+	}
+	events := make(chan event, 10)
+	run := func(ctx context.Context, identity string) <-chan error {
+		errc := make(chan error, 1)
+		go func() {
+			errc <- newElector(identity).Run(ctx, Callbacks{
+				OnStartedLeading: func(ctx context.Context) {
+					events <- event{identity, true}
+					<-ctx.Done()
+				},
+				OnStoppedLeading: func() { events <- event{identity, false} },
+			})
+		}()
+		return errc
+	}
+	expect := func(ev event) {
+		t.Helper()
+		select {
+		case act := <-events:
+			if act != ev {
+				t.Fatalf("expected %v, got %v", ev, act)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %v", ev)
+		}
+	}
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	defer cancelA()
+	errA := run(ctxA, "a")
+	expect(event{"a", true})
+
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	errB := run(ctxB, "b")
+
+	// a loses its connection, b takes over and a keeps contending. a only notices the loss
+	// on its next ping, so b might start leading first.
+	locks.breakSession(DefaultLockKey)
+	expectAll := map[event]bool{{"a", false}: true, {"b", true}: true}
+	for len(expectAll) > 0 {
+		select {
+		case act := <-events:
+			if !expectAll[act] {
+				t.Fatalf("unexpected %v", act)
+			}
+			delete(expectAll, act)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %v", expectAll)
+		}
+	}
+
+	cancelB()
+	expect(event{"b", false})
+	if err := <-errB; err != nil {
+		t.Fatal(err)
+	}
+	expect(event{"a", true})
+
+	cancelA()
+	expect(event{"a", false})
+	if err := <-errA; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPostgresElectorStopsTasksBeforeUnlock(t *testing.T) {
+	locks := &fakeLocks{holder: make(map[int64]*fakeSession)}
+	e := &PostgresElector{
+		RetryPeriod: 10 * time.Millisecond,
+		connect: func(ctx context.Context) (lockSession, error) {
+			return &fakeSession{locks: locks}, nil
+		},
+	}
+	held := func() bool {
+		locks.mu.Lock()
+		defer locks.mu.Unlock()
+		_, ok := locks.holder[DefaultLockKey]
+		return ok
+	}
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		started     = make(chan struct{})
+		heldOnStop  bool
+	)
+	defer cancel()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- e.Run(ctx, Callbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				close(started)
+				<-ctx.Done()
+				// a task which takes a while to wind down
+				time.Sleep(50 * time.Millisecond)
+				heldOnStop = held()
+			},
+		})
+	}()
+	<-started
+	cancel()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	if !heldOnStop {
+		t.Error("expected the lock to be held until the tasks stopped")
+	}
+	if held() {
+		t.Error("expected the lock to be released once Run returned")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	status := NewStatus("a")
+	cb := status.Track(Callbacks{OnStartedLeading: func(context.Context) {}})
+
+	cb.OnNewLeader("b")
+	if status.IsLeader() || status.Leader() != "b" {
+		t.Errorf("expected b to lead, got leader %q, leading %v", status.Leader(), status.IsLeader())
+	}
+	cb.OnStartedLeading(context.Background())
+	if !status.IsLeader() || status.Leader() != "a" {
+		t.Errorf("expected a to lead, got leader %q, leading %v", status.Leader(), status.IsLeader())
+	}
+	cb.OnStoppedLeading()
+	if status.IsLeader() {
+		t.Error("expected a to have stopped leading")
+	}
+}
@@ -0,0 +1,181 @@
+package leader
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultLockKey is the advisory lock key used unless configured otherwise. It spells "text".
+const DefaultLockKey int64 = 0x74657874
+
+// PostgresElector holds leadership through a PostgreSQL session-level advisory lock. The lock
+// is tied to a database connection: if the leader dies or loses its connection, the database
+// releases the lock and another replica takes over. The former leader only notices on its next
+// check, so its tasks might overlap with the new leader's for up to RetryPeriod.
+type PostgresElector struct {
+	DB *sql.DB
+	// Key is the advisory lock key. Defaults to DefaultLockKey.
+	Key int64
+	// Identity identifies this replica. Defaults to DefaultIdentity().
+	Identity string
+	// RetryPeriod is the time between two attempts to acquire the lock, and between two checks
+	// that the connection holding the lock is still alive. Defaults to DefaultRetryPeriod.
+	RetryPeriod time.Duration
+
+	// connect is replaced in tests
+	connect func(ctx context.Context) (lockSession, error)
+}
+
+// lockSession is a database session which can hold the advisory lock
+type lockSession interface {
+	TryLock(ctx context.Context, key int64) (bool, error)
+	Ping(ctx context.Context) error
+	Unlock(ctx context.Context, key int64) error
+	Close() error
+}
+
+// Run implements Elector. OnNewLeader is only called when this replica becomes the leader,
+// as advisory locks do not reveal their holder.
+func (e *PostgresElector) Run(ctx context.Context, cb Callbacks) error {
+	var (
+		key         = e.Key
+		identity    = e.Identity
+		retryPeriod = orDefault(e.RetryPeriod, DefaultRetryPeriod)
+		connect     = e.connect
+	)
+	if key == 0 {
+		key = DefaultLockKey
+	}
+	if identity == "" {
+		identity = DefaultIdentity()
+	}
+	if connect == nil {
+		connect = e.connectSQL
+	}
+
+	for {
+		sess, err := connect(ctx)
+		if err == nil {
+			err = e.contend(ctx, sess, key, identity, retryPeriod, cb)
+			sess.Close()
+		}
+		if err != nil && ctx.Err() == nil {
+			log.WithError(err).Warn("leader election: lost database session")
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retryPeriod):
+		}
+	}
+}
+
+// contend tries to acquire the lock on the session and holds it until the session breaks or the context is canceled
+func (e *PostgresElector) contend(ctx context.Context, sess lockSession, key int64, identity string, retryPeriod time.Duration, cb Callbacks) error {
+	ticker := time.NewTicker(retryPeriod)
+	defer ticker.Stop()
+
+	for {
+		locked, err := sess.TryLock(ctx, key)
+		if err != nil {
+			return err
+		}
+		if locked {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+
+	log.WithField("identity", identity).Info("leader election: acquired advisory lock")
+	if cb.OnNewLeader != nil {
+		cb.OnNewLeader(identity)
+	}
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cb.OnStartedLeading(leaderCtx)
+	}()
+	stop := func() {
+		cancel()
+		<-done
+		if cb.OnStoppedLeading != nil {
+			cb.OnStoppedLeading()
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// the tasks must have stopped before the lock is released, otherwise they would
+			// overlap with those of the next leader
+			stop()
+
+			// release the lock right away so that another replica can take over without waiting for the session to time out
+			unlockCtx, cancelUnlock := context.WithTimeout(context.Background(), retryPeriod)
+			defer cancelUnlock()
+			return sess.Unlock(unlockCtx, key)
+		case <-ticker.C:
+		}
+
+		if err := sess.Ping(ctx); err != nil && ctx.Err() == nil {
+			stop()
+			return err
+		}
+	}
+}
+
+func (e *PostgresElector) connectSQL(ctx context.Context) (lockSession, error) {
+	conn, err := e.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sqlSession{conn}, nil
+}
+
+type sqlSession struct {
+	*sql.Conn
+}
+
+func (s sqlSession) TryLock(ctx context.Context, key int64) (locked bool, err error) {
+	err = s.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked)
+	return
+}
+
+func (s sqlSession) Ping(ctx context.Context) error {
+	return s.PingContext(ctx)
+}
+
+func (s sqlSession) Unlock(ctx context.Context, key int64) error {
+	_, err := s.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key)
+	return err
+}
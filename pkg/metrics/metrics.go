@@ -0,0 +1,258 @@
+package metrics
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package metrics provides the Prometheus metrics of the Bhojpur Text server.
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "text"
+
+// Metrics holds all metrics of the server. All methods are safe for concurrent use.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	rpcStarted  *prometheus.CounterVec
+	rpcHandled  *prometheus.CounterVec
+	rpcDuration *prometheus.HistogramVec
+
+	engines        *prometheus.GaugeVec
+	engineDuration *prometheus.HistogramVec
+	queueDepth     prometheus.Gauge
+	queueRunning   prometheus.Gauge
+	subscribers    *prometheus.GaugeVec
+	logBytes       *prometheus.CounterVec
+
+	mu    sync.Mutex
+	known map[string]engineLabels
+}
+
+type engineLabels struct {
+	Phase    string
+	Spec     string
+	Trigger  string
+	Finished bool
+}
+
+// New creates the metrics and registers them with a new registry, together with the Go and process collectors
+func New() (*Metrics, error) {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		rpcStarted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_started_total",
+			Help:      "Total number of RPCs started on the server.",
+		}, []string{"service", "method"}),
+		rpcHandled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_handled_total",
+			Help:      "Total number of RPCs completed on the server, regardless of success or failure.",
+		}, []string{"service", "method", "code"}),
+		rpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_server_handling_seconds",
+			Help:      "Time it took the server to handle RPCs. Streams count until they are closed.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"service", "method"}),
+		engines: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "engines",
+			Help:      "Number of engines known to the server by phase.",
+		}, []string{"phase", "spec", "trigger"}),
+		engineDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "engine_duration_seconds",
+			Help:      "Time from the creation of an engine until it finished.",
+			Buckets:   prometheus.ExponentialBuckets(1, 2, 16),
+		}, []string{"spec", "trigger", "success"}),
+		queueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "queue_depth",
+			Help:      "Number of engines waiting in the queue for a concurrency slot.",
+		}),
+		queueRunning: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "queue_running",
+			Help:      "Number of engines holding a concurrency slot.",
+		}),
+		subscribers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "subscribers",
+			Help:      "Number of open Subscribe and Listen streams.",
+		}, []string{"method"}),
+		logBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "log_bytes_streamed_total",
+			Help:      "Total number of log bytes streamed to clients.",
+		}, []string{"spec", "trigger"}),
+		known: make(map[string]engineLabels),
+	}
+
+	collectors := []prometheus.Collector{
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.rpcStarted, m.rpcHandled, m.rpcDuration,
+		m.engines, m.engineDuration, m.queueDepth, m.queueRunning, m.subscribers, m.logBytes,
+	}
+	for _, c := range collectors {
+		if err := m.registry.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Handler serves the metrics, e.g. on /metrics
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Registry returns the registry the metrics are registered with, e.g. to add further collectors
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// UnaryServerInterceptor records the metrics of unary RPCs
+func (m *Metrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		done := m.startRPC(info.FullMethod)
+		resp, err := handler(ctx, req)
+		done(err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records the metrics of streaming RPCs
+func (m *Metrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		done := m.startRPC(info.FullMethod)
+		err := handler(srv, ss)
+		done(err)
+		return err
+	}
+}
+
+func (m *Metrics) startRPC(fullMethod string) (done func(error)) {
+	service, method := splitMethod(fullMethod)
+	m.rpcStarted.WithLabelValues(service, method).Inc()
+	start := time.Now()
+	return func(err error) {
+		m.rpcHandled.WithLabelValues(service, method, status.Code(err).String()).Inc()
+		m.rpcDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// splitMethod splits /v1.TextService/ListEngines into v1.TextService and ListEngines
+func splitMethod(fullMethod string) (service, method string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if idx := strings.LastIndex(fullMethod, "/"); idx >= 0 {
+		return fullMethod[:idx], fullMethod[idx+1:]
+	}
+	return "unknown", fullMethod
+}
+
+// ObserveEngine records a change of an engine's status. It must be called with every status
+// change, so that the engine counts stay accurate. The duration of an engine is recorded once
+// it has finished.
+func (m *Metrics) ObserveEngine(s *v1.EngineStatus) {
+	lbls := engineLabels{
+		Phase:   formatPhase(s.Phase),
+		Spec:    s.Metadata.GetEngineSpecName(),
+		Trigger: formatTrigger(s.Metadata.GetTrigger()),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev, known := m.known[s.Name]
+	if known {
+		m.engines.WithLabelValues(prev.Phase, prev.Spec, prev.Trigger).Dec()
+		lbls.Finished = prev.Finished
+	}
+	m.engines.WithLabelValues(lbls.Phase, lbls.Spec, lbls.Trigger).Inc()
+
+	created, finished := s.Metadata.GetCreated(), s.Metadata.GetFinished()
+	if !lbls.Finished && created != nil && finished != nil {
+		lbls.Finished = true
+		success := "false"
+		if s.Conditions.GetSuccess() {
+			success = "true"
+		}
+		d := finished.AsTime().Sub(created.AsTime())
+		m.engineDuration.WithLabelValues(lbls.Spec, lbls.Trigger, success).Observe(d.Seconds())
+	}
+	m.known[s.Name] = lbls
+}
+
+// ForgetEngine removes an engine from the engine counts, e.g. once it was deleted
+func (m *Metrics) ForgetEngine(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev, known := m.known[name]
+	if !known {
+		return
+	}
+	m.engines.WithLabelValues(prev.Phase, prev.Spec, prev.Trigger).Dec()
+	delete(m.known, name)
+}
+
+// SetQueue records the state of the engine queue
+func (m *Metrics) SetQueue(depth, running int) {
+	m.queueDepth.Set(float64(depth))
+	m.queueRunning.Set(float64(running))
+}
+
+// TrackSubscriber counts an open stream of the given method, e.g. Subscribe or Listen,
+// until the returned function is called.
+func (m *Metrics) TrackSubscriber(method string) (done func()) {
+	g := m.subscribers.WithLabelValues(method)
+	g.Inc()
+	var once sync.Once
+	return func() {
+		once.Do(g.Dec)
+	}
+}
+
+// AddLogBytes counts log bytes streamed to a client
+func (m *Metrics) AddLogBytes(md *v1.EngineMetadata, n int) {
+	m.logBytes.WithLabelValues(md.GetEngineSpecName(), formatTrigger(md.GetTrigger())).Add(float64(n))
+}
+
+func formatPhase(p v1.EnginePhase) string {
+	return strings.ToLower(strings.TrimPrefix(p.String(), "PHASE_"))
+}
+
+func formatTrigger(t v1.EngineTrigger) string {
+	return strings.ToLower(strings.TrimPrefix(t.String(), "TRIGGER_"))
+}
@@ -0,0 +1,129 @@
+package metrics
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRPCMetrics(t *testing.T) {
+	m, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	intercept := m.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/v1.TextService/GetEngine"}
+	_, _ = intercept(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	_, _ = intercept(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "not found")
+	})
+
+	if act := testutil.ToFloat64(m.rpcStarted.WithLabelValues("v1.TextService", "GetEngine")); act != 2 {
+		t.Errorf("expected two started RPCs, got %v", act)
+	}
+	if act := testutil.ToFloat64(m.rpcHandled.WithLabelValues("v1.TextService", "GetEngine", "NotFound")); act != 1 {
+		t.Errorf("expected one NotFound RPC, got %v", act)
+	}
+}
+
+func TestEngineMetrics(t *testing.T) {
+	m, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	e := &v1.EngineStatus{
+		Name:  "index-1",
+		Phase: v1.EnginePhase_PHASE_RUNNING,
+		Metadata: &v1.EngineMetadata{
+			EngineSpecName: "index",
+			Trigger:        v1.EngineTrigger_TRIGGER_SCHEDULED,
+			Created:        timestamppb.New(created),
+		},
+	}
+	m.ObserveEngine(e)
+	m.ObserveEngine(e)
+	if act := testutil.ToFloat64(m.engines.WithLabelValues("running", "index", "scheduled")); act != 1 {
+		t.Errorf("expected one running engine, got %v", act)
+	}
+
+	e.Phase = v1.EnginePhase_PHASE_DONE
+	e.Metadata.Finished = timestamppb.New(created.Add(time.Minute))
+	e.Conditions = &v1.EngineConditions{Success: true}
+	m.ObserveEngine(e)
+	m.ObserveEngine(e)
+	if act := testutil.ToFloat64(m.engines.WithLabelValues("running", "index", "scheduled")); act != 0 {
+		t.Errorf("expected no running engine, got %v", act)
+	}
+	if act := testutil.ToFloat64(m.engines.WithLabelValues("done", "index", "scheduled")); act != 1 {
+		t.Errorf("expected one done engine, got %v", act)
+	}
+	if act := testutil.CollectAndCount(m.engineDuration); act != 1 {
+		t.Errorf("expected one duration series, got %v", act)
+	}
+
+	m.ForgetEngine("index-1")
+	if act := testutil.ToFloat64(m.engines.WithLabelValues("done", "index", "scheduled")); act != 0 {
+		t.Errorf("expected no engine after forgetting it, got %v", act)
+	}
+
+	done := m.TrackSubscriber("Subscribe")
+	if act := testutil.ToFloat64(m.subscribers.WithLabelValues("Subscribe")); act != 1 {
+		t.Errorf("expected one subscriber, got %v", act)
+	}
+	done()
+	done()
+	if act := testutil.ToFloat64(m.subscribers.WithLabelValues("Subscribe")); act != 0 {
+		t.Errorf("expected no subscriber, got %v", act)
+	}
+
+	m.AddLogBytes(e.Metadata, 42)
+	m.SetQueue(3, 2)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, exp := range []string{
+		`text_log_bytes_streamed_total{spec="index",trigger="scheduled"} 42`,
+		`text_queue_depth 3`,
+		`text_engine_duration_seconds_sum{spec="index",success="true",trigger="scheduled"} 60`,
+	} {
+		if !strings.Contains(string(body), exp) {
+			t.Errorf("expected metrics to contain %s", exp)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package names
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSeparator joins the adjective and the surname of a generated name
+	DefaultSeparator = "_"
+
+	// dns1123LabelMaxLength is the maximum length of a DNS-1123 label
+	dns1123LabelMaxLength = 63
+
+	// maxAttempts limits the number of draws Name makes before it gives up
+	maxAttempts = 100
+)
+
+// ErrExhausted is returned by an exhaustive Generator once every name has been handed out
+var ErrExhausted = errors.New("all names have been generated")
+
+// Generator produces names formatted as "adjective<separator>surname". Unlike GetRandomName
+// a Generator draws from its own random source, which makes the produced sequence reproducible
+// when the source is seeded deterministically. A Generator is safe for concurrent use.
+type Generator struct {
+	mu sync.Mutex
+
+	rnd        *rand.Rand
+	left       []string
+	right      []string
+	separator  string
+	sepSet     bool
+	dns1123    bool
+	exhaustive bool
+
+	// drawn and perm implement a lazy Fisher-Yates shuffle over all left/right combinations
+	// so that exhaustive mode never needs more than len(left)*len(right) draws.
+	drawn int
+	perm  map[int]int
+	used  map[string]struct{}
+}
+
+// GeneratorOption configures a Generator
+type GeneratorOption func(*Generator)
+
+// WithSource makes the generator draw from src instead of a time-seeded source
+func WithSource(src rand.Source) GeneratorOption {
+	return func(g *Generator) {
+		g.rnd = rand.New(src) //nolint:gosec // G404: names are not security sensitive
+	}
+}
+
+// WithWords replaces the built-in adjectives (left) and surnames (right). Duplicates are ignored.
+func WithWords(left, right []string) GeneratorOption {
+	return func(g *Generator) {
+		g.left = dedup(left)
+		g.right = dedup(right)
+	}
+}
+
+// WithSeparator changes the string placed between the adjective and the surname
+func WithSeparator(sep string) GeneratorOption {
+	return func(g *Generator) {
+		g.separator = sep
+		g.sepSet = true
+	}
+}
+
+// WithDNS1123 makes the generator produce names which are valid DNS-1123 labels,
+// i.e. at most 63 lower case alphanumeric characters or '-', starting and ending
+// with an alphanumeric character. Unless a separator is configured explicitly, '-'
+// is used to join the words.
+func WithDNS1123() GeneratorOption {
+	return func(g *Generator) {
+		g.dns1123 = true
+	}
+}
+
+// WithExhaustive guarantees that the generator never produces the same name twice.
+// Once all combinations have been used the generator returns ErrExhausted.
+func WithExhaustive() GeneratorOption {
+	return func(g *Generator) {
+		g.exhaustive = true
+	}
+}
+
+// NewGenerator creates a new name generator
+func NewGenerator(opts ...GeneratorOption) (*Generator, error) {
+	g := &Generator{
+		left:  left[:],
+		right: right[:],
+	}
+	for _, o := range opts {
+		o(g)
+	}
+	if !g.sepSet {
+		g.separator = DefaultSeparator
+		if g.dns1123 {
+			g.separator = "-"
+		}
+	}
+	if g.rnd == nil {
+		g.rnd = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec // G404: names are not security sensitive
+	}
+	if len(g.left) == 0 || len(g.right) == 0 {
+		return nil, errors.New("word lists must not be empty")
+	}
+	if len(g.left) == 1 && len(g.right) == 1 && isBoring(g.left[0], g.right[0]) {
+		return nil, errors.New("word lists must permit a name other than boring_wozniak")
+	}
+	if g.dns1123 {
+		for _, w := range append(append([]string(nil), g.left...), g.right...) {
+			if sanitizeDNS1123(w) == "" {
+				return nil, fmt.Errorf("word %q contains no DNS-1123 label characters", w)
+			}
+		}
+	}
+	if g.exhaustive {
+		g.perm = make(map[int]int)
+		g.used = make(map[string]struct{})
+	}
+	return g, nil
+}
+
+// Name produces a new name. If retry is non-zero a random number is appended to the name,
+// with the number of digits growing with retry to make collisions increasingly unlikely,
+// e.g. `focused_turing3` for retry 1 or `focused_turing27` for retry 2.
+// In exhaustive mode names are unique by construction, hence retry is ignored.
+func (g *Generator) Name(retry int) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.exhaustive {
+		return g.nextUnique()
+	}
+
+	for i := 0; i < maxAttempts; i++ {
+		l, r := g.left[g.rnd.Intn(len(g.left))], g.right[g.rnd.Intn(len(g.right))]
+		if isBoring(l, r) {
+			continue
+		}
+
+		var suffix string
+		if retry > 0 {
+			suffix = strconv.Itoa(g.rnd.Intn(retryRange(retry)))
+		}
+		return g.format(l, r, suffix), nil
+	}
+	return "", fmt.Errorf("no name found after %d attempts", maxAttempts)
+}
+
+func (g *Generator) nextUnique() (string, error) {
+	total := len(g.left) * len(g.right)
+	for g.drawn < total {
+		// lazy Fisher-Yates: pick a random position in the undrawn remainder and
+		// swap it with the first undrawn position.
+		j := g.drawn + g.rnd.Intn(total-g.drawn)
+		idx := g.permAt(j)
+		g.perm[j] = g.permAt(g.drawn)
+		g.drawn++
+
+		l, r := g.left[idx/len(g.right)], g.right[idx%len(g.right)]
+		if isBoring(l, r) {
+			continue
+		}
+		name := g.format(l, r, "")
+		if _, exists := g.used[name]; exists {
+			// DNS-1123 sanitation can map distinct words onto the same name
+			continue
+		}
+		g.used[name] = struct{}{}
+		return name, nil
+	}
+	return "", ErrExhausted
+}
+
+func (g *Generator) permAt(i int) int {
+	if v, ok := g.perm[i]; ok {
+		return v
+	}
+	return i
+}
+
+func (g *Generator) format(l, r, suffix string) string {
+	if !g.dns1123 {
+		return l + g.separator + r + suffix
+	}
+
+	// the suffix must survive truncation, otherwise retries would not help
+	name := sanitizeDNS1123(l + g.separator + r)
+	if max := dns1123LabelMaxLength - len(suffix); len(name) > max {
+		name = strings.TrimRight(name[:max], "-")
+	}
+	return name + suffix
+}
+
+func sanitizeDNS1123(s string) string {
+	var b strings.Builder
+	for _, c := range strings.ToLower(s) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	return strings.Trim(b.String(), "-")
+}
+
+func retryRange(retry int) int {
+	const maxDigits = 6
+	if retry > maxDigits {
+		retry = maxDigits
+	}
+	n := 1
+	for i := 0; i < retry; i++ {
+		n *= 10
+	}
+	return n
+}
+
+func isBoring(l, r string) bool {
+	return l == "boring" && r == "wozniak" /* Steve Wozniak is not boring */
+}
+
+func dedup(words []string) []string {
+	var (
+		res  = make([]string, 0, len(words))
+		seen = make(map[string]struct{}, len(words))
+	)
+	for _, w := range words {
+		if _, exists := seen[w]; exists {
+			continue
+		}
+		seen[w] = struct{}{}
+		res = append(res, w)
+	}
+	return res
+}
@@ -0,0 +1,195 @@
+package names
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGeneratorDeterministic(t *testing.T) {
+	gen := func() *Generator {
+		g, err := NewGenerator(WithSource(rand.NewSource(42)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return g
+	}
+	a, b := gen(), gen()
+	for i := 0; i < 100; i++ {
+		na, err := a.Name(i % 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nb, err := b.Name(i % 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if na != nb {
+			t.Fatalf("generators with the same seed diverged at %d: %s != %s", i, na, nb)
+		}
+	}
+}
+
+func TestGeneratorSeparatorAndWords(t *testing.T) {
+	g, err := NewGenerator(
+		WithSource(rand.NewSource(1)),
+		WithWords([]string{"quick"}, []string{"fox"}),
+		WithSeparator("."),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := g.Name(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "quick.fox" {
+		t.Fatalf("expected quick.fox, got %s", name)
+	}
+
+	name, err = g.Name(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(name, "quick.fox") || len(name) <= len("quick.fox") {
+		t.Fatalf("expected a numeric suffix on retry, got %s", name)
+	}
+}
+
+func TestGeneratorInvalidWords(t *testing.T) {
+	tests := []struct {
+		Name string
+		Opts []GeneratorOption
+	}{
+		{"empty", []GeneratorOption{WithWords(nil, []string{"fox"})}},
+		{"only boring", []GeneratorOption{WithWords([]string{"boring", "boring"}, []string{"wozniak"})}},
+		{"symbols only", []GeneratorOption{WithWords([]string{"quick", "!!!"}, []string{"fox"}), WithDNS1123()}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if _, err := NewGenerator(test.Opts...); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+
+	// symbols are fine as long as the names need not be DNS-1123 labels
+	if _, err := NewGenerator(WithWords([]string{"!!!"}, []string{"fox"})); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGeneratorDNS1123(t *testing.T) {
+	valid := regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	long := strings.Repeat("Very_Long", 10)
+
+	g, err := NewGenerator(
+		WithSource(rand.NewSource(7)),
+		WithWords([]string{"Admiring", long}, []string{"O'Brien", "turing"}),
+		WithDNS1123(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		name, err := g.Name(i % 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(name) > 63 || !valid.MatchString(name) {
+			t.Fatalf("%q is not a valid DNS-1123 label", name)
+		}
+	}
+
+	g, err = NewGenerator(WithDNS1123())
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := g.Name(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(name, "-") {
+		t.Fatalf("expected DNS-1123 names to be joined by '-', got %s", name)
+	}
+}
+
+func TestGeneratorExhaustive(t *testing.T) {
+	var (
+		left  = []string{"a", "b", "c", "boring"}
+		right = []string{"x", "y", "wozniak"}
+	)
+	g, err := NewGenerator(
+		WithSource(rand.NewSource(3)),
+		WithWords(left, right),
+		WithExhaustive(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[string]struct{})
+	for {
+		name, err := g.Name(0)
+		if err == ErrExhausted {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, exists := seen[name]; exists {
+			t.Fatalf("name %s was generated twice", name)
+		}
+		seen[name] = struct{}{}
+	}
+
+	// all combinations minus boring_wozniak
+	if expected := len(left)*len(right) - 1; len(seen) != expected {
+		t.Fatalf("expected %d names, got %d", expected, len(seen))
+	}
+	if _, exists := seen["boring_wozniak"]; exists {
+		t.Fatal("Steve Wozniak is not boring")
+	}
+}
+
+func TestGeneratorExhaustiveError(t *testing.T) {
+	g, err := NewGenerator(WithWords([]string{"quick"}, []string{"fox"}), WithExhaustive())
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := g.Name(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "quick_fox" {
+		t.Fatalf("expected quick_fox, got %s", name)
+	}
+
+	// exhaustion is permanent, retries do not produce further names
+	for retry := 0; retry < 3; retry++ {
+		if name, err := g.Name(retry); err != ErrExhausted {
+			t.Fatalf("expected ErrExhausted on retry %d, got %q, %v", retry, name, err)
+		}
+	}
+}
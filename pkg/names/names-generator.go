@@ -857,7 +857,8 @@ var (
 
 // GetRandomName generates a random name from the list of adjectives and surnames in this package
 // formatted as "adjective_surname". For example 'focused_turing'. If retry is non-zero, a random
-// integer between 0 and 10 will be added to the end of the name, e.g `focused_turing3`
+// integer between 0 and 10 will be added to the end of the name, e.g `focused_turing3`.
+// GetRandomName draws from the global math/rand source; use a Generator for reproducible or unique names.
 func GetRandomName(retry int) string {
 begin:
 	name := left[rand.Intn(len(left))] + "_" + right[rand.Intn(len(right))] //nolint:gosec // G404: Use of weak random number generator (math/rand instead of crypto/rand)
@@ -0,0 +1,118 @@
+package notify
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"sync"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/filterexpr"
+)
+
+// DefaultBuffer is the number of updates a subscription buffers
+const DefaultBuffer = 100
+
+// Hub publishes engine updates to subscriptions. All methods are safe for concurrent use.
+type Hub struct {
+	// Buffer is the number of updates a subscription buffers. Defaults to DefaultBuffer.
+	Buffer int
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+}
+
+// Subscription receives the engine updates which match its filter
+type Subscription struct {
+	// C delivers the updates. It is closed once the subscription ends.
+	C <-chan *v1.EngineStatus
+
+	c       chan *v1.EngineStatus
+	filter  []*v1.FilterExpression
+	hub     *Hub
+	dropped bool
+}
+
+// Subscribe starts a subscription to the engines matching the filter, with the semantics of ListEngines filters
+func (h *Hub) Subscribe(filter []*v1.FilterExpression) *Subscription {
+	buffer := h.Buffer
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	c := make(chan *v1.EngineStatus, buffer)
+	sub := &Subscription{C: c, c: c, filter: filter, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = make(map[*Subscription]struct{})
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+// Publish delivers the update to all matching subscriptions. Subscriptions which do not keep up,
+// i.e. whose buffer is full, are dropped rather than slowing down everyone else.
+func (h *Hub) Publish(status *v1.EngineStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subs {
+		if !filterexpr.Match(status, sub.filter) {
+			continue
+		}
+		select {
+		case sub.c <- status:
+		default:
+			sub.dropped = true
+			h.removeLocked(sub)
+		}
+	}
+}
+
+// Len returns the number of subscriptions
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+func (h *Hub) removeLocked(sub *Subscription) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.c)
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
+
+// Dropped returns true if the subscription ended because it did not keep up with the updates.
+// Callers should end the stream with an error so that the client re-subscribes.
+func (s *Subscription) Dropped() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.dropped
+}
@@ -0,0 +1,180 @@
+package notify
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package notify distributes engine updates across the replicas of the Bhojpur Text server.
+// The replica which changes an engine sends a PostgreSQL notification carrying the engine's
+// name, every replica listens for those notifications, loads the engine from the store and
+// publishes it to the Subscribe and Listen calls it serves.
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Channel is the PostgreSQL notification channel engine updates are sent on
+const Channel = "text_engine_updates"
+
+// Execer executes statements, e.g. *sql.DB or *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Notify tells all replicas that the engine has changed. Called within a transaction, the
+// notification is delivered once the transaction commits, i.e. once the change is visible.
+func Notify(ctx context.Context, db Execer, name string) error {
+	_, err := db.ExecContext(ctx, "SELECT pg_notify($1, $2)", Channel, name)
+	return err
+}
+
+// pingInterval is the time after which an idle listener checks its connection
+const pingInterval = 90 * time.Second
+
+// Listener receives engine update notifications and publishes the updated engines to the hub
+type Listener struct {
+	// DSN is the PostgreSQL connection string. The listener holds a connection of its own.
+	DSN string
+	// Load loads an engine from the store. It returns an error with status code NotFound if the
+	// engine was removed.
+	Load func(ctx context.Context, name string) (*v1.EngineStatus, error)
+	// Resync loads the engines which might have changed while the listener was disconnected,
+	// e.g. all unfinished engines. Optional.
+	Resync func(ctx context.Context) ([]*v1.EngineStatus, error)
+	Hub    *Hub
+	// OnUpdate is called with every engine before it's published, e.g. to advance pipelines. Optional.
+	OnUpdate func(ctx context.Context, status *v1.EngineStatus)
+	// OnDelete is called with the name of every removed engine, e.g. to forget its history. Optional.
+	OnDelete func(ctx context.Context, name string)
+
+	// known holds the engines published so far and whether they were done. A resync uses it to
+	// find the engines which changed or were removed while the listener was disconnected.
+	known map[string]bool
+}
+
+// Run listens for notifications until the context is canceled. The listener reconnects on its own.
+func (l *Listener) Run(ctx context.Context) error {
+	pl := pq.NewListener(l.DSN, time.Second, time.Minute, func(evt pq.ListenerEventType, err error) {
+		if err != nil {
+			log.WithError(err).WithField("event", evt).Warn("engine update listener")
+		}
+	})
+	defer pl.Close()
+
+	if err := pl.Listen(Channel); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-pl.Notify:
+			l.handle(ctx, n)
+		case <-time.After(pingInterval):
+			go func() {
+				if err := pl.Ping(); err != nil {
+					log.WithError(err).Debug("engine update listener ping failed")
+				}
+			}()
+		}
+	}
+}
+
+// handle publishes the engine a notification refers to. pq sends a nil notification after
+// it re-established the connection, in which case notifications might have been lost.
+func (l *Listener) handle(ctx context.Context, n *pq.Notification) {
+	if n == nil {
+		l.resync(ctx)
+		return
+	}
+	l.load(ctx, n.Extra)
+}
+
+// resync publishes the engines Resync returns. Known engines it doesn't return are loaded
+// again, unless they were done already: they might have finished or been removed meanwhile.
+func (l *Listener) resync(ctx context.Context) {
+	if l.Resync == nil {
+		return
+	}
+	engines, err := l.Resync(ctx)
+	if err != nil {
+		log.WithError(err).Warn("cannot resync engines after reconnect")
+		return
+	}
+	resynced := make(map[string]struct{}, len(engines))
+	for _, e := range engines {
+		resynced[e.Name] = struct{}{}
+		l.publish(ctx, e)
+	}
+	for name, done := range l.known {
+		if _, ok := resynced[name]; ok {
+			continue
+		}
+		if !done {
+			l.load(ctx, name)
+			continue
+		}
+		_, err := l.Load(ctx, name)
+		if status.Code(err) == codes.NotFound {
+			l.remove(ctx, name)
+		} else if err != nil {
+			log.WithError(err).WithField("name", name).Warn("cannot load engine during resync")
+		}
+	}
+}
+
+// load publishes the engine, or reports it as removed
+func (l *Listener) load(ctx context.Context, name string) {
+	engine, err := l.Load(ctx, name)
+	if status.Code(err) == codes.NotFound {
+		l.remove(ctx, name)
+		return
+	}
+	if err != nil {
+		log.WithError(err).WithField("name", name).Warn("cannot load updated engine")
+		return
+	}
+	l.publish(ctx, engine)
+}
+
+func (l *Listener) remove(ctx context.Context, name string) {
+	delete(l.known, name)
+	if l.OnDelete != nil {
+		l.OnDelete(ctx, name)
+	}
+}
+
+func (l *Listener) publish(ctx context.Context, status *v1.EngineStatus) {
+	if l.known == nil {
+		l.known = make(map[string]bool)
+	}
+	l.known[status.Name] = status.Phase == v1.EnginePhase_PHASE_DONE
+	if l.OnUpdate != nil {
+		l.OnUpdate(ctx, status)
+	}
+	l.Hub.Publish(status)
+}
@@ -0,0 +1,225 @@
+package notify
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/filterexpr"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHubFilter(t *testing.T) {
+	var hub Hub
+	filter, err := filterexpr.Parse([]string{"phase==running"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	running := hub.Subscribe(filter)
+	all := hub.Subscribe(nil)
+	defer running.Close()
+	defer all.Close()
+
+	hub.Publish(&v1.EngineStatus{Name: "a", Phase: v1.EnginePhase_PHASE_RUNNING})
+	hub.Publish(&v1.EngineStatus{Name: "b", Phase: v1.EnginePhase_PHASE_DONE})
+
+	if n := len(running.C); n != 1 {
+		t.Fatalf("expected 1 update for the filtered subscription, got %d", n)
+	}
+	if s := <-running.C; s.Name != "a" {
+		t.Errorf("expected update of a, got %s", s.Name)
+	}
+	if n := len(all.C); n != 2 {
+		t.Errorf("expected 2 updates for the unfiltered subscription, got %d", n)
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := Hub{Buffer: 2}
+	slow := hub.Subscribe(nil)
+	fast := hub.Subscribe(nil)
+	defer fast.Close()
+
+	for i := 0; i < 3; i++ {
+		hub.Publish(&v1.EngineStatus{Name: fmt.Sprintf("e%d", i)})
+		if i < 2 {
+			<-fast.C
+		}
+	}
+
+	var n int
+	for range slow.C {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected the slow subscription to receive 2 updates before it was closed, got %d", n)
+	}
+	if !slow.Dropped() {
+		t.Error("expected the slow subscription to be dropped")
+	}
+	if fast.Dropped() {
+		t.Error("expected the fast subscription to be kept")
+	}
+	if hub.Len() != 1 {
+		t.Errorf("expected 1 subscription, got %d", hub.Len())
+	}
+
+	// closing a dropped subscription must not panic
+	slow.Close()
+}
+
+func TestHubClose(t *testing.T) {
+	var hub Hub
+	sub := hub.Subscribe(nil)
+	sub.Close()
+	sub.Close()
+
+	hub.Publish(&v1.EngineStatus{Name: "a"})
+	if _, ok := <-sub.C; ok {
+		t.Error("expected a closed subscription to receive no updates")
+	}
+	if sub.Dropped() {
+		t.Error("expected a closed subscription not to be dropped")
+	}
+}
+
+func TestListenerHandle(t *testing.T) {
+	var (
+		hub     Hub
+		updates []string
+		deleted []string
+	)
+	sub := hub.Subscribe(nil)
+	defer sub.Close()
+
+	l := &Listener{
+		Hub:      &hub,
+		OnUpdate: func(ctx context.Context, s *v1.EngineStatus) { updates = append(updates, s.Name) },
+		OnDelete: func(ctx context.Context, name string) { deleted = append(deleted, name) },
+		Load: func(ctx context.Context, name string) (*v1.EngineStatus, error) {
+			switch name {
+			case "broken":
+				return nil, fmt.Errorf("cannot load")
+			case "removed":
+				return nil, status.Error(codes.NotFound, "not found")
+			}
+			return &v1.EngineStatus{Name: name, Phase: v1.EnginePhase_PHASE_DONE}, nil
+		},
+	}
+	ctx := context.Background()
+
+	l.handle(ctx, &pq.Notification{Channel: Channel, Extra: "a"})
+	l.handle(ctx, &pq.Notification{Channel: Channel, Extra: "broken"})
+	l.handle(ctx, &pq.Notification{Channel: Channel, Extra: "removed"})
+	// without Resync a reconnect publishes nothing
+	l.handle(ctx, nil)
+	if n := len(sub.C); n != 1 {
+		t.Fatalf("expected 1 update, got %d", n)
+	}
+	if s := <-sub.C; s.Name != "a" {
+		t.Errorf("expected update of a, got %s", s.Name)
+	}
+
+	l.Resync = func(ctx context.Context) ([]*v1.EngineStatus, error) {
+		return []*v1.EngineStatus{{Name: "b"}, {Name: "c"}}, nil
+	}
+	l.handle(ctx, nil)
+	if n := len(sub.C); n != 2 {
+		t.Errorf("expected 2 updates after resync, got %d", n)
+	}
+	if exp := []string{"a", "b", "c"}; !reflect.DeepEqual(updates, exp) {
+		t.Errorf("expected OnUpdate with %v, got %v", exp, updates)
+	}
+	if exp := []string{"removed"}; !reflect.DeepEqual(deleted, exp) {
+		t.Errorf("expected OnDelete with %v, got %v", exp, deleted)
+	}
+}
+
+func TestListenerResyncFindsRemovedEngines(t *testing.T) {
+	var (
+		hub     Hub
+		updates []string
+		deleted []string
+		store   = map[string]*v1.EngineStatus{
+			"done":     {Name: "done", Phase: v1.EnginePhase_PHASE_DONE},
+			"kept":     {Name: "kept", Phase: v1.EnginePhase_PHASE_DONE},
+			"running":  {Name: "running", Phase: v1.EnginePhase_PHASE_RUNNING},
+			"finished": {Name: "finished", Phase: v1.EnginePhase_PHASE_RUNNING},
+			"aborted":  {Name: "aborted", Phase: v1.EnginePhase_PHASE_RUNNING},
+		}
+	)
+	l := &Listener{
+		Hub:      &hub,
+		OnUpdate: func(ctx context.Context, s *v1.EngineStatus) { updates = append(updates, s.Name) },
+		OnDelete: func(ctx context.Context, name string) { deleted = append(deleted, name) },
+		Load: func(ctx context.Context, name string) (*v1.EngineStatus, error) {
+			s, ok := store[name]
+			if !ok {
+				return nil, status.Error(codes.NotFound, "not found")
+			}
+			return s, nil
+		},
+		Resync: func(ctx context.Context) ([]*v1.EngineStatus, error) {
+			var res []*v1.EngineStatus
+			for _, s := range store {
+				if s.Phase != v1.EnginePhase_PHASE_DONE {
+					res = append(res, s)
+				}
+			}
+			return res, nil
+		},
+	}
+	ctx := context.Background()
+	for _, name := range []string{"done", "kept", "running", "finished", "aborted"} {
+		l.handle(ctx, &pq.Notification{Channel: Channel, Extra: name})
+	}
+
+	// while the listener was disconnected
+	delete(store, "done")
+	delete(store, "aborted")
+	store["finished"] = &v1.EngineStatus{Name: "finished", Phase: v1.EnginePhase_PHASE_DONE}
+	store["new"] = &v1.EngineStatus{Name: "new", Phase: v1.EnginePhase_PHASE_RUNNING}
+
+	updates, deleted = nil, nil
+	l.handle(ctx, nil)
+	sort.Strings(updates)
+	sort.Strings(deleted)
+	if exp := []string{"finished", "new", "running"}; !reflect.DeepEqual(updates, exp) {
+		t.Errorf("expected OnUpdate with %v, got %v", exp, updates)
+	}
+	if exp := []string{"aborted", "done"}; !reflect.DeepEqual(deleted, exp) {
+		t.Errorf("expected OnDelete with %v, got %v", exp, deleted)
+	}
+
+	// removed engines are forgotten, and not reported twice
+	updates, deleted = nil, nil
+	l.handle(ctx, nil)
+	if len(deleted) != 0 {
+		t.Errorf("expected no OnDelete on the second resync, got %v", deleted)
+	}
+}
@@ -0,0 +1,318 @@
+package output
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package output renders API messages in the formats offered by all client commands,
+// i.e. as table, JSON, YAML or using a JSONPath expression or Go template.
+// It mirrors the -o flag of kubectl.
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+)
+
+// Names of the supported formats
+const (
+	FormatTable      = "table"
+	FormatWide       = "wide"
+	FormatName       = "name"
+	FormatJSON       = "json"
+	FormatYAML       = "yaml"
+	FormatJSONPath   = "jsonpath"
+	FormatGoTemplate = "go-template"
+)
+
+// Formats lists the supported values of the output flag
+var Formats = []string{FormatTable, FormatWide, FormatName, FormatJSON, FormatYAML, FormatJSONPath + "=<expr>", FormatGoTemplate + "=<template>"}
+
+// Format is an output format, optionally with an argument, e.g. jsonpath={.name}
+type Format struct {
+	Name string
+	Arg  string
+}
+
+// ParseFormat parses the value of an output flag. The empty string yields the zero Format,
+// which commands can use to fall back to their default output.
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return Format{}, nil
+	}
+
+	name, arg := s, ""
+	if idx := strings.Index(s, "="); idx >= 0 {
+		name, arg = s[:idx], s[idx+1:]
+	}
+	switch name {
+	case FormatTable, FormatWide, FormatName, FormatJSON, FormatYAML:
+		if arg != "" {
+			return Format{}, fmt.Errorf("output format %s does not take an argument", name)
+		}
+	case FormatJSONPath, FormatGoTemplate:
+		if arg == "" {
+			return Format{}, fmt.Errorf("output format %s requires an argument, e.g. %s=...", name, name)
+		}
+	default:
+		return Format{}, fmt.Errorf("unknown output format %q: expected one of %s", s, strings.Join(Formats, ", "))
+	}
+	return Format{Name: name, Arg: arg}, nil
+}
+
+// IsZero returns true if no format was given
+func (f Format) IsZero() bool {
+	return f.Name == ""
+}
+
+// String returns the format as it's given on the command line
+func (f Format) String() string {
+	if f.Arg == "" {
+		return f.Name
+	}
+	return f.Name + "=" + f.Arg
+}
+
+// Column is a column of the table output
+type Column struct {
+	Header string
+	// Wide columns are only printed in wide format
+	Wide  bool
+	Value func(proto.Message) string
+}
+
+// Printer prints messages in a particular format
+type Printer struct {
+	Format    Format
+	NoHeaders bool
+	// Columns are used for the table formats
+	Columns []Column
+	// Name returns the name of a message for the name format
+	Name func(proto.Message) string
+}
+
+// NewPrinter creates a new printer. The zero format prints a table.
+func NewPrinter(format Format, noHeaders bool, columns []Column, name func(proto.Message) string) (*Printer, error) {
+	if format.IsZero() {
+		format.Name = FormatTable
+	}
+	p := &Printer{
+		Format:    format,
+		NoHeaders: noHeaders,
+		Columns:   columns,
+		Name:      name,
+	}
+
+	// validate expressions and templates early, rather than after having talked to the server
+	switch format.Name {
+	case FormatJSONPath:
+		if _, err := p.jsonPath(); err != nil {
+			return nil, err
+		}
+	case FormatGoTemplate:
+		if _, err := p.template(); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// PrintObject prints a single message
+func (p *Printer) PrintObject(out io.Writer, msg proto.Message) error {
+	switch p.Format.Name {
+	case FormatTable, FormatWide, FormatName:
+		return p.PrintList(out, []proto.Message{msg})
+	}
+
+	doc, err := marshal(msg)
+	if err != nil {
+		return err
+	}
+	return p.printDocument(out, doc)
+}
+
+// PrintList prints a list of messages. In the structured formats the list is rendered
+// as object with an "items" field holding the messages, as kubectl does.
+func (p *Printer) PrintList(out io.Writer, msgs []proto.Message) error {
+	switch p.Format.Name {
+	case FormatTable, FormatWide:
+		return p.printTable(out, msgs)
+	case FormatName:
+		if p.Name == nil {
+			return fmt.Errorf("output format %s is not supported", FormatName)
+		}
+		for _, msg := range msgs {
+			if _, err := fmt.Fprintln(out, p.Name(msg)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	items := make([]json.RawMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		doc, err := marshal(msg)
+		if err != nil {
+			return err
+		}
+		items = append(items, doc)
+	}
+	doc, err := json.Marshal(struct {
+		Items []json.RawMessage `json:"items"`
+	}{items})
+	if err != nil {
+		return err
+	}
+	return p.printDocument(out, doc)
+}
+
+func (p *Printer) printTable(out io.Writer, msgs []proto.Message) error {
+	wide := p.Format.Name == FormatWide
+	cols := make([]Column, 0, len(p.Columns))
+	for _, c := range p.Columns {
+		if c.Wide && !wide {
+			continue
+		}
+		cols = append(cols, c)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	row := make([]string, len(cols))
+	if !p.NoHeaders {
+		for i, c := range cols {
+			row[i] = c.Header
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	for _, msg := range msgs {
+		for i, c := range cols {
+			row[i] = cellValue(c.Value(msg))
+		}
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// cellValue makes sure a value does not break the table layout
+func cellValue(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return strings.NewReplacer("\t", " ", "\n", " ").Replace(s)
+}
+
+func (p *Printer) printDocument(out io.Writer, doc []byte) error {
+	switch p.Format.Name {
+	case FormatJSON:
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, doc, "", "    "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := buf.WriteTo(out)
+		return err
+	case FormatYAML:
+		y, err := yaml.JSONToYAML(doc)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(y)
+		return err
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(doc, &data); err != nil {
+		return err
+	}
+	switch p.Format.Name {
+	case FormatJSONPath:
+		jp, err := p.jsonPath()
+		if err != nil {
+			return err
+		}
+		if err := jp.Execute(out, data); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out)
+		return err
+	case FormatGoTemplate:
+		tpl, err := p.template()
+		if err != nil {
+			return err
+		}
+		return tpl.Execute(out, data)
+	default:
+		return fmt.Errorf("unknown output format %q", p.Format.Name)
+	}
+}
+
+func (p *Printer) jsonPath() (*jsonpath.JSONPath, error) {
+	jp := jsonpath.New("output").AllowMissingKeys(true)
+	if err := jp.Parse(relaxedJSONPath(p.Format.Arg)); err != nil {
+		return nil, fmt.Errorf("invalid jsonpath expression %q: %w", p.Format.Arg, err)
+	}
+	return jp, nil
+}
+
+func (p *Printer) template() (*template.Template, error) {
+	tpl, err := template.New("output").Parse(p.Format.Arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %q: %w", p.Format.Arg, err)
+	}
+	return tpl, nil
+}
+
+// relaxedJSONPath accepts expressions without braces, e.g. .name, as kubectl does
+func relaxedJSONPath(expr string) string {
+	if strings.Contains(expr, "{") {
+		return expr
+	}
+	if !strings.HasPrefix(expr, ".") && !strings.HasPrefix(expr, "[") {
+		expr = "." + expr
+	}
+	return "{" + expr + "}"
+}
+
+var marshaler = protojson.MarshalOptions{
+	// scripts should be able to rely on the presence of fields
+	EmitUnpopulated: true,
+}
+
+// marshal renders a message as compact JSON. protojson deliberately randomizes its whitespace,
+// hence we compact the output to produce stable documents.
+func marshal(msg proto.Message) ([]byte, error) {
+	doc, err := marshaler.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,158 @@
+package output
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		Input       string
+		Expectation Format
+		Error       bool
+	}{
+		{Input: "", Expectation: Format{}},
+		{Input: "table", Expectation: Format{Name: FormatTable}},
+		{Input: "wide", Expectation: Format{Name: FormatWide}},
+		{Input: "json", Expectation: Format{Name: FormatJSON}},
+		{Input: "yaml", Expectation: Format{Name: FormatYAML}},
+		{Input: "jsonpath={.name}", Expectation: Format{Name: FormatJSONPath, Arg: "{.name}"}},
+		{Input: "go-template={{ .name }}={{ .phase }}", Expectation: Format{Name: FormatGoTemplate, Arg: "{{ .name }}={{ .phase }}"}},
+		{Input: "jsonpath", Error: true},
+		{Input: "json=foo", Error: true},
+		{Input: "xml", Error: true},
+	}
+	for _, test := range tests {
+		t.Run(test.Input, func(t *testing.T) {
+			act, err := ParseFormat(test.Input)
+			if test.Error {
+				if err == nil {
+					t.Fatalf("expected error, got %v", act)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if act != test.Expectation {
+				t.Errorf("ParseFormat() = %v, want %v", act, test.Expectation)
+			}
+		})
+	}
+}
+
+var testColumns = []Column{
+	{Header: "NAME", Value: func(m proto.Message) string { return m.(*v1.EngineStatus).Name }},
+	{Header: "DETAILS", Wide: true, Value: func(m proto.Message) string { return m.(*v1.EngineStatus).Details }},
+}
+
+func TestPrinter(t *testing.T) {
+	engines := []proto.Message{
+		&v1.EngineStatus{Name: "foo", Phase: v1.EnginePhase_PHASE_RUNNING, Details: "some\tdetails"},
+		&v1.EngineStatus{Name: "bar", Phase: v1.EnginePhase_PHASE_DONE},
+	}
+	name := func(m proto.Message) string { return "engine/" + m.(*v1.EngineStatus).Name }
+
+	tests := []struct {
+		Name        string
+		Format      string
+		NoHeaders   bool
+		Single      bool
+		Expectation string
+	}{
+		{Name: "default", Expectation: "NAME\nfoo\nbar\n"},
+		{Name: "wide", Format: "wide", Expectation: "NAME   DETAILS\nfoo    some details\nbar    <none>\n"},
+		{Name: "no headers", Format: "table", NoHeaders: true, Expectation: "foo\nbar\n"},
+		{Name: "name", Format: "name", Expectation: "engine/foo\nengine/bar\n"},
+		{Name: "jsonpath list", Format: "jsonpath={.items[*].name}", Expectation: "foo bar\n"},
+		{Name: "jsonpath relaxed", Format: "jsonpath=.name", Single: true, Expectation: "foo\n"},
+		{Name: "jsonpath missing key", Format: "jsonpath={.doesNotExist}", Single: true, Expectation: "\n"},
+		{Name: "go-template", Format: "go-template={{ .name }}:{{ .phase }}", Single: true, Expectation: "foo:PHASE_RUNNING"},
+		{Name: "go-template list", Format: "go-template={{ range .items }}{{ .name }} {{ end }}", Expectation: "foo bar "},
+		{Name: "yaml", Format: "yaml", Single: true, Expectation: "conditions: null\ndetails: \"some\\tdetails\"\nmetadata: null\nname: foo\nphase: PHASE_RUNNING\nresults: []\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			f, err := ParseFormat(test.Format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := NewPrinter(f, test.NoHeaders, testColumns, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if test.Single {
+				err = p.PrintObject(&buf, engines[0])
+			} else {
+				err = p.PrintList(&buf, engines)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if act := buf.String(); act != test.Expectation {
+				t.Errorf("unexpected output:\n%q\nwant:\n%q", act, test.Expectation)
+			}
+		})
+	}
+}
+
+func TestPrinterJSONIsStable(t *testing.T) {
+	p, err := NewPrinter(Format{Name: FormatJSON}, false, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &v1.EngineStatus{Name: "foo", Metadata: &v1.EngineMetadata{Owner: "alice"}}
+
+	var first string
+	for i := 0; i < 10; i++ {
+		var buf bytes.Buffer
+		if err := p.PrintObject(&buf, msg); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			first = buf.String()
+			continue
+		}
+		if buf.String() != first {
+			t.Fatalf("JSON output is not stable:\n%s\n%s", first, buf.String())
+		}
+	}
+	if !strings.HasPrefix(first, "{\n    \"name\": \"foo\",") {
+		t.Errorf("unexpected JSON output:\n%s", first)
+	}
+}
+
+func TestNewPrinterValidatesExpressions(t *testing.T) {
+	if _, err := NewPrinter(Format{Name: FormatJSONPath, Arg: "{.items[}"}, false, nil, nil); err == nil {
+		t.Error("expected error for invalid jsonpath expression")
+	}
+	if _, err := NewPrinter(Format{Name: FormatGoTemplate, Arg: "{{ .name "}, false, nil, nil); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
@@ -0,0 +1,277 @@
+package pagination
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package pagination implements the cursor based pagination of ListEngines. A page token
+// holds the sort keys of the last engine of a page, so that the next page starts right after
+// it (keyset pagination) and stays consistent while engines are being created or removed.
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/filterexpr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultOrder is the order of engines if a request does not specify one: newest first
+var DefaultOrder = []*v1.OrderExpression{{Field: "created", Ascending: false}}
+
+// tokenVersion changes whenever the token format changes incompatibly
+const tokenVersion = 1
+
+// timeFormat formats timestamps with a fixed width so that their keys sort chronologically
+const timeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// Key returns the sort key of an engine for an order field. Keys compare lexically.
+// Supported fields are name, created, finished, phase, owner, spec, trigger, success,
+// repo.host, repo.owner, repo.repo, repo.ref, repo.revision and annotation.<key>.
+// Phases and triggers sort by their short lower case name.
+func Key(s *v1.EngineStatus, field string) (string, error) {
+	md := s.Metadata
+	switch field {
+	case "name":
+		return s.Name, nil
+	case "created":
+		return formatTime(md.GetCreated().AsTime(), md.GetCreated() != nil), nil
+	case "finished":
+		return formatTime(md.GetFinished().AsTime(), md.GetFinished() != nil), nil
+	case "phase":
+		return strings.ToLower(strings.TrimPrefix(s.Phase.String(), "PHASE_")), nil
+	case "trigger":
+		return strings.ToLower(strings.TrimPrefix(md.GetTrigger().String(), "TRIGGER_")), nil
+	case "owner":
+		return md.GetOwner(), nil
+	case "spec":
+		return md.GetEngineSpecName(), nil
+	case "success":
+		return fmt.Sprint(s.Conditions.GetSuccess()), nil
+	case "repo.host":
+		return md.GetRepository().GetHost(), nil
+	case "repo.owner":
+		return md.GetRepository().GetOwner(), nil
+	case "repo.repo":
+		return md.GetRepository().GetRepo(), nil
+	case "repo.ref":
+		return md.GetRepository().GetRef(), nil
+	case "repo.revision":
+		return md.GetRepository().GetRevision(), nil
+	}
+	if key := strings.TrimPrefix(field, "annotation."); key != field && key != "" {
+		for _, a := range md.GetAnnotations() {
+			if a.Key == key {
+				return a.Value, nil
+			}
+		}
+		return "", nil
+	}
+	return "", fmt.Errorf("cannot order engines by %q", field)
+}
+
+// formatTime returns the key of a timestamp. Missing timestamps sort first.
+func formatTime(t time.Time, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return t.UTC().Format(timeFormat)
+}
+
+// ValidateOrder returns an error if engines cannot be ordered by one of the fields
+func ValidateOrder(order []*v1.OrderExpression) error {
+	for _, o := range order {
+		if _, err := Key(&v1.EngineStatus{}, o.Field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Order returns the order of a request, including the name as final tie-breaker, which makes the order total
+func Order(order []*v1.OrderExpression) []*v1.OrderExpression {
+	if len(order) == 0 {
+		order = DefaultOrder
+	}
+	for _, o := range order {
+		if o.Field == "name" {
+			return order
+		}
+	}
+	res := make([]*v1.OrderExpression, 0, len(order)+1)
+	res = append(res, order...)
+	return append(res, &v1.OrderExpression{Field: "name", Ascending: true})
+}
+
+// Keys returns the sort keys of an engine for the order returned by Order
+func Keys(s *v1.EngineStatus, order []*v1.OrderExpression) ([]string, error) {
+	res := make([]string, len(order))
+	for i, o := range order {
+		k, err := Key(s, o.Field)
+		if err != nil {
+			return nil, err
+		}
+		res[i] = k
+	}
+	return res, nil
+}
+
+// Compare compares two sets of sort keys in the given order. It returns a negative number
+// if a comes before b, a positive number if a comes after b and zero if they are equal.
+func Compare(a, b []string, order []*v1.OrderExpression) int {
+	for i, o := range order {
+		if i >= len(a) || i >= len(b) {
+			break
+		}
+		c := strings.Compare(a[i], b[i])
+		if c == 0 {
+			continue
+		}
+		if !o.Ascending {
+			c = -c
+		}
+		return c
+	}
+	return 0
+}
+
+// token is the content of a page token
+type token struct {
+	Version int `json:"v"`
+	// Query identifies the filter and order the token was issued for
+	Query string `json:"q"`
+	// Keys are the sort keys of the last engine of the previous page
+	Keys []string `json:"k"`
+}
+
+// query fingerprints the filter and order of a request
+func query(req *v1.ListEnginesRequest) string {
+	q, _ := proto.MarshalOptions{Deterministic: true}.Marshal(&v1.ListEnginesRequest{Filter: req.Filter, Order: Order(req.Order)})
+	sum := sha256.Sum256(q)
+	return hex.EncodeToString(sum[:8])
+}
+
+// EncodeToken returns the token of the page which follows last
+func EncodeToken(req *v1.ListEnginesRequest, last *v1.EngineStatus) (string, error) {
+	keys, err := Keys(last, Order(req.Order))
+	if err != nil {
+		return "", err
+	}
+	tkn, err := json.Marshal(token{Version: tokenVersion, Query: query(req), Keys: keys})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(tkn), nil
+}
+
+// DecodeToken returns the sort keys of the last engine of the previous page, or nil if the request has no page token.
+// It returns an InvalidArgument error if the token is malformed or was issued for a different filter or order.
+func DecodeToken(req *v1.ListEnginesRequest) ([]string, error) {
+	if req.PageToken == "" {
+		return nil, nil
+	}
+	if req.Start != 0 {
+		return nil, status.Error(codes.InvalidArgument, "start and page_token are mutually exclusive")
+	}
+
+	invalid := status.Error(codes.InvalidArgument, "invalid page token")
+	raw, err := base64.RawURLEncoding.DecodeString(req.PageToken)
+	if err != nil {
+		return nil, invalid
+	}
+	var tkn token
+	if err := json.Unmarshal(raw, &tkn); err != nil || tkn.Version != tokenVersion || len(tkn.Keys) != len(Order(req.Order)) {
+		return nil, invalid
+	}
+	if tkn.Query != query(req) {
+		return nil, status.Error(codes.InvalidArgument, "page token was issued for a different filter or order")
+	}
+	return tkn.Keys, nil
+}
+
+// List answers a ListEngines request from a set of engines, e.g. those of an in-memory store.
+// Engines are filtered, ordered and paged by page token or, for compatibility, by start. Total
+// counts all engines matching the filter. Every page but the last carries a next page token.
+func List(engines []*v1.EngineStatus, req *v1.ListEnginesRequest) (*v1.ListEnginesResponse, error) {
+	if req.Start < 0 || req.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "start and limit must not be negative")
+	}
+	if err := ValidateOrder(req.Order); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	order := Order(req.Order)
+	after, err := DecodeToken(req)
+	if err != nil {
+		return nil, err
+	}
+
+	type entry struct {
+		status *v1.EngineStatus
+		keys   []string
+	}
+	var matches []entry
+	for _, e := range engines {
+		if !filterexpr.Match(e, req.Filter) {
+			continue
+		}
+		keys, err := Keys(e, order)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, entry{e, keys})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return Compare(matches[i].keys, matches[j].keys, order) < 0
+	})
+
+	start := int(req.Start)
+	if after != nil {
+		start = sort.Search(len(matches), func(i int) bool {
+			return Compare(matches[i].keys, after, order) > 0
+		})
+	}
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := len(matches)
+	if req.Limit > 0 && start+int(req.Limit) < end {
+		end = start + int(req.Limit)
+	}
+
+	res := &v1.ListEnginesResponse{Total: int32(len(matches))}
+	for _, m := range matches[start:end] {
+		res.Result = append(res.Result, m.status)
+	}
+	if end < len(matches) && end > start {
+		res.NextPageToken, err = EncodeToken(req, matches[end-1].status)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
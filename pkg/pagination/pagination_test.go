@@ -0,0 +1,205 @@
+package pagination
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/filterexpr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// testEngines returns engines e0 … e(n-1), created a second apart, alternating between the owners alice and bob
+func testEngines(n int) []*v1.EngineStatus {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	res := make([]*v1.EngineStatus, n)
+	for i := range res {
+		owner := "alice"
+		if i%2 == 1 {
+			owner = "bob"
+		}
+		res[i] = &v1.EngineStatus{
+			Name: fmt.Sprintf("e%d", i),
+			Metadata: &v1.EngineMetadata{
+				Owner:   owner,
+				Created: timestamppb.New(start.Add(time.Duration(i) * time.Second)),
+			},
+		}
+	}
+	return res
+}
+
+func names(engines []*v1.EngineStatus) []string {
+	res := make([]string, len(engines))
+	for i, e := range engines {
+		res[i] = e.Name
+	}
+	return res
+}
+
+// listAll follows the page tokens of a listing
+func listAll(t *testing.T, engines []*v1.EngineStatus, req *v1.ListEnginesRequest) (pages [][]string) {
+	for i := 0; ; i++ {
+		if i > len(engines) {
+			t.Fatal("pagination does not terminate")
+		}
+		resp, err := List(engines, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, names(resp.Result))
+		if resp.NextPageToken == "" {
+			return pages
+		}
+		req.PageToken, req.Start = resp.NextPageToken, 0
+	}
+}
+
+func TestList(t *testing.T) {
+	engines := testEngines(5)
+	filter, err := filterexpr.Parse([]string{"owner==alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		Name        string
+		Req         *v1.ListEnginesRequest
+		Expectation [][]string
+	}{
+		{"default order", &v1.ListEnginesRequest{Limit: 2}, [][]string{{"e4", "e3"}, {"e2", "e1"}, {"e0"}}},
+		{"exact pages", &v1.ListEnginesRequest{Limit: 5}, [][]string{{"e4", "e3", "e2", "e1", "e0"}}},
+		{"no limit", &v1.ListEnginesRequest{}, [][]string{{"e4", "e3", "e2", "e1", "e0"}}},
+		{"ascending", &v1.ListEnginesRequest{Limit: 3, Order: []*v1.OrderExpression{{Field: "created", Ascending: true}}}, [][]string{{"e0", "e1", "e2"}, {"e3", "e4"}}},
+		{"tie-breaker", &v1.ListEnginesRequest{Limit: 2, Order: []*v1.OrderExpression{{Field: "owner", Ascending: true}}}, [][]string{{"e0", "e2"}, {"e4", "e1"}, {"e3"}}},
+		{"mixed directions", &v1.ListEnginesRequest{Limit: 2, Order: []*v1.OrderExpression{{Field: "owner", Ascending: true}, {Field: "created"}}}, [][]string{{"e4", "e2"}, {"e0", "e3"}, {"e1"}}},
+		{"filter", &v1.ListEnginesRequest{Limit: 2, Filter: filter}, [][]string{{"e4", "e2"}, {"e0"}}},
+		{"start", &v1.ListEnginesRequest{Start: 3, Limit: 1}, [][]string{{"e1"}, {"e0"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := listAll(t, engines, test.Req)
+			if !reflect.DeepEqual(act, test.Expectation) {
+				t.Errorf("expected pages %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestListConsistentWhileCreating(t *testing.T) {
+	engines := testEngines(4)
+	req := &v1.ListEnginesRequest{Limit: 2}
+	resp, err := List(engines, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := names(resp.Result); !reflect.DeepEqual(act, []string{"e3", "e2"}) {
+		t.Fatalf("unexpected first page %v", act)
+	}
+
+	// new engines come first, which would shift offset based pages
+	engines = testEngines(6)
+	req.PageToken = resp.NextPageToken
+	resp, err = List(engines, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := names(resp.Result); !reflect.DeepEqual(act, []string{"e1", "e0"}) {
+		t.Errorf("expected the second page to continue after e2, got %v", act)
+	}
+	if resp.Total != 6 {
+		t.Errorf("expected a total of 6, got %d", resp.Total)
+	}
+}
+
+func TestListInvalid(t *testing.T) {
+	engines := testEngines(3)
+	resp, err := List(engines, &v1.ListEnginesRequest{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tkn := resp.NextPageToken
+
+	tests := []struct {
+		Name string
+		Req  *v1.ListEnginesRequest
+	}{
+		{"garbage token", &v1.ListEnginesRequest{PageToken: "not a token"}},
+		{"start and token", &v1.ListEnginesRequest{PageToken: tkn, Start: 1}},
+		{"different order", &v1.ListEnginesRequest{PageToken: tkn, Order: []*v1.OrderExpression{{Field: "created", Ascending: true}}}},
+		{"different filter", &v1.ListEnginesRequest{PageToken: tkn, Filter: []*v1.FilterExpression{{Terms: []*v1.FilterTerm{{Field: "owner", Value: "bob"}}}}}},
+		{"unknown order field", &v1.ListEnginesRequest{Order: []*v1.OrderExpression{{Field: "color"}}}},
+		{"negative limit", &v1.ListEnginesRequest{Limit: -1}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if _, err := List(engines, test.Req); status.Code(err) != codes.InvalidArgument {
+				t.Errorf("expected InvalidArgument, got %v", err)
+			}
+		})
+	}
+
+	// the default order and its explicit form are the same query
+	if _, err := List(engines, &v1.ListEnginesRequest{PageToken: tkn, Order: DefaultOrder}); err != nil {
+		t.Errorf("expected the token to be valid with the explicit default order: %v", err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	s := &v1.EngineStatus{
+		Name:  "e",
+		Phase: v1.EnginePhase_PHASE_RUNNING,
+		Metadata: &v1.EngineMetadata{
+			Created:     timestamppb.New(time.Date(2021, 3, 1, 12, 0, 0, 5, time.UTC)),
+			Annotations: []*v1.Annotation{{Key: "lang", Value: "en"}},
+		},
+	}
+	tests := []struct {
+		Field       string
+		Expectation string
+	}{
+		{"name", "e"},
+		{"created", "2021-03-01T12:00:00.000000005Z"},
+		{"finished", ""},
+		{"phase", "running"},
+		{"success", "false"},
+		{"annotation.lang", "en"},
+		{"annotation.missing", ""},
+	}
+	for _, test := range tests {
+		act, err := Key(s, test.Field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if act != test.Expectation {
+			t.Errorf("Key(%s) = %q, expected %q", test.Field, act, test.Expectation)
+		}
+	}
+	if _, err := Key(s, "annotation."); err == nil {
+		t.Error("expected an error for an empty annotation key")
+	}
+}
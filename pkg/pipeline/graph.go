@@ -0,0 +1,117 @@
+package pipeline
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package pipeline runs engine specs which depend on other specs as a directed acyclic graph.
+// Starting a spec starts all specs it depends on first, and passes their results on.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Graph maps engine spec names to the names of the specs they depend on
+type Graph map[string][]string
+
+// Validate makes sure all dependencies exist and there are no cycles
+func (g Graph) Validate() error {
+	for spec, deps := range g {
+		for _, d := range deps {
+			if _, ok := g[d]; !ok {
+				return fmt.Errorf("spec %s depends on unknown spec %s", spec, d)
+			}
+			if d == spec {
+				return fmt.Errorf("spec %s depends on itself", spec)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(g))
+	var visit func(spec string, path []string) error
+	visit = func(spec string, path []string) error {
+		switch state[spec] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s -> %s", strings.Join(path, " -> "), spec)
+		case visited:
+			return nil
+		}
+		state[spec] = visiting
+		for _, d := range g[spec] {
+			if err := visit(d, append(path, spec)); err != nil {
+				return err
+			}
+		}
+		state[spec] = visited
+		return nil
+	}
+	for _, spec := range g.specs() {
+		if err := visit(spec, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stages returns the target spec and all specs it depends on, directly or indirectly, ordered
+// such that each spec comes after the specs it depends on. The order is stable.
+func (g Graph) Stages(target string) ([]string, error) {
+	if _, ok := g[target]; !ok {
+		return nil, fmt.Errorf("unknown spec %s", target)
+	}
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	var (
+		res  []string
+		done = make(map[string]bool)
+	)
+	var visit func(spec string)
+	visit = func(spec string) {
+		if done[spec] {
+			return
+		}
+		done[spec] = true
+		deps := append([]string(nil), g[spec]...)
+		sort.Strings(deps)
+		for _, d := range deps {
+			visit(d)
+		}
+		res = append(res, spec)
+	}
+	visit(target)
+	return res, nil
+}
+
+func (g Graph) specs() []string {
+	res := make([]string, 0, len(g))
+	for spec := range g {
+		res = append(res, spec)
+	}
+	sort.Strings(res)
+	return res
+}
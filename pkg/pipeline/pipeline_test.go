@@ -0,0 +1,384 @@
+package pipeline
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+)
+
+var testGraph = Graph{
+	"extract":   nil,
+	"fetch":     nil,
+	"normalize": {"extract", "fetch"},
+	"index":     {"normalize"},
+	"unrelated": nil,
+}
+
+func TestStages(t *testing.T) {
+	act, err := testGraph.Stages("index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"extract", "fetch", "normalize", "index"}
+	if !reflect.DeepEqual(act, exp) {
+		t.Errorf("expected %v, got %v", exp, act)
+	}
+
+	if _, err := testGraph.Stages("missing"); err == nil {
+		t.Error("expected error for unknown spec")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		Name  string
+		Graph Graph
+	}{
+		{Name: "unknown dependency", Graph: Graph{"a": {"b"}}},
+		{Name: "self dependency", Graph: Graph{"a": {"a"}}},
+		{Name: "cycle", Graph: Graph{"a": {"b"}, "b": {"c"}, "c": {"a"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if err := test.Graph.Validate(); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+	if err := testGraph.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type fakeStarter struct {
+	started map[string]*v1.EngineStatus
+	fail    string
+}
+
+func (f *fakeStarter) StartStage(ctx context.Context, spec string, md *v1.EngineMetadata) (*v1.EngineStatus, error) {
+	if spec == f.fail {
+		return nil, errors.New("cannot start")
+	}
+	e := &v1.EngineStatus{
+		Name:     spec + "-engine",
+		Phase:    v1.EnginePhase_PHASE_PREPARING,
+		Metadata: md,
+	}
+	f.started[spec] = e
+	return e, nil
+}
+
+func finish(e *v1.EngineStatus, success bool, results ...*v1.EngineResult) *v1.EngineStatus {
+	return &v1.EngineStatus{
+		Name:       e.Name,
+		Phase:      v1.EnginePhase_PHASE_DONE,
+		Metadata:   e.Metadata,
+		Conditions: &v1.EngineConditions{Success: success},
+		Results:    results,
+	}
+}
+
+func stageStates(p *v1.PipelineStatus) map[string]v1.PipelineStageState {
+	res := make(map[string]v1.PipelineStageState)
+	for _, s := range p.Stages {
+		res[s.Name] = s.State
+	}
+	return res
+}
+
+func TestRunner(t *testing.T) {
+	ctx := context.Background()
+	starter := &fakeStarter{started: make(map[string]*v1.EngineStatus)}
+	r := NewRunner(testGraph, starter)
+
+	p, err := r.Start(ctx, "index", &v1.EngineMetadata{Owner: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(starter.started) != 2 || starter.started["extract"] == nil || starter.started["fetch"] == nil {
+		t.Fatalf("expected extract and fetch to start, got %v", starter.started)
+	}
+	if p.Phase != v1.EnginePhase_PHASE_RUNNING {
+		t.Errorf("expected running pipeline, got %v", p.Phase)
+	}
+	if md := starter.started["extract"].Metadata; md.Owner != "alice" || md.EngineSpecName != "extract" || annotation(starter.started["extract"], AnnotationPipeline) != p.Name {
+		t.Errorf("unexpected metadata %v", md)
+	}
+
+	r.Update(ctx, finish(starter.started["extract"], true,
+		&v1.EngineResult{Type: "url", Payload: "a"},
+		&v1.EngineResult{Type: "url", Payload: "b"},
+	))
+	if starter.started["normalize"] != nil {
+		t.Fatal("normalize must wait for fetch")
+	}
+	r.Update(ctx, finish(starter.started["fetch"], true, &v1.EngineResult{Type: "count", Payload: "42"}))
+
+	normalize := starter.started["normalize"]
+	if normalize == nil {
+		t.Fatal("expected normalize to start")
+	}
+	inputs := make(map[string]string)
+	for _, a := range normalize.Metadata.Annotations {
+		inputs[a.Key] = a.Value
+	}
+	exp := map[string]string{
+		AnnotationInputPrefix + "extract.url":   "a",
+		AnnotationInputPrefix + "extract.url.1": "b",
+		AnnotationInputPrefix + "fetch.count":   "42",
+	}
+	for k, v := range exp {
+		if inputs[k] != v {
+			t.Errorf("expected annotation %s=%s, got %q", k, v, inputs[k])
+		}
+	}
+
+	r.Update(ctx, finish(normalize, true))
+	r.Update(ctx, finish(starter.started["index"], true))
+
+	resp, err := r.GetPipeline(ctx, &v1.GetPipelineRequest{Name: p.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Result.Phase != v1.EnginePhase_PHASE_DONE || !resp.Result.Success {
+		t.Errorf("expected successful pipeline, got %v", resp.Result)
+	}
+	if _, ok := starter.started["unrelated"]; ok {
+		t.Error("unrelated spec must not start")
+	}
+}
+
+func TestRunnerFailure(t *testing.T) {
+	ctx := context.Background()
+	starter := &fakeStarter{started: make(map[string]*v1.EngineStatus), fail: "fetch"}
+	var updates int
+	r := NewRunner(testGraph, starter)
+	r.OnUpdate = func(*v1.PipelineStatus) { updates++ }
+
+	p, err := r.Start(ctx, "index", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Update(ctx, finish(starter.started["extract"], true))
+
+	p, _ = r.Get(p.Name)
+	exp := map[string]v1.PipelineStageState{
+		"extract":   v1.PipelineStageState_STAGE_SUCCEEDED,
+		"fetch":     v1.PipelineStageState_STAGE_FAILED,
+		"normalize": v1.PipelineStageState_STAGE_SKIPPED,
+		"index":     v1.PipelineStageState_STAGE_SKIPPED,
+	}
+	if act := stageStates(p); !reflect.DeepEqual(act, exp) {
+		t.Errorf("expected %v, got %v", exp, act)
+	}
+	if p.Phase != v1.EnginePhase_PHASE_DONE || p.Success {
+		t.Errorf("expected failed pipeline, got %v", p)
+	}
+	if updates == 0 {
+		t.Error("expected pipeline updates")
+	}
+
+	// updates of finished stages are ignored
+	r.Update(ctx, finish(starter.started["extract"], false))
+	if p, _ := r.Get(p.Name); p.Stages[0].State != v1.PipelineStageState_STAGE_SUCCEEDED {
+		t.Errorf("expected finished stage to remain succeeded, got %v", p.Stages[0].State)
+	}
+
+	if err := r.Forget(ctx, p.Name); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.GetPipeline(ctx, &v1.GetPipelineRequest{Name: p.Name}); err == nil {
+		t.Error("expected error for forgotten pipeline")
+	}
+}
+
+func TestRunnerStageStates(t *testing.T) {
+	ctx := context.Background()
+	starter := &fakeStarter{started: make(map[string]*v1.EngineStatus)}
+	r := NewRunner(Graph{"a": nil}, starter)
+
+	p, err := r.Start(ctx, "a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := starter.started["a"]
+	state := func() v1.PipelineStageState {
+		p, _ := r.Get(p.Name)
+		return p.Stages[0].State
+	}
+	withPhase := func(phase v1.EnginePhase) *v1.EngineStatus {
+		return &v1.EngineStatus{Name: engine.Name, Phase: phase, Metadata: engine.Metadata}
+	}
+
+	tests := []struct {
+		Name        string
+		Phase       v1.EnginePhase
+		Expectation v1.PipelineStageState
+	}{
+		{Name: "preparing", Phase: v1.EnginePhase_PHASE_PREPARING, Expectation: v1.PipelineStageState_STAGE_STARTING},
+		{Name: "waiting", Phase: v1.EnginePhase_PHASE_WAITING, Expectation: v1.PipelineStageState_STAGE_STARTING},
+		{Name: "running", Phase: v1.EnginePhase_PHASE_RUNNING, Expectation: v1.PipelineStageState_STAGE_RUNNING},
+		{Name: "late starting", Phase: v1.EnginePhase_PHASE_STARTING, Expectation: v1.PipelineStageState_STAGE_RUNNING},
+	}
+	for _, test := range tests {
+		r.Update(ctx, withPhase(test.Phase))
+		if act := state(); act != test.Expectation {
+			t.Errorf("%s: expected %v, got %v", test.Name, test.Expectation, act)
+		}
+	}
+}
+
+func TestRunnerStore(t *testing.T) {
+	ctx := context.Background()
+	store := &MemoryStore{}
+	starter := &fakeStarter{started: make(map[string]*v1.EngineStatus)}
+	r := NewRunner(testGraph, starter)
+	r.Store = store
+
+	p, err := r.Start(ctx, "normalize", &v1.EngineMetadata{Owner: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := findStage(p, "extract"); s.Engine == nil || s.Engine.Name != starter.started["extract"].Name {
+		t.Errorf("expected the engine of the first stage, got %v", s.Engine)
+	}
+	// the pipeline is advanced by the runner which receives the updates, which loads it from the store
+	if _, ok := r.Get(p.Name); ok {
+		t.Error("expected the started pipeline to be left to the store")
+	}
+	r.Update(ctx, finish(starter.started["extract"], true))
+
+	// a new runner picks up where the old one stopped
+	r = NewRunner(testGraph, starter)
+	r.Store = store
+	if err := r.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	loaded, ok := r.Get(p.Name)
+	if !ok {
+		t.Fatal("expected pipeline to be loaded")
+	}
+	if s := stageStates(loaded); s["extract"] != v1.PipelineStageState_STAGE_SUCCEEDED || s["fetch"] != v1.PipelineStageState_STAGE_STARTING {
+		t.Errorf("unexpected stages %v", s)
+	}
+
+	r.Update(ctx, finish(starter.started["fetch"], true))
+	normalize := starter.started["normalize"]
+	if normalize == nil {
+		t.Fatal("expected normalize to start")
+	}
+	if normalize.Metadata.Owner != "alice" {
+		t.Errorf("expected loaded metadata, got %v", normalize.Metadata)
+	}
+	r.Update(ctx, finish(normalize, true))
+	if _, ok := r.Get(p.Name); ok {
+		t.Error("expected the finished pipeline to be left to the store")
+	}
+
+	stored, _ := store.List(ctx)
+	if len(stored) != 1 || stored[0].Status.Phase != v1.EnginePhase_PHASE_DONE || !stored[0].Status.Success {
+		t.Errorf("expected the finished pipeline to be stored, got %v", stored)
+	}
+
+	// other replicas read the pipeline from the store
+	other := NewRunner(testGraph, starter)
+	other.Store = store
+	resp, err := other.GetPipeline(ctx, &v1.GetPipelineRequest{Name: p.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Result.Success {
+		t.Errorf("expected successful pipeline, got %v", resp.Result)
+	}
+
+	if err := r.Forget(ctx, p.Name); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := store.List(ctx); len(stored) != 0 {
+		t.Errorf("expected forgotten pipeline to be deleted, got %v", stored)
+	}
+}
+
+func TestGraphFromSpecs(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Specs       map[string][]byte
+		Expectation Graph
+		Error       bool
+	}{
+		{
+			Name: "dependencies",
+			Specs: map[string][]byte{
+				"extract": []byte("pod:\n  containers: []\n"),
+				"index":   []byte("dependsOn:\n- extract\n"),
+			},
+			Expectation: Graph{"extract": nil, "index": {"extract"}},
+		},
+		{Name: "unknown dependency", Specs: map[string][]byte{"index": []byte("dependsOn: [extract]")}, Error: true},
+		{Name: "invalid yaml", Specs: map[string][]byte{"index": []byte("dependsOn: extract")}, Error: true},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act, err := GraphFromSpecs(test.Specs)
+			if test.Error {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(act, test.Expectation) {
+				t.Errorf("expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestLoadGraph(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"extract.yaml": "",
+		"index.yml":    "dependsOn: [extract]",
+		"README.md":    "dependsOn: [nothing]",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	act, err := LoadGraph(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := (Graph{"extract": nil, "index": {"extract"}}); !reflect.DeepEqual(act, exp) {
+		t.Errorf("expected %v, got %v", exp, act)
+	}
+}
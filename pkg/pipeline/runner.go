@@ -0,0 +1,511 @@
+package pipeline
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/stringid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// AnnotationPipeline names the pipeline an engine belongs to
+	AnnotationPipeline = "text.bhojpur.net/pipeline"
+	// AnnotationStage names the pipeline stage an engine runs
+	AnnotationStage = "text.bhojpur.net/pipeline-stage"
+	// AnnotationInputPrefix prefixes the annotations which pass the results of the stages an engine
+	// depends on, e.g. text.bhojpur.net/input.extract.url for a result of type url of stage extract.
+	// Further results of the same type get a numeric suffix, e.g. text.bhojpur.net/input.extract.url.1.
+	AnnotationInputPrefix = "text.bhojpur.net/input."
+)
+
+// Starter starts the engine of a pipeline stage
+type Starter interface {
+	StartStage(ctx context.Context, spec string, md *v1.EngineMetadata) (*v1.EngineStatus, error)
+}
+
+// StarterFunc turns a function into a Starter
+type StarterFunc func(ctx context.Context, spec string, md *v1.EngineMetadata) (*v1.EngineStatus, error)
+
+// StartStage calls f
+func (f StarterFunc) StartStage(ctx context.Context, spec string, md *v1.EngineMetadata) (*v1.EngineStatus, error) {
+	return f(ctx, spec, md)
+}
+
+// Runner runs pipelines. It starts the stages of a pipeline once all stages they depend on have
+// succeeded, and skips them if one has failed. The runner learns about the progress of stages
+// through Update, which must be called with every engine status change.
+//
+// With a store, pipelines may be started by the runner of any replica, but only the runner which
+// receives the updates, i.e. that of the leader, advances them: Start stores the pipeline before
+// its first stages start, and Update loads it once their engines report back. Finished pipelines
+// are only kept in the store.
+//
+// A Runner is safe for concurrent use.
+type Runner struct {
+	Graph   Graph
+	Starter Starter
+	// Store persists pipelines if set. Call Load to pick up the stored pipelines.
+	Store Store
+	// OnUpdate is called with a copy of the status of a pipeline whenever it changes
+	OnUpdate func(*v1.PipelineStatus)
+
+	mu        sync.Mutex
+	pipelines map[string]*Pipeline
+
+	// saveMu serialises writes to the store, so that an older state never overwrites a newer one
+	saveMu sync.Mutex
+}
+
+// NewRunner creates a new pipeline runner
+func NewRunner(graph Graph, starter Starter) *Runner {
+	return &Runner{
+		Graph:     graph,
+		Starter:   starter,
+		pipelines: make(map[string]*Pipeline),
+	}
+}
+
+// Load replaces the pipelines of the runner with those of the store, e.g. when this replica
+// takes over from another one. Stages which were started before continue once their engines
+// report back through Update.
+func (r *Runner) Load(ctx context.Context) error {
+	if r.Store == nil {
+		return nil
+	}
+	pipelines, err := r.Store.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pipelines = make(map[string]*Pipeline, len(pipelines))
+	for _, p := range pipelines {
+		if p.Status.Phase == v1.EnginePhase_PHASE_DONE {
+			continue
+		}
+		r.pipelines[p.Status.Name] = p
+	}
+	return nil
+}
+
+// SetGraph replaces the graph new pipelines are started from, e.g. when the specs have changed.
+// Pipelines which are already running keep their stages.
+func (r *Runner) SetGraph(graph Graph) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Graph = graph
+}
+
+// Start starts a pipeline for the target spec. All stages inherit the metadata, e.g. owner,
+// repository and trigger.
+func (r *Runner) Start(ctx context.Context, target string, md *v1.EngineMetadata) (*v1.PipelineStatus, error) {
+	r.mu.Lock()
+	graph := r.Graph
+	r.mu.Unlock()
+
+	stages, err := graph.Stages(target)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Pipeline{
+		Status: &v1.PipelineStatus{
+			Name:   target + "-" + stringid.TruncateID(stringid.GenerateRandomID()),
+			Target: target,
+		},
+		Metadata: md,
+	}
+	for _, s := range stages {
+		p.Status.Stages = append(p.Status.Stages, &v1.PipelineStage{
+			Name:      s,
+			DependsOn: append([]string(nil), graph[s]...),
+		})
+	}
+
+	if r.Store != nil {
+		return r.startStored(ctx, p)
+	}
+
+	r.mu.Lock()
+	r.pipelines[p.Status.Name] = p
+	ready := r.advance(p)
+	res := proto.Clone(p.Status).(*v1.PipelineStatus)
+	r.mu.Unlock()
+
+	r.start(ctx, res.Name, ready)
+
+	if s, ok := r.Get(p.Status.Name); ok {
+		res = s
+	}
+	return res, nil
+}
+
+// startStored stores a new pipeline and starts its first stages. The runner of the leader
+// advances the pipeline from there, hence it's not kept by this runner.
+func (r *Runner) startStored(ctx context.Context, p *Pipeline) (*v1.PipelineStatus, error) {
+	r.mu.Lock()
+	ready := r.advance(p)
+	r.mu.Unlock()
+	r.saveMu.Lock()
+	err := r.Store.Save(ctx, p.clone())
+	r.saveMu.Unlock()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot store pipeline: %v", err)
+	}
+
+	var failed bool
+	for _, req := range ready {
+		s := findStage(p.Status, req.Stage)
+		engine, err := r.Starter.StartStage(ctx, req.Stage, req.Metadata)
+		if err != nil {
+			log.WithError(err).WithField("pipeline", p.Status.Name).WithField("stage", req.Stage).Warn("cannot start pipeline stage")
+			s.State = v1.PipelineStageState_STAGE_FAILED
+			s.Engine = &v1.EngineStatus{Details: fmt.Sprintf("cannot start: %v", err)}
+			failed = true
+			continue
+		}
+		s.Engine = engine
+	}
+	if failed {
+		// failed stages only skip the stages which depend on them, none can become ready
+		r.mu.Lock()
+		r.advance(p)
+		r.mu.Unlock()
+		r.saveMu.Lock()
+		if err := r.Store.Save(ctx, p.clone()); err != nil {
+			log.WithError(err).WithField("pipeline", p.Status.Name).Warn("cannot store pipeline")
+		}
+		r.saveMu.Unlock()
+	}
+	return proto.Clone(p.Status).(*v1.PipelineStatus), nil
+}
+
+// Update updates the pipeline an engine belongs to, and starts stages which have become ready.
+// Engines which don't belong to a pipeline are ignored.
+func (r *Runner) Update(ctx context.Context, engine *v1.EngineStatus) {
+	name, stage := annotation(engine, AnnotationPipeline), annotation(engine, AnnotationStage)
+	if name == "" || stage == "" {
+		return
+	}
+
+	if !r.load(ctx, name) {
+		return
+	}
+
+	r.mu.Lock()
+	p, ok := r.pipelines[name]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	s := findStage(p.Status, stage)
+	if s == nil || isFinal(s.State) {
+		r.evictFinished(p)
+		r.mu.Unlock()
+		return
+	}
+	s.Engine = engine
+	// engines may report an earlier phase late, which must not move a running stage back
+	if state := stageState(engine); state != v1.PipelineStageState_STAGE_STARTING || s.State != v1.PipelineStageState_STAGE_RUNNING {
+		s.State = state
+	}
+	ready := r.advance(p)
+	r.mu.Unlock()
+
+	r.save(ctx, name)
+	r.start(ctx, name, ready)
+
+	r.mu.Lock()
+	r.evictFinished(p)
+	r.mu.Unlock()
+}
+
+// load makes sure the runner holds the pipeline, loading it from the store if necessary. It
+// returns false if the pipeline does not exist.
+func (r *Runner) load(ctx context.Context, name string) bool {
+	r.mu.Lock()
+	_, ok := r.pipelines[name]
+	r.mu.Unlock()
+	if ok || r.Store == nil {
+		return ok
+	}
+
+	p, err := r.Store.Get(ctx, name)
+	if err != nil {
+		log.WithError(err).WithField("pipeline", name).Warn("cannot load pipeline")
+		return false
+	}
+	if p == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.pipelines[name]; !ok {
+		r.pipelines[name] = p
+	}
+	return true
+}
+
+// evictFinished drops a finished pipeline, which the store keeps. Must be called with r.mu held.
+func (r *Runner) evictFinished(p *Pipeline) {
+	if r.Store == nil || p.Status.Phase != v1.EnginePhase_PHASE_DONE {
+		return
+	}
+	if r.pipelines[p.Status.Name] == p {
+		delete(r.pipelines, p.Status.Name)
+	}
+}
+
+// stageState maps the phase of a stage's engine to the state of the stage
+func stageState(engine *v1.EngineStatus) v1.PipelineStageState {
+	switch engine.Phase {
+	case v1.EnginePhase_PHASE_RUNNING:
+		return v1.PipelineStageState_STAGE_RUNNING
+	case v1.EnginePhase_PHASE_DONE, v1.EnginePhase_PHASE_CLEANUP:
+		if engine.Conditions.GetSuccess() {
+			return v1.PipelineStageState_STAGE_SUCCEEDED
+		}
+		return v1.PipelineStageState_STAGE_FAILED
+	default:
+		return v1.PipelineStageState_STAGE_STARTING
+	}
+}
+
+// Get returns a copy of the status of a pipeline
+func (r *Runner) Get(name string) (*v1.PipelineStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, ok := r.pipelines[name]
+	if !ok {
+		return nil, false
+	}
+	return proto.Clone(p.Status).(*v1.PipelineStatus), true
+}
+
+// Forget removes a pipeline from the runner and its store, e.g. once its engines have been removed
+func (r *Runner) Forget(ctx context.Context, name string) error {
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+
+	r.mu.Lock()
+	delete(r.pipelines, name)
+	r.mu.Unlock()
+
+	if r.Store == nil {
+		return nil
+	}
+	return r.Store.Delete(ctx, name)
+}
+
+// save writes the current state of a pipeline to the store. It's called after the runner's lock
+// was released, and always writes the latest state, so concurrent saves cannot reorder states.
+func (r *Runner) save(ctx context.Context, name string) {
+	if r.Store == nil {
+		return
+	}
+
+	r.saveMu.Lock()
+	defer r.saveMu.Unlock()
+
+	r.mu.Lock()
+	p, ok := r.pipelines[name]
+	if ok {
+		p = p.clone()
+	}
+	r.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := r.Store.Save(ctx, p); err != nil {
+		log.WithError(err).WithField("pipeline", name).Warn("cannot store pipeline")
+	}
+}
+
+// GetPipeline implements the TextService RPC of the same name. With a store the pipeline is read
+// from the store, which also holds the pipelines of runners on other replicas.
+func (r *Runner) GetPipeline(ctx context.Context, req *v1.GetPipelineRequest) (*v1.GetPipelineResponse, error) {
+	if r.Store != nil {
+		p, err := r.Store.Get(ctx, req.Name)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot load pipeline %s: %v", req.Name, err)
+		}
+		if p == nil {
+			return nil, status.Errorf(codes.NotFound, "pipeline %s not found", req.Name)
+		}
+		return &v1.GetPipelineResponse{Result: p.Status}, nil
+	}
+
+	p, ok := r.Get(req.Name)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "pipeline %s not found", req.Name)
+	}
+	return &v1.GetPipelineResponse{Result: p}, nil
+}
+
+// advance skips stages whose dependencies failed, updates the pipeline phase and returns the
+// stages which are ready to start, marking them as starting. Must be called with r.mu held.
+func (r *Runner) advance(p *Pipeline) (ready []*startRequest) {
+	for changed := true; changed; {
+		changed = false
+		for _, s := range p.Status.Stages {
+			if s.State != v1.PipelineStageState_STAGE_PENDING {
+				continue
+			}
+
+			allSucceeded := true
+			for _, d := range s.DependsOn {
+				switch findStage(p.Status, d).State {
+				case v1.PipelineStageState_STAGE_FAILED, v1.PipelineStageState_STAGE_SKIPPED:
+					s.State = v1.PipelineStageState_STAGE_SKIPPED
+					changed = true
+				case v1.PipelineStageState_STAGE_SUCCEEDED:
+				default:
+					allSucceeded = false
+				}
+			}
+			if s.State != v1.PipelineStageState_STAGE_PENDING || !allSucceeded {
+				continue
+			}
+
+			s.State = v1.PipelineStageState_STAGE_STARTING
+			ready = append(ready, &startRequest{Stage: s.Name, Metadata: stageMetadata(p, s)})
+		}
+	}
+
+	var (
+		final     = true
+		succeeded = true
+	)
+	for _, s := range p.Status.Stages {
+		if !isFinal(s.State) {
+			final = false
+		}
+		if s.State != v1.PipelineStageState_STAGE_SUCCEEDED {
+			succeeded = false
+		}
+	}
+	if final {
+		p.Status.Phase = v1.EnginePhase_PHASE_DONE
+		p.Status.Success = succeeded
+	} else {
+		p.Status.Phase = v1.EnginePhase_PHASE_RUNNING
+	}
+
+	if r.OnUpdate != nil {
+		r.OnUpdate(proto.Clone(p.Status).(*v1.PipelineStatus))
+	}
+	return ready
+}
+
+type startRequest struct {
+	Stage    string
+	Metadata *v1.EngineMetadata
+}
+
+// start starts the engines of the ready stages. Stages whose engine cannot be started fail.
+func (r *Runner) start(ctx context.Context, name string, ready []*startRequest) {
+	for _, req := range ready {
+		engine, err := r.Starter.StartStage(ctx, req.Stage, req.Metadata)
+		if err != nil {
+			log.WithError(err).WithField("pipeline", name).WithField("stage", req.Stage).Warn("cannot start pipeline stage")
+			r.fail(ctx, name, req.Stage, err)
+			continue
+		}
+		r.Update(ctx, engine)
+	}
+}
+
+func (r *Runner) fail(ctx context.Context, name, stage string, err error) {
+	r.mu.Lock()
+	p, ok := r.pipelines[name]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	s := findStage(p.Status, stage)
+	s.State = v1.PipelineStageState_STAGE_FAILED
+	s.Engine = &v1.EngineStatus{Details: fmt.Sprintf("cannot start: %v", err)}
+	ready := r.advance(p)
+	r.mu.Unlock()
+
+	r.save(ctx, name)
+	r.start(ctx, name, ready)
+}
+
+// stageMetadata produces the metadata of a stage's engine, including the results of the stages it depends on
+func stageMetadata(p *Pipeline, s *v1.PipelineStage) *v1.EngineMetadata {
+	md := &v1.EngineMetadata{}
+	if p.Metadata != nil {
+		md = proto.Clone(p.Metadata).(*v1.EngineMetadata)
+	}
+	md.EngineSpecName = s.Name
+	md.Annotations = append(md.Annotations,
+		&v1.Annotation{Key: AnnotationPipeline, Value: p.Status.Name},
+		&v1.Annotation{Key: AnnotationStage, Value: s.Name},
+	)
+
+	for _, d := range s.DependsOn {
+		seen := make(map[string]int)
+		for _, res := range findStage(p.Status, d).Engine.GetResults() {
+			key := AnnotationInputPrefix + d + "." + res.Type
+			if n := seen[res.Type]; n > 0 {
+				key += "." + strconv.Itoa(n)
+			}
+			seen[res.Type]++
+			md.Annotations = append(md.Annotations, &v1.Annotation{Key: key, Value: res.Payload})
+		}
+	}
+	return md
+}
+
+func findStage(p *v1.PipelineStatus, name string) *v1.PipelineStage {
+	for _, s := range p.Stages {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func isFinal(s v1.PipelineStageState) bool {
+	return s == v1.PipelineStageState_STAGE_SUCCEEDED || s == v1.PipelineStageState_STAGE_FAILED || s == v1.PipelineStageState_STAGE_SKIPPED
+}
+
+func annotation(e *v1.EngineStatus, key string) string {
+	for _, a := range e.Metadata.GetAnnotations() {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,89 @@
+package pipeline
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// specDependencies is the part of an engine spec the pipeline graph is built from
+type specDependencies struct {
+	// DependsOn lists the names of the specs which must succeed before the spec can start
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// ParseDependencies returns the names of the specs an engine spec depends on, as listed in its
+// dependsOn field. All other fields of the spec are ignored.
+func ParseDependencies(spec []byte) ([]string, error) {
+	var deps specDependencies
+	if err := yaml.Unmarshal(spec, &deps); err != nil {
+		return nil, err
+	}
+	return deps.DependsOn, nil
+}
+
+// GraphFromSpecs builds a graph from engine specs, keyed by their name
+func GraphFromSpecs(specs map[string][]byte) (Graph, error) {
+	res := make(Graph, len(specs))
+	for name, spec := range specs {
+		deps, err := ParseDependencies(spec)
+		if err != nil {
+			return nil, fmt.Errorf("spec %s: %w", name, err)
+		}
+		res[name] = deps
+	}
+	if err := res.Validate(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// LoadGraph builds a graph from the engine specs in a directory. Specs are the .yaml and .yml
+// files of the directory, named after the file without its extension, e.g. index for index.yaml.
+func LoadGraph(dir string) (Graph, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make(map[string][]byte)
+	for _, f := range files {
+		ext := filepath.Ext(f.Name())
+		if f.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), ext)
+		if _, exists := specs[name]; exists {
+			return nil, fmt.Errorf("spec %s is defined twice in %s", name, dir)
+		}
+		spec, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		specs[name] = spec
+	}
+	return GraphFromSpecs(specs)
+}
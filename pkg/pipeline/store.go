@@ -0,0 +1,199 @@
+package pipeline
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Pipeline is the state of a pipeline a Runner keeps
+type Pipeline struct {
+	Status *v1.PipelineStatus
+	// Metadata is the metadata the pipeline was started with, which all stages inherit
+	Metadata *v1.EngineMetadata
+}
+
+func (p *Pipeline) clone() *Pipeline {
+	res := &Pipeline{Status: proto.Clone(p.Status).(*v1.PipelineStatus)}
+	if p.Metadata != nil {
+		res.Metadata = proto.Clone(p.Metadata).(*v1.EngineMetadata)
+	}
+	return res
+}
+
+// Store persists pipelines, so that a Runner can pick them up again after a restart
+type Store interface {
+	// Save stores a pipeline, replacing a previously stored pipeline of the same name
+	Save(ctx context.Context, p *Pipeline) error
+
+	// Get returns a stored pipeline, or nil if there is none of that name
+	Get(ctx context.Context, name string) (*Pipeline, error)
+
+	// List returns all stored pipelines
+	List(ctx context.Context) ([]*Pipeline, error)
+
+	// Delete removes a pipeline
+	Delete(ctx context.Context, name string) error
+}
+
+// MemoryStore keeps pipelines in memory, e.g. for tests or installations without a database
+type MemoryStore struct {
+	mu        sync.RWMutex
+	pipelines map[string]*Pipeline
+}
+
+// Save implements Store
+func (s *MemoryStore) Save(ctx context.Context, p *Pipeline) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pipelines == nil {
+		s.pipelines = make(map[string]*Pipeline)
+	}
+	s.pipelines[p.Status.Name] = p.clone()
+	return nil
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(ctx context.Context, name string) (*Pipeline, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.pipelines[name]
+	if !ok {
+		return nil, nil
+	}
+	return p.clone(), nil
+}
+
+// List implements Store
+func (s *MemoryStore) List(ctx context.Context) ([]*Pipeline, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]*Pipeline, 0, len(s.pipelines))
+	for _, p := range s.pipelines {
+		res = append(res, p.clone())
+	}
+	return res, nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pipelines, name)
+	return nil
+}
+
+// SQLStore stores pipelines in the pipelines table of a PostgreSQL database
+type SQLStore struct {
+	DB *sql.DB
+}
+
+// Migrate creates the pipelines table if it does not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS pipelines (
+			name     TEXT PRIMARY KEY,
+			status   BYTEA NOT NULL,
+			metadata BYTEA NOT NULL
+		);
+	`)
+	return err
+}
+
+// Save implements Store
+func (s *SQLStore) Save(ctx context.Context, p *Pipeline) error {
+	status, err := proto.Marshal(p.Status)
+	if err != nil {
+		return err
+	}
+	md, err := proto.Marshal(p.Metadata)
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx,
+		`INSERT INTO pipelines (name, status, metadata) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET status = EXCLUDED.status, metadata = EXCLUDED.metadata`,
+		p.Status.Name, status, md,
+	)
+	return err
+}
+
+// Get implements Store
+func (s *SQLStore) Get(ctx context.Context, name string) (*Pipeline, error) {
+	var status, md []byte
+	err := s.DB.QueryRowContext(ctx, `SELECT status, metadata FROM pipelines WHERE name = $1`, name).Scan(&status, &md)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalPipeline(status, md)
+}
+
+// List implements Store
+func (s *SQLStore) List(ctx context.Context) ([]*Pipeline, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT status, metadata FROM pipelines ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []*Pipeline
+	for rows.Next() {
+		var status, md []byte
+		if err := rows.Scan(&status, &md); err != nil {
+			return nil, err
+		}
+		p, err := unmarshalPipeline(status, md)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+func unmarshalPipeline(status, md []byte) (*Pipeline, error) {
+	p := &Pipeline{Status: &v1.PipelineStatus{}, Metadata: &v1.EngineMetadata{}}
+	if err := proto.Unmarshal(status, p.Status); err != nil {
+		return nil, err
+	}
+	if err := proto.Unmarshal(md, p.Metadata); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Delete implements Store
+func (s *SQLStore) Delete(ctx context.Context, name string) error {
+	_, err := s.DB.ExecContext(ctx, `DELETE FROM pipelines WHERE name = $1`, name)
+	return err
+}
@@ -0,0 +1,327 @@
+package queue
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package queue implements admission control for engines. Engines wait in the queue until
+// the global, per-owner and per-spec concurrency limits permit them to start.
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+)
+
+// AnnotationPriority sets the priority of an engine among the engines of its owner. Engines with
+// higher priority start before the other engines of the same owner. Since owners set it themselves,
+// it does not let an owner jump ahead of other owners; that's what Limits.OwnerPriorities is for.
+const AnnotationPriority = "text.bhojpur.net/priority"
+
+// Limits are the concurrency limits. Zero means unlimited.
+type Limits struct {
+	// Global limits the number of engines running at the same time
+	Global int `json:"global,omitempty"`
+	// PerOwner limits the number of engines an owner can run at the same time
+	PerOwner int `json:"perOwner,omitempty"`
+	// PerSpec limits the number of engines of the same spec running at the same time
+	PerSpec int `json:"perSpec,omitempty"`
+	// Owners overrides PerOwner for particular owners
+	Owners map[string]int `json:"owners,omitempty"`
+	// Specs overrides PerSpec for particular specs
+	Specs map[string]int `json:"specs,omitempty"`
+	// OwnerPriorities lets the engines of particular owners start before those of other owners.
+	// Owners default to priority 0. Owners of equal priority are served in turn.
+	OwnerPriorities map[string]int `json:"ownerPriorities,omitempty"`
+}
+
+// Validate checks if the limits are sound
+func (l Limits) Validate() error {
+	if l.Global < 0 || l.PerOwner < 0 || l.PerSpec < 0 {
+		return fmt.Errorf("limits must not be negative")
+	}
+	for o, n := range l.Owners {
+		if n < 0 {
+			return fmt.Errorf("limit of owner %s must not be negative", o)
+		}
+	}
+	for s, n := range l.Specs {
+		if n < 0 {
+			return fmt.Errorf("limit of spec %s must not be negative", s)
+		}
+	}
+	return nil
+}
+
+func (l Limits) owner(name string) int {
+	if n, ok := l.Owners[name]; ok {
+		return n
+	}
+	return l.PerOwner
+}
+
+func (l Limits) ownerPriority(name string) int {
+	return l.OwnerPriorities[name]
+}
+
+func (l Limits) spec(name string) int {
+	if n, ok := l.Specs[name]; ok {
+		return n
+	}
+	return l.PerSpec
+}
+
+// Priority returns the priority of an engine as set by its priority annotation
+func Priority(status *v1.EngineStatus) (int, error) {
+	for _, a := range status.Metadata.GetAnnotations() {
+		if a.Key != AnnotationPriority {
+			continue
+		}
+		p, err := strconv.Atoi(a.Value)
+		if err != nil {
+			return 0, fmt.Errorf("invalid priority %q: %w", a.Value, err)
+		}
+		return p, nil
+	}
+	return 0, nil
+}
+
+type entry struct {
+	Status   *v1.EngineStatus
+	Priority int
+	Seq      uint64
+}
+
+func (e *entry) owner() string { return e.Status.Metadata.GetOwner() }
+func (e *entry) spec() string  { return e.Status.Metadata.GetEngineSpecName() }
+
+// Queue holds engines until they may start. Among the engines the limits permit to start, the
+// queue picks an engine of the owner with the highest owner priority. Between owners of equal
+// priority it picks the owner with the fewest running engines, falling back to the owner served
+// least recently, so that one owner cannot starve the others. Among the engines of an owner, those
+// with higher priority start first, and engines of equal priority start in order of arrival.
+// A Queue is safe for concurrent use.
+type Queue struct {
+	// OnUpdate is called with the status of queued engines whose position changed,
+	// e.g. to store it and notify subscribers. It is called with the queue's lock held.
+	OnUpdate func(*v1.EngineStatus)
+
+	mu      sync.Mutex
+	limits  Limits
+	seq     uint64
+	pending []*entry
+	running map[string]*entry
+	owners  map[string]int
+	specs   map[string]int
+	served  map[string]uint64
+}
+
+// New creates a new queue
+func New(limits Limits) *Queue {
+	return &Queue{
+		limits:  limits,
+		running: make(map[string]*entry),
+		owners:  make(map[string]int),
+		specs:   make(map[string]int),
+		served:  make(map[string]uint64),
+	}
+}
+
+// SetLimits changes the limits. Call Admit afterwards to start engines the new limits permit.
+func (q *Queue) SetLimits(limits Limits) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.limits = limits
+	q.updatePositions()
+}
+
+// Add queues an engine. The engine is moved to the preparing phase and its details explain its queue position.
+func (q *Queue) Add(status *v1.EngineStatus, priority int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.seq++
+	status.Phase = v1.EnginePhase_PHASE_PREPARING
+	q.pending = append(q.pending, &entry{Status: status, Priority: priority, Seq: q.seq})
+	q.updatePositions()
+}
+
+// Admit removes all engines from the queue which may start now and returns them in the order
+// they should be started. The engines count towards the limits until they are released.
+func (q *Queue) Admit() []*v1.EngineStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var res []*v1.EngineStatus
+	for {
+		idx := -1
+		for i, e := range q.pending {
+			if q.blockedBy(e) != "" {
+				continue
+			}
+			if idx < 0 || q.before(e, q.pending[idx]) {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			break
+		}
+
+		e := q.pending[idx]
+		q.pending = append(q.pending[:idx], q.pending[idx+1:]...)
+		q.running[e.Status.Name] = e
+		q.owners[e.owner()]++
+		q.specs[e.spec()]++
+		q.seq++
+		q.served[e.owner()] = q.seq
+		e.Status.Details = ""
+		res = append(res, e.Status)
+	}
+	if len(res) > 0 {
+		q.updatePositions()
+	}
+	return res
+}
+
+// Release frees the slot of an engine which has finished. Call Admit afterwards to start queued engines.
+func (q *Queue) Release(name string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	e, ok := q.running[name]
+	if !ok {
+		return
+	}
+	delete(q.running, name)
+	q.owners[e.owner()]--
+	if q.owners[e.owner()] <= 0 {
+		delete(q.owners, e.owner())
+	}
+	q.specs[e.spec()]--
+	if q.specs[e.spec()] <= 0 {
+		delete(q.specs, e.spec())
+	}
+	q.updatePositions()
+}
+
+// Remove removes a queued engine, e.g. because it was stopped before it started.
+// It returns false if the engine is not queued.
+func (q *Queue) Remove(name string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, e := range q.pending {
+		if e.Status.Name == name {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			q.updatePositions()
+			return true
+		}
+	}
+	return false
+}
+
+// Position returns the 1-based position of a queued engine
+func (q *Queue) Position(name string) (pos int, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, e := range q.ordered() {
+		if e.Status.Name == name {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// Len returns the number of queued engines
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Running returns the number of engines which were admitted and not released yet
+func (q *Queue) Running() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.running)
+}
+
+// blockedBy returns the limit which prevents an engine from starting, or an empty string if there's none
+func (q *Queue) blockedBy(e *entry) string {
+	if l := q.limits.Global; l > 0 && len(q.running) >= l {
+		return fmt.Sprintf("global limit of %d running engines", l)
+	}
+	if l := q.limits.owner(e.owner()); l > 0 && q.owners[e.owner()] >= l {
+		return fmt.Sprintf("limit of %d running engines for owner %q", l, e.owner())
+	}
+	if l := q.limits.spec(e.spec()); l > 0 && q.specs[e.spec()] >= l {
+		return fmt.Sprintf("limit of %d running engines for spec %q", l, e.spec())
+	}
+	return ""
+}
+
+// before returns true if a should start before b. The engine priority only decides among
+// owners which are otherwise equal, so that an owner cannot starve others by raising it.
+func (q *Queue) before(a, b *entry) bool {
+	if a.owner() != b.owner() {
+		if pa, pb := q.limits.ownerPriority(a.owner()), q.limits.ownerPriority(b.owner()); pa != pb {
+			return pa > pb
+		}
+		if ra, rb := q.owners[a.owner()], q.owners[b.owner()]; ra != rb {
+			return ra < rb
+		}
+		if sa, sb := q.served[a.owner()], q.served[b.owner()]; sa != sb {
+			return sa < sb
+		}
+	}
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.Seq < b.Seq
+}
+
+// ordered returns the queued engines in the order they would start if there were no limits
+func (q *Queue) ordered() []*entry {
+	res := make([]*entry, len(q.pending))
+	copy(res, q.pending)
+	sort.SliceStable(res, func(i, j int) bool { return q.before(res[i], res[j]) })
+	return res
+}
+
+// updatePositions explains the queue position of all queued engines in their details
+func (q *Queue) updatePositions() {
+	ordered := q.ordered()
+	for i, e := range ordered {
+		details := fmt.Sprintf("queued at position %d of %d", i+1, len(ordered))
+		if reason := q.blockedBy(e); reason != "" {
+			details += ", waiting for " + reason
+		}
+		if e.Status.Details == details {
+			continue
+		}
+		e.Status.Details = details
+		if q.OnUpdate != nil {
+			q.OnUpdate(e.Status)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package queue
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+)
+
+func engine(name, owner, spec string) *v1.EngineStatus {
+	return &v1.EngineStatus{
+		Name:     name,
+		Metadata: &v1.EngineMetadata{Owner: owner, EngineSpecName: spec},
+	}
+}
+
+func names(engines []*v1.EngineStatus) []string {
+	res := make([]string, 0, len(engines))
+	for _, e := range engines {
+		res = append(res, e.Name)
+	}
+	return res
+}
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Limits      Limits
+		Expectation []string
+	}{
+		{Name: "unlimited", Limits: Limits{}, Expectation: []string{"a1", "b1", "a2", "a3"}},
+		{Name: "global", Limits: Limits{Global: 2}, Expectation: []string{"a1", "b1"}},
+		{Name: "per owner", Limits: Limits{PerOwner: 1}, Expectation: []string{"a1", "b1"}},
+		{Name: "owner override", Limits: Limits{PerOwner: 1, Owners: map[string]int{"alice": 2}}, Expectation: []string{"a1", "b1", "a2"}},
+		{Name: "per spec", Limits: Limits{PerSpec: 1}, Expectation: []string{"a1", "a3"}},
+		{Name: "spec override", Limits: Limits{Specs: map[string]int{"index": 1}}, Expectation: []string{"a1", "a3"}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			q := New(test.Limits)
+			q.Add(engine("a1", "alice", "index"), 0)
+			q.Add(engine("a2", "alice", "index"), 0)
+			q.Add(engine("b1", "bob", "index"), 0)
+			q.Add(engine("a3", "alice", "extract"), 0)
+
+			act := names(q.Admit())
+			if !reflect.DeepEqual(act, test.Expectation) {
+				t.Errorf("expected %v, got %v", test.Expectation, act)
+			}
+			if q.Running() != len(test.Expectation) || q.Len() != 4-len(test.Expectation) {
+				t.Errorf("unexpected running %d and queued %d", q.Running(), q.Len())
+			}
+		})
+	}
+}
+
+func TestFairness(t *testing.T) {
+	q := New(Limits{Global: 1})
+	for _, n := range []string{"a1", "a2", "a3"} {
+		q.Add(engine(n, "alice", "index"), 0)
+	}
+	q.Add(engine("b1", "bob", "index"), 0)
+	q.Add(engine("b2", "bob", "index"), 0)
+	q.Add(engine("c1", "carol", "index"), 0)
+
+	var order []string
+	for q.Len() > 0 {
+		admitted := q.Admit()
+		if len(admitted) != 1 {
+			t.Fatalf("expected exactly one engine to be admitted, got %v", names(admitted))
+		}
+		order = append(order, admitted[0].Name)
+		q.Release(admitted[0].Name)
+	}
+	exp := []string{"a1", "b1", "c1", "a2", "b2", "a3"}
+	if !reflect.DeepEqual(order, exp) {
+		t.Errorf("expected %v, got %v", exp, order)
+	}
+}
+
+func TestPriority(t *testing.T) {
+	q := New(Limits{Global: 1})
+	q.Add(engine("low", "alice", "index"), 0)
+	q.Add(engine("high", "alice", "index"), 10)
+
+	if act := names(q.Admit()); !reflect.DeepEqual(act, []string{"high"}) {
+		t.Errorf("expected high priority engine first, got %v", act)
+	}
+}
+
+func TestPriorityFlood(t *testing.T) {
+	q := New(Limits{Global: 1})
+	for _, n := range []string{"a1", "a2", "a3", "a4"} {
+		q.Add(engine(n, "alice", "index"), 100)
+	}
+	q.Add(engine("b1", "bob", "index"), 0)
+
+	var order []string
+	for q.Len() > 0 {
+		admitted := q.Admit()
+		order = append(order, names(admitted)...)
+		for _, e := range admitted {
+			q.Release(e.Name)
+		}
+	}
+	if exp := []string{"a1", "b1", "a2", "a3", "a4"}; !reflect.DeepEqual(order, exp) {
+		t.Errorf("expected bob to get a turn despite alice's priority, got %v", order)
+	}
+}
+
+func TestOwnerPriorities(t *testing.T) {
+	q := New(Limits{Global: 1, OwnerPriorities: map[string]int{"ops": 1}})
+	q.Add(engine("a1", "alice", "index"), 100)
+	q.Add(engine("o1", "ops", "index"), 0)
+	q.Add(engine("o2", "ops", "index"), 0)
+
+	var order []string
+	for q.Len() > 0 {
+		admitted := q.Admit()
+		order = append(order, names(admitted)...)
+		for _, e := range admitted {
+			q.Release(e.Name)
+		}
+	}
+	if exp := []string{"o1", "o2", "a1"}; !reflect.DeepEqual(order, exp) {
+		t.Errorf("expected the prioritized owner first, got %v", order)
+	}
+}
+
+func TestDetails(t *testing.T) {
+	var updates []string
+	q := New(Limits{PerOwner: 1})
+	q.OnUpdate = func(s *v1.EngineStatus) { updates = append(updates, s.Name) }
+
+	a1, a2, a3 := engine("a1", "alice", "index"), engine("a2", "alice", "index"), engine("a3", "alice", "index")
+	q.Add(a1, 0)
+	q.Admit()
+	q.Add(a2, 0)
+	q.Add(a3, 0)
+
+	if a2.Phase != v1.EnginePhase_PHASE_PREPARING {
+		t.Errorf("expected queued engine in preparing phase, got %v", a2.Phase)
+	}
+	if a1.Details != "" {
+		t.Errorf("expected admitted engine without details, got %q", a1.Details)
+	}
+	if !strings.HasPrefix(a3.Details, "queued at position 2 of 2") || !strings.Contains(a3.Details, `owner "alice"`) {
+		t.Errorf("unexpected details %q", a3.Details)
+	}
+	if pos, ok := q.Position("a3"); !ok || pos != 2 {
+		t.Errorf("expected a3 at position 2, got %d", pos)
+	}
+
+	updates = nil
+	if !q.Remove("a2") {
+		t.Fatal("expected a2 to be removed")
+	}
+	if !strings.HasPrefix(a3.Details, "queued at position 1 of 1") {
+		t.Errorf("unexpected details %q", a3.Details)
+	}
+	if !reflect.DeepEqual(updates, []string{"a3"}) {
+		t.Errorf("expected update for a3, got %v", updates)
+	}
+
+	q.Release("a1")
+	if act := names(q.Admit()); !reflect.DeepEqual(act, []string{"a3"}) {
+		t.Errorf("expected a3 to start after release, got %v", act)
+	}
+}
+
+func TestPriorityAnnotation(t *testing.T) {
+	e := engine("a", "alice", "index")
+	if p, err := Priority(e); err != nil || p != 0 {
+		t.Errorf("expected default priority 0, got %d (%v)", p, err)
+	}
+	e.Metadata.Annotations = []*v1.Annotation{{Key: AnnotationPriority, Value: "5"}}
+	if p, err := Priority(e); err != nil || p != 5 {
+		t.Errorf("expected priority 5, got %d (%v)", p, err)
+	}
+	e.Metadata.Annotations[0].Value = "high"
+	if _, err := Priority(e); err == nil {
+		t.Error("expected error for invalid priority")
+	}
+}
@@ -0,0 +1,305 @@
+package retention
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	log "github.com/sirupsen/logrus"
+)
+
+// AnnotationReason is added to engines which are being removed. Engines which still carry it
+// after a collection failed half-way are removed by the next collection.
+const AnnotationReason = "text.bhojpur.net/retention-reason"
+
+// findPageSize is the number of engines loaded from the store at once
+const findPageSize = 500
+
+// EngineStore provides access to the stored engine status
+type EngineStore interface {
+	// Find searches for engines
+	Find(ctx context.Context, filter []*v1.FilterExpression, order []*v1.OrderExpression, start, limit int) (slice []*v1.EngineStatus, total int, err error)
+	// Store stores an engine status
+	Store(ctx context.Context, status *v1.EngineStatus) error
+	// Delete removes an engine
+	Delete(ctx context.Context, name string) error
+}
+
+// Remover removes data associated with an engine, e.g. its log segments or spool files.
+// Removing data which does not exist must not fail.
+type Remover interface {
+	Remove(ctx context.Context, name string) error
+}
+
+// RemoverFunc turns a function into a Remover
+type RemoverFunc func(ctx context.Context, name string) error
+
+// Remove calls f
+func (f RemoverFunc) Remove(ctx context.Context, name string) error {
+	return f(ctx, name)
+}
+
+// Collector applies a retention policy by removing engines with all their data
+type Collector struct {
+	// Policy is the retention policy. Use SetPolicy to change it while the collector is in use.
+	Policy  Policy
+	Engines EngineStore
+	Logs    Remover
+	Spool   Remover
+
+	// OnUpdate is called when an engine enters the cleanup phase, e.g. to notify subscribers
+	OnUpdate func(*v1.EngineStatus)
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	policyMu sync.RWMutex
+}
+
+// SetPolicy replaces the retention policy, e.g. when the server config was reloaded. It takes
+// effect with the next collection.
+func (c *Collector) SetPolicy(p Policy) {
+	c.policyMu.Lock()
+	defer c.policyMu.Unlock()
+	c.Policy = p
+}
+
+func (c *Collector) policy() Policy {
+	c.policyMu.RLock()
+	defer c.policyMu.RUnlock()
+	return c.Policy
+}
+
+// Start runs a collection every policy interval until the context is canceled
+func (c *Collector) Start(ctx context.Context) {
+	for {
+		removed, err := c.Collect(ctx)
+		if err != nil {
+			log.WithError(err).Warn("engine retention failed")
+		}
+		if len(removed) > 0 {
+			log.WithField("count", len(removed)).Info("removed engines according to retention policy")
+		}
+
+		interval := c.policy().Interval
+		if interval == 0 {
+			interval = DefaultInterval
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Plan determines which engines a collection would remove right now, without removing them
+func (c *Collector) Plan(ctx context.Context) ([]Candidate, error) {
+	policy := c.policy()
+	if !policy.Enabled() {
+		return nil, nil
+	}
+
+	finished, err := c.findAll(ctx, v1.EnginePhase_PHASE_DONE)
+	if err != nil {
+		return nil, err
+	}
+	res := policy.Plan(finished, c.now())
+
+	// engines a previous collection failed to remove
+	cleanup, err := c.findAll(ctx, v1.EnginePhase_PHASE_CLEANUP)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range cleanup {
+		if reason, ok := annotation(e, AnnotationReason); ok {
+			res = append(res, Candidate{Engine: e, Reason: reason})
+		}
+	}
+	return res, nil
+}
+
+// Collect removes all engines the policy selects, together with their logs and spool files.
+// Engines are moved to the cleanup phase before their data is removed. The engine itself is
+// removed last, so that a failed removal is retried by the next collection.
+func (c *Collector) Collect(ctx context.Context) (removed []Candidate, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	candidates, err := c.Plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		failed   int
+		firstErr error
+	)
+	for _, cand := range candidates {
+		if ctx.Err() != nil {
+			return removed, ctx.Err()
+		}
+
+		err := c.remove(ctx, cand)
+		if err != nil {
+			log.WithError(err).WithField("name", cand.Engine.Name).Warn("cannot remove engine")
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		log.WithField("name", cand.Engine.Name).WithField("reason", cand.Reason).Debug("removed engine")
+		removed = append(removed, cand)
+	}
+	if failed > 0 {
+		return removed, fmt.Errorf("cannot remove %d of %d engines: %w", failed, len(candidates), firstErr)
+	}
+	return removed, nil
+}
+
+func (c *Collector) remove(ctx context.Context, cand Candidate) error {
+	e := cand.Engine
+	if e.Phase != v1.EnginePhase_PHASE_CLEANUP {
+		e.Phase = v1.EnginePhase_PHASE_CLEANUP
+		e.Metadata = ensureMetadata(e.Metadata)
+		e.Metadata.Annotations = append(e.Metadata.Annotations, &v1.Annotation{Key: AnnotationReason, Value: cand.Reason})
+		if err := c.Engines.Store(ctx, e); err != nil {
+			return fmt.Errorf("cannot mark engine for cleanup: %w", err)
+		}
+		if c.OnUpdate != nil {
+			c.OnUpdate(e)
+		}
+	}
+
+	if c.Spool != nil {
+		if err := c.Spool.Remove(ctx, e.Name); err != nil {
+			return fmt.Errorf("cannot remove spool files: %w", err)
+		}
+	}
+	if c.Logs != nil {
+		if err := c.Logs.Remove(ctx, e.Name); err != nil {
+			return fmt.Errorf("cannot remove logs: %w", err)
+		}
+	}
+	if err := c.Engines.Delete(ctx, e.Name); err != nil {
+		return fmt.Errorf("cannot remove engine: %w", err)
+	}
+	return nil
+}
+
+// GetRetentionReport implements the TextAdmin RPC of the same name
+func (c *Collector) GetRetentionReport(ctx context.Context, req *v1.GetRetentionReportRequest) (*v1.GetRetentionReportResponse, error) {
+	candidates, err := c.Plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &v1.GetRetentionReportResponse{
+		Policy:     c.policy().String(),
+		Candidates: make([]*v1.RetentionCandidate, 0, len(candidates)),
+	}
+	for _, cand := range candidates {
+		res.Candidates = append(res.Candidates, &v1.RetentionCandidate{Engine: cand.Engine, Reason: cand.Reason})
+	}
+	return res, nil
+}
+
+func (c *Collector) findAll(ctx context.Context, phase v1.EnginePhase) ([]*v1.EngineStatus, error) {
+	filter := []*v1.FilterExpression{{Terms: []*v1.FilterTerm{
+		{Field: "phase", Value: phaseName(phase), Operation: v1.FilterOp_OP_EQUALS},
+	}}}
+	order := []*v1.OrderExpression{{Field: "name", Ascending: true}}
+
+	var res []*v1.EngineStatus
+	for {
+		slice, total, err := c.Engines.Find(ctx, filter, order, len(res), findPageSize)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, slice...)
+		if len(slice) == 0 || len(res) >= total {
+			return res, nil
+		}
+	}
+}
+
+func (c *Collector) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func ensureMetadata(md *v1.EngineMetadata) *v1.EngineMetadata {
+	if md == nil {
+		return &v1.EngineMetadata{}
+	}
+	return md
+}
+
+func annotation(e *v1.EngineStatus, key string) (string, bool) {
+	for _, a := range e.Metadata.GetAnnotations() {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// phaseName returns the name of a phase as used in filter expressions, e.g. "done"
+func phaseName(p v1.EnginePhase) string {
+	return strings.ToLower(strings.TrimPrefix(p.String(), "PHASE_"))
+}
+
+// SpoolExtensions are the extensions of the files an engine leaves in a spool directory next to
+// its workspace, e.g. <dir>/<name>.tar.gz for an uploaded application tar
+var SpoolExtensions = []string{".tar", ".tar.gz", ".tgz"}
+
+// DirRemover removes the files and directories of an engine from a spool directory, i.e.
+// <dir>/<name> and <dir>/<name><ext> for each of the SpoolExtensions. Files of other engines
+// whose name starts with the same prefix, e.g. <dir>/<name>.1.tar.gz, are left alone.
+type DirRemover string
+
+// Remove removes the engine's files
+func (d DirRemover) Remove(ctx context.Context, name string) error {
+	if name == "" || strings.ContainsAny(name, `/\*?[`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid engine name %q", name)
+	}
+
+	fns := []string{filepath.Join(string(d), name)}
+	for _, ext := range SpoolExtensions {
+		fns = append(fns, filepath.Join(string(d), name+ext))
+	}
+	for _, fn := range fns {
+		if err := os.RemoveAll(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package retention
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package retention removes old engines together with their logs and spool files,
+// according to a retention policy.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+)
+
+// Policy determines which finished engines are removed. Zero values disable the respective rule.
+// Engines which have not finished yet are never removed.
+type Policy struct {
+	// MaxAge is the time after which finished engines are removed
+	MaxAge time.Duration
+	// MaxCount is the number of finished engines kept per spec and owner
+	MaxCount int
+	// KeepFailed is the number of most recent failed engines per spec and owner which are kept
+	// regardless of MaxAge and MaxCount, so that failures can still be investigated.
+	KeepFailed int
+	// Interval is the time between two collections
+	Interval time.Duration
+}
+
+// DefaultInterval is the collection interval used if the policy does not specify one
+const DefaultInterval = 1 * time.Hour
+
+// Validate checks if the policy is sound
+func (p Policy) Validate() error {
+	if p.MaxAge < 0 {
+		return fmt.Errorf("max age must not be negative")
+	}
+	if p.MaxCount < 0 {
+		return fmt.Errorf("max count must not be negative")
+	}
+	if p.KeepFailed < 0 {
+		return fmt.Errorf("keep failed must not be negative")
+	}
+	if p.Interval < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+	return nil
+}
+
+// Enabled returns true if the policy would ever remove an engine
+func (p Policy) Enabled() bool {
+	return p.MaxAge > 0 || p.MaxCount > 0
+}
+
+// String describes the policy in a human readable form
+func (p Policy) String() string {
+	if !p.Enabled() {
+		return "disabled"
+	}
+	var rules []string
+	if p.MaxAge > 0 {
+		rules = append(rules, fmt.Sprintf("max age %s", p.MaxAge))
+	}
+	if p.MaxCount > 0 {
+		rules = append(rules, fmt.Sprintf("max %d per spec and owner", p.MaxCount))
+	}
+	if p.KeepFailed > 0 {
+		rules = append(rules, fmt.Sprintf("keep last %d failed", p.KeepFailed))
+	}
+	return strings.Join(rules, ", ")
+}
+
+// Candidate is an engine the policy would remove
+type Candidate struct {
+	Engine *v1.EngineStatus
+	Reason string
+}
+
+// Plan determines which of the engines the policy would remove at the given time.
+// Candidates are returned oldest first.
+func (p Policy) Plan(engines []*v1.EngineStatus, now time.Time) []Candidate {
+	if !p.Enabled() {
+		return nil
+	}
+
+	groups := make(map[groupKey][]*v1.EngineStatus)
+	for _, e := range engines {
+		if e.Phase != v1.EnginePhase_PHASE_DONE {
+			continue
+		}
+		k := groupKeyOf(e)
+		groups[k] = append(groups[k], e)
+	}
+
+	var res []Candidate
+	for _, group := range groups {
+		// newest first, so that the position within the group is the number of newer engines
+		sort.SliceStable(group, func(i, j int) bool {
+			return finishedAt(group[i]).After(finishedAt(group[j]))
+		})
+
+		var failed int
+		for i, e := range group {
+			if !e.Conditions.GetSuccess() {
+				failed++
+				if failed <= p.KeepFailed {
+					continue
+				}
+			}
+
+			switch {
+			case p.MaxAge > 0 && now.Sub(finishedAt(e)) > p.MaxAge:
+				res = append(res, Candidate{Engine: e, Reason: fmt.Sprintf("finished more than %s ago", p.MaxAge)})
+			case p.MaxCount > 0 && i >= p.MaxCount:
+				res = append(res, Candidate{Engine: e, Reason: fmt.Sprintf("%d newer engines of spec %q owned by %q", i, e.Metadata.GetEngineSpecName(), e.Metadata.GetOwner())})
+			}
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		ti, tj := finishedAt(res[i].Engine), finishedAt(res[j].Engine)
+		if ti.Equal(tj) {
+			return res[i].Engine.Name < res[j].Engine.Name
+		}
+		return ti.Before(tj)
+	})
+	return res
+}
+
+type groupKey struct {
+	Spec  string
+	Owner string
+}
+
+func groupKeyOf(e *v1.EngineStatus) groupKey {
+	return groupKey{Spec: e.Metadata.GetEngineSpecName(), Owner: e.Metadata.GetOwner()}
+}
+
+// finishedAt returns the time an engine finished, falling back to its creation time
+// for engines which never recorded their end.
+func finishedAt(e *v1.EngineStatus) time.Time {
+	if ts := e.Metadata.GetFinished(); ts != nil {
+		return ts.AsTime()
+	}
+	return e.Metadata.GetCreated().AsTime()
+}
@@ -0,0 +1,252 @@
+package retention
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var now = time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func engine(name, spec, owner string, phase v1.EnginePhase, success bool, age time.Duration) *v1.EngineStatus {
+	return &v1.EngineStatus{
+		Name:  name,
+		Phase: phase,
+		Metadata: &v1.EngineMetadata{
+			Owner:          owner,
+			EngineSpecName: spec,
+			Created:        timestamppb.New(now.Add(-age - time.Minute)),
+			Finished:       timestamppb.New(now.Add(-age)),
+		},
+		Conditions: &v1.EngineConditions{Success: success},
+	}
+}
+
+func candidateNames(cs []Candidate) []string {
+	res := make([]string, 0, len(cs))
+	for _, c := range cs {
+		res = append(res, c.Engine.Name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func TestPlan(t *testing.T) {
+	const (
+		done    = v1.EnginePhase_PHASE_DONE
+		running = v1.EnginePhase_PHASE_RUNNING
+	)
+	engines := func() []*v1.EngineStatus {
+		return []*v1.EngineStatus{
+			engine("a1", "a", "alice", done, true, 1*time.Hour),
+			engine("a2", "a", "alice", done, false, 2*time.Hour),
+			engine("a3", "a", "alice", done, true, 3*time.Hour),
+			engine("a4", "a", "alice", done, false, 4*time.Hour),
+			engine("a5", "a", "alice", running, false, 5*time.Hour),
+			engine("b1", "a", "bob", done, true, 5*time.Hour),
+			engine("c1", "c", "alice", done, true, 48*time.Hour),
+		}
+	}
+
+	tests := []struct {
+		Name        string
+		Policy      Policy
+		Expectation []string
+	}{
+		{Name: "disabled", Policy: Policy{KeepFailed: 1}, Expectation: []string{}},
+		{Name: "max age", Policy: Policy{MaxAge: 150 * time.Minute}, Expectation: []string{"a3", "a4", "b1", "c1"}},
+		{Name: "max count", Policy: Policy{MaxCount: 2}, Expectation: []string{"a3", "a4"}},
+		{Name: "max count keep failed", Policy: Policy{MaxCount: 1, KeepFailed: 1}, Expectation: []string{"a3", "a4"}},
+		{Name: "max age keep failed", Policy: Policy{MaxAge: time.Minute, KeepFailed: 2}, Expectation: []string{"a1", "a3", "b1", "c1"}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := candidateNames(test.Policy.Plan(engines(), now))
+			if !reflect.DeepEqual(act, test.Expectation) {
+				t.Errorf("expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestPlanOrder(t *testing.T) {
+	p := Policy{MaxAge: time.Minute}
+	act := p.Plan([]*v1.EngineStatus{
+		engine("new", "a", "alice", v1.EnginePhase_PHASE_DONE, true, 1*time.Hour),
+		engine("old", "b", "bob", v1.EnginePhase_PHASE_DONE, true, 2*time.Hour),
+	}, now)
+	if len(act) != 2 || act[0].Engine.Name != "old" || act[0].Reason == "" {
+		t.Errorf("expected oldest candidate first, got %v", act)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, p := range []Policy{{MaxAge: -1}, {MaxCount: -1}, {KeepFailed: -1}, {Interval: -1}} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("expected error for %+v", p)
+		}
+	}
+	if err := (Policy{MaxAge: time.Hour, MaxCount: 10, KeepFailed: 3}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type memStore struct {
+	engines map[string]*v1.EngineStatus
+	failOn  string
+}
+
+func (s *memStore) Find(ctx context.Context, filter []*v1.FilterExpression, order []*v1.OrderExpression, start, limit int) ([]*v1.EngineStatus, int, error) {
+	var res []*v1.EngineStatus
+	for _, e := range s.engines {
+		if phaseName(e.Phase) == filter[0].Terms[0].Value {
+			res = append(res, e)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	total := len(res)
+	if start > len(res) {
+		start = len(res)
+	}
+	res = res[start:]
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, total, nil
+}
+
+func (s *memStore) Store(ctx context.Context, status *v1.EngineStatus) error {
+	s.engines[status.Name] = status
+	return nil
+}
+
+func (s *memStore) Delete(ctx context.Context, name string) error {
+	if name == s.failOn {
+		return errors.New("cannot delete")
+	}
+	delete(s.engines, name)
+	return nil
+}
+
+func TestCollect(t *testing.T) {
+	store := &memStore{
+		engines: map[string]*v1.EngineStatus{
+			"old":     engine("old", "a", "alice", v1.EnginePhase_PHASE_DONE, true, 48*time.Hour),
+			"broken":  engine("broken", "a", "alice", v1.EnginePhase_PHASE_DONE, true, 72*time.Hour),
+			"new":     engine("new", "a", "alice", v1.EnginePhase_PHASE_DONE, true, time.Hour),
+			"running": engine("running", "a", "alice", v1.EnginePhase_PHASE_RUNNING, true, 96*time.Hour),
+		},
+		failOn: "broken",
+	}
+	var (
+		updates []string
+		logs    []string
+	)
+	c := &Collector{
+		Policy:   Policy{MaxAge: 24 * time.Hour},
+		Engines:  store,
+		Logs:     RemoverFunc(func(ctx context.Context, name string) error { logs = append(logs, name); return nil }),
+		OnUpdate: func(s *v1.EngineStatus) { updates = append(updates, s.Name) },
+		Now:      func() time.Time { return now },
+	}
+
+	report, err := c.GetRetentionReport(context.Background(), &v1.GetRetentionReportRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Candidates) != 2 || len(store.engines) != 4 {
+		t.Fatalf("dry run: expected two candidates and no removal, got %v", report.Candidates)
+	}
+
+	removed, err := c.Collect(context.Background())
+	if err == nil {
+		t.Fatal("expected error for broken engine")
+	}
+	if act := candidateNames(removed); !reflect.DeepEqual(act, []string{"old"}) {
+		t.Errorf("expected old to be removed, got %v", act)
+	}
+	if !reflect.DeepEqual(updates, []string{"broken", "old"}) {
+		t.Errorf("expected updates for broken and old, got %v", updates)
+	}
+	if !reflect.DeepEqual(logs, []string{"broken", "old"}) {
+		t.Errorf("expected logs of broken and old to be removed, got %v", logs)
+	}
+	broken := store.engines["broken"]
+	if broken == nil || broken.Phase != v1.EnginePhase_PHASE_CLEANUP {
+		t.Fatalf("expected broken engine to remain in cleanup phase, got %v", broken)
+	}
+
+	// the next collection retries the engine left in cleanup phase, even if the policy changed
+	store.failOn = ""
+	c.SetPolicy(Policy{MaxAge: 100 * time.Hour})
+	removed, err = c.Collect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := candidateNames(removed); !reflect.DeepEqual(act, []string{"broken"}) {
+		t.Errorf("expected broken to be removed, got %v", act)
+	}
+	if _, exists := store.engines["running"]; !exists {
+		t.Error("running engine must not be removed")
+	}
+}
+
+func TestDirRemover(t *testing.T) {
+	dir := t.TempDir()
+	for _, fn := range []string{"foo/workspace/file", "foo.tar.gz", "foo.tgz", "foobar.tar.gz", "foo.1/workspace/file", "foo.1.tar.gz", "foo.tar.gz.1"} {
+		fn = filepath.Join(dir, fn)
+		if err := os.MkdirAll(filepath.Dir(fn), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fn, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := DirRemover(dir).Remove(context.Background(), "foo"); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var remaining []string
+	for _, e := range entries {
+		remaining = append(remaining, e.Name())
+	}
+	if exp := []string{"foo.1", "foo.1.tar.gz", "foo.tar.gz.1", "foobar.tar.gz"}; !reflect.DeepEqual(remaining, exp) {
+		t.Errorf("expected %v to remain, got %v", exp, remaining)
+	}
+
+	if err := DirRemover(dir).Remove(context.Background(), "../foo"); err == nil {
+		t.Error("expected error for name with path separator")
+	}
+}
@@ -0,0 +1,329 @@
+package retry
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package retry implements retry policies for failed engines. Each retry increments the
+// engine's failure count and reschedules it using the waiting phase.
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// AnnotationAttemptPrefix prefixes the annotations which record the attempt history of an engine,
+// e.g. text.bhojpur.net/attempt-1 for the first failed attempt.
+const AnnotationAttemptPrefix = "text.bhojpur.net/attempt-"
+
+// Default backoff settings used when a policy leaves them unset
+const (
+	DefaultInitialBackoff = 10 * time.Second
+	DefaultMaxBackoff     = 10 * time.Minute
+	DefaultMultiplier     = 2.0
+)
+
+// Duration is a time.Duration which reads and writes as string, e.g. 1m30s
+type Duration time.Duration
+
+// MarshalJSON marshals the duration as string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON unmarshals the duration from a string like 1m30s
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like 1m30s: %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Policy determines if and when a failed engine is retried
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Values below two disable retries.
+	MaxAttempts int `json:"maxAttempts,omitempty"`
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff Duration `json:"initialBackoff,omitempty"`
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff Duration `json:"maxBackoff,omitempty"`
+	// Multiplier grows the delay with every attempt
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// Jitter is the fraction by which a delay is randomly shortened, e.g. 0.2 for up to 20%
+	Jitter float64 `json:"jitter,omitempty"`
+	// RetryOnExitCodes limits retries to failures with one of these exit codes
+	RetryOnExitCodes []int `json:"retryOnExitCodes,omitempty"`
+	// RetryOnSlices limits retries to failures of log slices matching one of these glob patterns
+	RetryOnSlices []string `json:"retryOnSlices,omitempty"`
+}
+
+// Validate checks if the policy is sound
+func (p Policy) Validate() error {
+	if p.MaxAttempts < 0 {
+		return fmt.Errorf("max attempts must not be negative")
+	}
+	if p.InitialBackoff < 0 || p.MaxBackoff < 0 {
+		return fmt.Errorf("backoff must not be negative")
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return fmt.Errorf("multiplier must be at least 1")
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+	for _, s := range p.RetryOnSlices {
+		if _, err := path.Match(s, ""); err != nil {
+			return fmt.Errorf("invalid slice pattern %q: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// Failure describes why an engine attempt failed
+type Failure struct {
+	// ExitCode is the exit code of the engine, zero if unknown
+	ExitCode int
+	// Slices are the names of the log slices which failed
+	Slices []string
+	// Message describes the failure
+	Message string
+	// Stopped is true if the engine was stopped on purpose. Stopped engines are never retried.
+	Stopped bool
+}
+
+// Retryable returns true if the policy permits a retry for this kind of failure, regardless of the attempt
+func (p Policy) Retryable(f Failure) bool {
+	if f.Stopped {
+		return false
+	}
+	if len(p.RetryOnExitCodes) == 0 && len(p.RetryOnSlices) == 0 {
+		return true
+	}
+	for _, c := range p.RetryOnExitCodes {
+		if f.ExitCode != 0 && c == f.ExitCode {
+			return true
+		}
+	}
+	for _, pattern := range p.RetryOnSlices {
+		for _, s := range f.Slices {
+			if ok, _ := path.Match(pattern, s); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Backoff returns the delay before the given retry (starting at 1) without jitter
+func (p Policy) Backoff(retry int) time.Duration {
+	var (
+		initial = time.Duration(p.InitialBackoff)
+		max     = time.Duration(p.MaxBackoff)
+		mult    = p.Multiplier
+	)
+	if initial == 0 {
+		initial = DefaultInitialBackoff
+	}
+	if max == 0 {
+		max = DefaultMaxBackoff
+	}
+	if mult == 0 {
+		mult = DefaultMultiplier
+	}
+	if retry < 1 {
+		retry = 1
+	}
+
+	d := float64(initial) * math.Pow(mult, float64(retry-1))
+	if d > float64(max) || math.IsInf(d, 0) {
+		return max
+	}
+	return time.Duration(d)
+}
+
+// Policies holds the retry policies of all engine specs
+type Policies struct {
+	// Default applies to specs without a policy of their own
+	Default Policy `json:"default,omitempty"`
+	// Specs are the policies by engine spec name
+	Specs map[string]Policy `json:"specs,omitempty"`
+}
+
+// Validate checks if all policies are sound
+func (ps Policies) Validate() error {
+	if err := ps.Default.Validate(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	for spec, p := range ps.Specs {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("specs.%s: %w", spec, err)
+		}
+	}
+	return nil
+}
+
+// For returns the policy of an engine spec
+func (ps Policies) For(spec string) Policy {
+	if p, ok := ps.Specs[spec]; ok {
+		return p
+	}
+	return ps.Default
+}
+
+// Attempt records a failed attempt of an engine
+type Attempt struct {
+	Number   int        `json:"attempt"`
+	Failed   time.Time  `json:"failed"`
+	ExitCode int        `json:"exitCode,omitempty"`
+	Slices   []string   `json:"slices,omitempty"`
+	Message  string     `json:"message,omitempty"`
+	Next     *time.Time `json:"next,omitempty"`
+}
+
+// Retrier applies retry policies to failed engines. A Retrier is safe for concurrent use.
+type Retrier struct {
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	mu       sync.Mutex
+	policies Policies
+	rnd      *rand.Rand
+}
+
+// NewRetrier creates a new retrier
+func NewRetrier(policies Policies) *Retrier {
+	return &Retrier{
+		policies: policies,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec // G404: jitter is not security sensitive
+	}
+}
+
+// SetPolicies replaces the retry policies, e.g. when the config is reloaded. Engines which are
+// waiting for a retry keep their schedule.
+func (r *Retrier) SetPolicies(policies Policies) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies = policies
+}
+
+// Policies returns the retry policies in effect
+func (r *Retrier) Policies() Policies {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.policies
+}
+
+// Fail records a failed attempt of the engine and increments its failure count. If the engine's
+// retry policy permits another attempt, the engine is moved to the waiting phase until the next
+// attempt is due and Fail returns true. Otherwise the engine is left as it is for the caller to
+// finish it.
+func (r *Retrier) Fail(status *v1.EngineStatus, f Failure) (retry bool) {
+	if status.Conditions == nil {
+		status.Conditions = &v1.EngineConditions{}
+	}
+	if status.Metadata == nil {
+		status.Metadata = &v1.EngineMetadata{}
+	}
+
+	var (
+		policy  = r.Policies().For(status.Metadata.EngineSpecName)
+		now     = r.now()
+		attempt = int(status.Conditions.FailureCount) + 1
+	)
+	status.Conditions.FailureCount = int32(attempt)
+	retry = attempt < policy.MaxAttempts && policy.Retryable(f)
+
+	rec := Attempt{
+		Number:   attempt,
+		Failed:   now.UTC(),
+		ExitCode: f.ExitCode,
+		Slices:   f.Slices,
+		Message:  f.Message,
+	}
+	if retry {
+		next := now.Add(r.jitter(policy.Backoff(attempt), policy.Jitter)).UTC()
+		rec.Next = &next
+
+		status.Phase = v1.EnginePhase_PHASE_WAITING
+		status.Conditions.WaitUntil = timestamppb.New(next)
+		status.Details = fmt.Sprintf("attempt %d of %d failed, retrying at %s", attempt, policy.MaxAttempts, next.Format(time.RFC3339))
+	}
+
+	// marshalling a struct of strings, ints and times cannot fail
+	val, _ := json.Marshal(rec)
+	status.Metadata.Annotations = append(status.Metadata.Annotations, &v1.Annotation{
+		Key:   AnnotationAttemptPrefix + strconv.Itoa(attempt),
+		Value: string(val),
+	})
+	return retry
+}
+
+func (r *Retrier) jitter(d time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return d
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rnd == nil {
+		r.rnd = rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec // G404: jitter is not security sensitive
+	}
+	return d - time.Duration(jitter*r.rnd.Float64()*float64(d))
+}
+
+func (r *Retrier) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// History returns the failed attempts recorded in an engine's annotations, oldest first
+func History(status *v1.EngineStatus) ([]Attempt, error) {
+	var res []Attempt
+	for _, a := range status.Metadata.GetAnnotations() {
+		if !strings.HasPrefix(a.Key, AnnotationAttemptPrefix) {
+			continue
+		}
+		var att Attempt
+		if err := json.Unmarshal([]byte(a.Value), &att); err != nil {
+			return nil, fmt.Errorf("invalid attempt annotation %s: %w", a.Key, err)
+		}
+		res = append(res, att)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Number < res[j].Number })
+	return res, nil
+}
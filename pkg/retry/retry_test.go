@@ -0,0 +1,188 @@
+package retry
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+)
+
+func TestBackoff(t *testing.T) {
+	p := Policy{InitialBackoff: Duration(time.Second), MaxBackoff: Duration(10 * time.Second), Multiplier: 3}
+	tests := []struct {
+		Retry       int
+		Expectation time.Duration
+	}{
+		{0, time.Second},
+		{1, time.Second},
+		{2, 3 * time.Second},
+		{3, 9 * time.Second},
+		{4, 10 * time.Second},
+		{1000, 10 * time.Second},
+	}
+	for _, test := range tests {
+		if act := p.Backoff(test.Retry); act != test.Expectation {
+			t.Errorf("Backoff(%d) = %s, expected %s", test.Retry, act, test.Expectation)
+		}
+	}
+
+	if act := (Policy{}).Backoff(2); act != 2*DefaultInitialBackoff {
+		t.Errorf("expected default backoff, got %s", act)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Policy      Policy
+		Failure     Failure
+		Expectation bool
+	}{
+		{Name: "any failure", Policy: Policy{}, Failure: Failure{ExitCode: 1}, Expectation: true},
+		{Name: "stopped", Policy: Policy{}, Failure: Failure{Stopped: true}, Expectation: false},
+		{Name: "exit code matches", Policy: Policy{RetryOnExitCodes: []int{3, 4}}, Failure: Failure{ExitCode: 4}, Expectation: true},
+		{Name: "exit code differs", Policy: Policy{RetryOnExitCodes: []int{3}}, Failure: Failure{ExitCode: 1}, Expectation: false},
+		{Name: "slice matches", Policy: Policy{RetryOnSlices: []string{"fetch-*"}}, Failure: Failure{Slices: []string{"index", "fetch-docs"}}, Expectation: true},
+		{Name: "slice differs", Policy: Policy{RetryOnSlices: []string{"fetch-*"}}, Failure: Failure{Slices: []string{"index"}}, Expectation: false},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			if act := test.Policy.Retryable(test.Failure); act != test.Expectation {
+				t.Errorf("expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+}
+
+func TestFail(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRetrier(Policies{
+		Specs: map[string]Policy{
+			"index": {MaxAttempts: 3, InitialBackoff: Duration(time.Minute), Jitter: 0.5},
+		},
+	})
+	r.Now = func() time.Time { return now }
+
+	status := &v1.EngineStatus{
+		Name:     "index-1",
+		Phase:    v1.EnginePhase_PHASE_RUNNING,
+		Metadata: &v1.EngineMetadata{EngineSpecName: "index"},
+	}
+	for attempt := 1; attempt <= 3; attempt++ {
+		status.Phase = v1.EnginePhase_PHASE_RUNNING
+		retry := r.Fail(status, Failure{ExitCode: 2, Message: "boom"})
+		if retry != (attempt < 3) {
+			t.Fatalf("attempt %d: expected retry %v", attempt, attempt < 3)
+		}
+		if status.Conditions.FailureCount != int32(attempt) {
+			t.Errorf("attempt %d: expected failure count %d, got %d", attempt, attempt, status.Conditions.FailureCount)
+		}
+		if !retry {
+			if status.Phase != v1.EnginePhase_PHASE_RUNNING {
+				t.Errorf("expected phase to be left alone on last attempt, got %v", status.Phase)
+			}
+			continue
+		}
+
+		if status.Phase != v1.EnginePhase_PHASE_WAITING {
+			t.Errorf("attempt %d: expected waiting phase, got %v", attempt, status.Phase)
+		}
+		backoff := time.Duration(attempt) * time.Minute
+		delay := status.Conditions.WaitUntil.AsTime().Sub(now)
+		if delay > backoff || delay < backoff/2 {
+			t.Errorf("attempt %d: expected delay between %s and %s, got %s", attempt, backoff/2, backoff, delay)
+		}
+	}
+
+	history, err := History(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected three attempts, got %v", history)
+	}
+	if history[0].Number != 1 || history[0].ExitCode != 2 || history[0].Message != "boom" || history[0].Next == nil {
+		t.Errorf("unexpected first attempt %+v", history[0])
+	}
+	if history[2].Next != nil {
+		t.Errorf("last attempt must not have a next attempt, got %v", history[2].Next)
+	}
+}
+
+func TestFailWithoutPolicy(t *testing.T) {
+	r := NewRetrier(Policies{})
+	status := &v1.EngineStatus{Name: "foo"}
+	if r.Fail(status, Failure{ExitCode: 1}) {
+		t.Error("expected no retry without policy")
+	}
+	if status.Conditions.FailureCount != 1 {
+		t.Errorf("expected failure count 1, got %d", status.Conditions.FailureCount)
+	}
+}
+
+func TestPolicyJSON(t *testing.T) {
+	var p Policies
+	err := json.Unmarshal([]byte(`{"default":{"maxAttempts":2},"specs":{"index":{"maxAttempts":5,"initialBackoff":"30s","maxBackoff":"1h"}}}`), &p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := p.For("index")
+	if idx.MaxAttempts != 5 || time.Duration(idx.InitialBackoff) != 30*time.Second || time.Duration(idx.MaxBackoff) != time.Hour {
+		t.Errorf("unexpected policy %+v", idx)
+	}
+	if p.For("other").MaxAttempts != 2 {
+		t.Errorf("expected default policy for other spec")
+	}
+
+	if err := json.Unmarshal([]byte(`{"initialBackoff":30}`), &Policy{}); err == nil {
+		t.Error("expected error for numeric duration")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, p := range []Policy{{MaxAttempts: -1}, {Multiplier: 0.5}, {Jitter: 2}, {RetryOnSlices: []string{"["}}} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("expected error for %+v", p)
+		}
+	}
+
+	ps := Policies{Default: Policy{MaxAttempts: 3}, Specs: map[string]Policy{"index": {Jitter: 2}}}
+	if err := ps.Validate(); err == nil || !strings.Contains(err.Error(), "specs.index") {
+		t.Errorf("expected error for the policy of spec index, got %v", err)
+	}
+}
+
+func TestSetPolicies(t *testing.T) {
+	r := NewRetrier(Policies{})
+	status := &v1.EngineStatus{Name: "e1", Metadata: &v1.EngineMetadata{EngineSpecName: "index"}}
+	if r.Fail(status, Failure{ExitCode: 1}) {
+		t.Fatal("expected no retry without a policy")
+	}
+
+	r.SetPolicies(Policies{Specs: map[string]Policy{"index": {MaxAttempts: 3}}})
+	if !r.Fail(status, Failure{ExitCode: 1}) {
+		t.Error("expected a retry once the spec has a policy")
+	}
+}
@@ -0,0 +1,423 @@
+package schedule
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package schedule starts engines on cron schedules, catching up on runs missed while the
+// server was down according to each schedule's catch-up policy.
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+// AnnotationScheduledAt records the time a scheduled engine was due, in RFC3339 format
+const AnnotationScheduledAt = "text.bhojpur.net/scheduled-at"
+
+// AnnotationSchedule names the schedule which started an engine
+const AnnotationSchedule = "text.bhojpur.net/schedule"
+
+// CatchUpPolicy determines what happens to runs which were missed, e.g. because the server was down
+type CatchUpPolicy string
+
+const (
+	// CatchUpSkip drops missed runs
+	CatchUpSkip CatchUpPolicy = "skip"
+	// CatchUpRunOnce starts a single engine for all missed runs
+	CatchUpRunOnce CatchUpPolicy = "run-once"
+	// CatchUpRunAll starts an engine for every missed run, up to MaxCatchUp
+	CatchUpRunAll CatchUpPolicy = "run-all"
+)
+
+// Defaults used if the scheduler leaves them unset
+const (
+	// DefaultInterval is the time between two checks for due runs
+	DefaultInterval = 15 * time.Second
+	// DefaultGrace is the time after which a due run counts as missed
+	DefaultGrace = 1 * time.Minute
+	// MaxCatchUp limits the number of runs started when catching up
+	MaxCatchUp = 100
+)
+
+// Schedule starts an engine spec on a cron schedule
+type Schedule struct {
+	// Name identifies the schedule. Defaults to the spec name.
+	Name string `json:"name,omitempty"`
+	// Spec is the engine spec to start
+	Spec string `json:"spec"`
+	// Cron is a standard cron expression, e.g. "0 3 * * *", or a descriptor like "@daily"
+	Cron string `json:"cron"`
+	// Timezone is the IANA timezone the cron expression refers to, e.g. Europe/Berlin. Defaults to UTC.
+	Timezone string `json:"timezone,omitempty"`
+	// CatchUp determines how runs missed during downtime are handled. Defaults to skip.
+	CatchUp CatchUpPolicy `json:"catchUp,omitempty"`
+	// Annotations are added to the engines the schedule starts
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ID returns the name of the schedule, or its spec if it has no name
+func (s Schedule) ID() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Spec
+}
+
+// Parse validates the schedule and parses its cron expression
+func (s Schedule) Parse() (cron.Schedule, error) {
+	if s.Spec == "" {
+		return nil, fmt.Errorf("schedule %s: spec is required", s.ID())
+	}
+	switch s.CatchUp {
+	case "", CatchUpSkip, CatchUpRunOnce, CatchUpRunAll:
+	default:
+		return nil, fmt.Errorf("schedule %s: invalid catch-up policy %q: expected %s, %s or %s", s.ID(), s.CatchUp, CatchUpSkip, CatchUpRunOnce, CatchUpRunAll)
+	}
+	if strings.HasPrefix(s.Cron, "TZ=") || strings.HasPrefix(s.Cron, "CRON_TZ=") {
+		return nil, fmt.Errorf("schedule %s: use the timezone field instead of TZ= in the cron expression", s.ID())
+	}
+
+	expr := s.Cron
+	tz := s.Timezone
+	if tz == "" {
+		tz = "UTC"
+	}
+	if _, err := time.LoadLocation(tz); err != nil {
+		return nil, fmt.Errorf("schedule %s: invalid timezone %q: %w", s.ID(), s.Timezone, err)
+	}
+	sched, err := cron.ParseStandard("CRON_TZ=" + tz + " " + expr)
+	if err != nil {
+		return nil, fmt.Errorf("schedule %s: invalid cron expression %q: %w", s.ID(), s.Cron, err)
+	}
+	return sched, nil
+}
+
+// Due returns the times at which the schedule should start an engine, given the time of the last
+// check and now. Runs which were due more than grace ago count as missed and are handled according
+// to the catch-up policy.
+func Due(sched cron.Schedule, policy CatchUpPolicy, last, now time.Time, grace time.Duration) []time.Time {
+	var (
+		onTime []time.Time
+		missed []time.Time
+	)
+	for t := sched.Next(last); !t.After(now); t = sched.Next(t) {
+		if now.Sub(t) > grace {
+			missed = append(missed, t)
+		} else {
+			onTime = append(onTime, t)
+		}
+		if len(missed) > MaxCatchUp {
+			// only the most recent missed runs are caught up on
+			missed = missed[1:]
+		}
+	}
+
+	switch policy {
+	case CatchUpRunAll:
+		return append(missed, onTime...)
+	case CatchUpRunOnce:
+		if len(missed) > 0 && len(onTime) == 0 {
+			return []time.Time{missed[len(missed)-1]}
+		}
+		return onTime
+	default:
+		return onTime
+	}
+}
+
+// Starter starts the engine of a schedule
+type Starter interface {
+	StartScheduled(ctx context.Context, spec string, md *v1.EngineMetadata) error
+}
+
+// StarterFunc turns a function into a Starter
+type StarterFunc func(ctx context.Context, spec string, md *v1.EngineMetadata) error
+
+// StartScheduled calls f
+func (f StarterFunc) StartScheduled(ctx context.Context, spec string, md *v1.EngineMetadata) error {
+	return f(ctx, spec, md)
+}
+
+// State remembers up to when each schedule was processed, so that missed runs can be detected after a restart
+type State interface {
+	LastRun(schedule string) (t time.Time, ok bool, err error)
+	SetLastRun(schedule string, t time.Time) error
+}
+
+// Scheduler starts engines on their schedules
+type Scheduler struct {
+	Starter Starter
+	State   State
+	// Interval is the time between two checks for due runs. Defaults to DefaultInterval.
+	Interval time.Duration
+	// Grace is the time after which a due run counts as missed. Defaults to DefaultGrace.
+	Grace time.Duration
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+
+	mu        sync.Mutex
+	schedules []Schedule
+	parsed    []cron.Schedule
+}
+
+// NewScheduler creates a new scheduler
+func NewScheduler(schedules []Schedule, starter Starter, state State) (*Scheduler, error) {
+	s := &Scheduler{Starter: starter, State: state}
+	if err := s.SetSchedules(schedules); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Validate checks that all schedules are valid and have distinct IDs
+func Validate(schedules []Schedule) error {
+	_, err := parseAll(schedules)
+	return err
+}
+
+// CheckSpecs checks that the specs of all schedules exist. Validate can't check them, because
+// the specs are only known to the server.
+func CheckSpecs(schedules []Schedule, exists func(spec string) bool) error {
+	for _, sched := range schedules {
+		if !exists(sched.Spec) {
+			return fmt.Errorf("schedule %s: spec %s does not exist", sched.ID(), sched.Spec)
+		}
+	}
+	return nil
+}
+
+func parseAll(schedules []Schedule) ([]cron.Schedule, error) {
+	parsed := make([]cron.Schedule, len(schedules))
+	ids := make(map[string]struct{}, len(schedules))
+	for i, sched := range schedules {
+		p, err := sched.Parse()
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := ids[sched.ID()]; exists {
+			return nil, fmt.Errorf("duplicate schedule %s", sched.ID())
+		}
+		ids[sched.ID()] = struct{}{}
+		parsed[i] = p
+	}
+	return parsed, nil
+}
+
+// SetSchedules replaces all schedules
+func (s *Scheduler) SetSchedules(schedules []Schedule) error {
+	parsed, err := parseAll(schedules)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedules = schedules
+	s.parsed = parsed
+	return nil
+}
+
+// Run checks for due runs every interval until the context is canceled
+func (s *Scheduler) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		s.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Check starts the engines of all schedules which are due. Schedules which were never checked
+// before start with their next run, i.e. they don't catch up on runs before their creation.
+func (s *Scheduler) Check(ctx context.Context) {
+	s.mu.Lock()
+	schedules, parsed := s.schedules, s.parsed
+	s.mu.Unlock()
+
+	grace := s.Grace
+	if grace == 0 {
+		grace = DefaultGrace
+	}
+	now := s.now()
+	for i, sched := range schedules {
+		log := log.WithField("schedule", sched.ID())
+
+		last, ok, err := s.State.LastRun(sched.ID())
+		if err != nil {
+			log.WithError(err).Warn("cannot load schedule state")
+			continue
+		}
+		if !ok {
+			if err := s.State.SetLastRun(sched.ID(), now); err != nil {
+				log.WithError(err).Warn("cannot save schedule state")
+			}
+			continue
+		}
+
+		processed := now
+		for _, due := range Due(parsed[i], sched.CatchUp, last, now, grace) {
+			err := s.Starter.StartScheduled(ctx, sched.Spec, metadata(sched, due))
+			if err != nil {
+				// we'll try again with the next check
+				log.WithError(err).WithField("due", due).Warn("cannot start scheduled engine")
+				processed = last
+				break
+			}
+			log.WithField("due", due).Info("started scheduled engine")
+			last = due
+		}
+		if err := s.State.SetLastRun(sched.ID(), processed); err != nil {
+			log.WithError(err).Warn("cannot save schedule state")
+		}
+	}
+}
+
+func (s *Scheduler) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func metadata(sched Schedule, due time.Time) *v1.EngineMetadata {
+	md := &v1.EngineMetadata{
+		Trigger:        v1.EngineTrigger_TRIGGER_SCHEDULED,
+		EngineSpecName: sched.Spec,
+		Annotations: []*v1.Annotation{
+			{Key: AnnotationSchedule, Value: sched.ID()},
+			{Key: AnnotationScheduledAt, Value: due.UTC().Format(time.RFC3339)},
+		},
+	}
+	keys := make([]string, 0, len(sched.Annotations))
+	for k := range sched.Annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		md.Annotations = append(md.Annotations, &v1.Annotation{Key: k, Value: sched.Annotations[k]})
+	}
+	return md
+}
+
+// MemoryState keeps the schedule state in memory. Missed runs are only detected while the process lives.
+type MemoryState struct {
+	mu   sync.Mutex
+	runs map[string]time.Time
+}
+
+// LastRun returns the time up to which a schedule was processed
+func (m *MemoryState) LastRun(schedule string) (time.Time, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, ok := m.runs[schedule]
+	return t, ok, nil
+}
+
+// SetLastRun stores the time up to which a schedule was processed
+func (m *MemoryState) SetLastRun(schedule string, t time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.runs == nil {
+		m.runs = make(map[string]time.Time)
+	}
+	m.runs[schedule] = t
+	return nil
+}
+
+// FileState keeps the schedule state in a JSON file
+type FileState struct {
+	Filename string
+
+	mu sync.Mutex
+}
+
+// LastRun returns the time up to which a schedule was processed
+func (f *FileState) LastRun(schedule string) (time.Time, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	runs, err := f.load()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	t, ok := runs[schedule]
+	return t, ok, nil
+}
+
+// SetLastRun stores the time up to which a schedule was processed
+func (f *FileState) SetLastRun(schedule string, t time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	runs, err := f.load()
+	if err != nil {
+		return err
+	}
+	runs[schedule] = t
+
+	fc, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := f.Filename + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(f.Filename), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(tmp, fc, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.Filename)
+}
+
+func (f *FileState) load() (map[string]time.Time, error) {
+	runs := make(map[string]time.Time)
+	fc, err := os.ReadFile(f.Filename)
+	if os.IsNotExist(err) {
+		return runs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(fc, &runs); err != nil {
+		return nil, fmt.Errorf("cannot read schedule state %s: %w", f.Filename, err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,191 @@
+package schedule
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+)
+
+func mustParse(t *testing.T, s Schedule) Schedule {
+	t.Helper()
+	if _, err := s.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestParse(t *testing.T) {
+	valid := []Schedule{
+		{Spec: "index", Cron: "0 3 * * *"},
+		{Spec: "index", Cron: "@daily", Timezone: "Asia/Kolkata"},
+		{Spec: "index", Cron: "*/5 * * * *", CatchUp: CatchUpRunAll},
+	}
+	for _, s := range valid {
+		if _, err := s.Parse(); err != nil {
+			t.Errorf("unexpected error for %+v: %v", s, err)
+		}
+	}
+
+	invalid := []Schedule{
+		{Cron: "0 3 * * *"},
+		{Spec: "index", Cron: "0 3 * *"},
+		{Spec: "index", Cron: "0 3 * * *", Timezone: "Mars/Olympus"},
+		{Spec: "index", Cron: "CRON_TZ=UTC 0 3 * * *"},
+		{Spec: "index", Cron: "0 3 * * *", CatchUp: "sometimes"},
+	}
+	for _, s := range invalid {
+		if _, err := s.Parse(); err == nil {
+			t.Errorf("expected error for %+v", s)
+		}
+	}
+}
+
+func TestCheckSpecs(t *testing.T) {
+	exists := func(spec string) bool { return spec == "index" }
+	if err := CheckSpecs([]Schedule{{Spec: "index", Cron: "@daily"}}, exists); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := CheckSpecs([]Schedule{{Name: "nightly", Spec: "reindex", Cron: "@daily"}}, exists); err == nil {
+		t.Error("expected error for a schedule of an unknown spec")
+	}
+}
+
+func TestTimezone(t *testing.T) {
+	sched, err := Schedule{Spec: "index", Cron: "0 3 * * *", Timezone: "Asia/Kolkata"}.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := sched.Next(time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC))
+	// 03:00 IST is 21:30 UTC of the previous day
+	if exp := time.Date(2022, 3, 1, 21, 30, 0, 0, time.UTC); !next.Equal(exp) {
+		t.Errorf("expected %v, got %v", exp, next.UTC())
+	}
+}
+
+func TestDue(t *testing.T) {
+	sched, err := Schedule{Spec: "index", Cron: "0 * * * *"}.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(h, m int) time.Time { return time.Date(2022, 3, 1, h, m, 0, 0, time.UTC) }
+
+	tests := []struct {
+		Name        string
+		Policy      CatchUpPolicy
+		Last, Now   time.Time
+		Expectation []time.Time
+	}{
+		{Name: "nothing due", Last: at(1, 1), Now: at(1, 30)},
+		{Name: "on time", Last: at(1, 59), Now: at(2, 0), Expectation: []time.Time{at(2, 0)}},
+		{Name: "skip", Policy: CatchUpSkip, Last: at(1, 59), Now: at(5, 30)},
+		{Name: "skip keeps on time run", Policy: CatchUpSkip, Last: at(1, 59), Now: at(5, 0), Expectation: []time.Time{at(5, 0)}},
+		{Name: "run once", Policy: CatchUpRunOnce, Last: at(1, 59), Now: at(5, 30), Expectation: []time.Time{at(5, 0)}},
+		{Name: "run once with on time run", Policy: CatchUpRunOnce, Last: at(1, 59), Now: at(5, 0), Expectation: []time.Time{at(5, 0)}},
+		{Name: "run all", Policy: CatchUpRunAll, Last: at(1, 59), Now: at(5, 30), Expectation: []time.Time{at(2, 0), at(3, 0), at(4, 0), at(5, 0)}},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			act := Due(sched, test.Policy, test.Last, test.Now, time.Minute)
+			if len(act) == 0 && len(test.Expectation) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(act, test.Expectation) {
+				t.Errorf("expected %v, got %v", test.Expectation, act)
+			}
+		})
+	}
+
+	every, err := Schedule{Spec: "index", Cron: "* * * * *", CatchUp: CatchUpRunAll}.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := Due(every, CatchUpRunAll, at(0, 0), at(23, 0), 30*time.Second); len(act) != MaxCatchUp+1 {
+		t.Errorf("expected catch-up to be limited to %d runs plus the on time run, got %d", MaxCatchUp, len(act))
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	var (
+		now     = time.Date(2022, 3, 1, 1, 30, 0, 0, time.UTC)
+		started []*v1.EngineMetadata
+		fail    bool
+	)
+	starter := StarterFunc(func(ctx context.Context, spec string, md *v1.EngineMetadata) error {
+		if fail {
+			return errors.New("cannot start")
+		}
+		started = append(started, md)
+		return nil
+	})
+	state := &FileState{Filename: filepath.Join(t.TempDir(), "state.json")}
+	s, err := NewScheduler([]Schedule{
+		mustParse(t, Schedule{Name: "hourly-index", Spec: "index", Cron: "0 * * * *", CatchUp: CatchUpRunAll, Annotations: map[string]string{"lang": "en"}}),
+	}, starter, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Now = func() time.Time { return now }
+
+	// the first check only records the state
+	s.Check(context.Background())
+	if len(started) != 0 {
+		t.Fatalf("expected no engine on first check, got %d", len(started))
+	}
+
+	now = now.Add(time.Hour)
+	fail = true
+	s.Check(context.Background())
+	fail = false
+	now = now.Add(2 * time.Hour)
+	s.Check(context.Background())
+	if len(started) != 3 {
+		t.Fatalf("expected three engines, got %d", len(started))
+	}
+
+	md := started[0]
+	if md.Trigger != v1.EngineTrigger_TRIGGER_SCHEDULED || md.EngineSpecName != "index" {
+		t.Errorf("unexpected metadata %v", md)
+	}
+	annotations := make(map[string]string)
+	for _, a := range md.Annotations {
+		annotations[a.Key] = a.Value
+	}
+	exp := map[string]string{AnnotationSchedule: "hourly-index", AnnotationScheduledAt: "2022-03-01T02:00:00Z", "lang": "en"}
+	if !reflect.DeepEqual(annotations, exp) {
+		t.Errorf("expected annotations %v, got %v", exp, annotations)
+	}
+
+	last, ok, err := state.LastRun("hourly-index")
+	if err != nil || !ok || !last.Equal(now) {
+		t.Errorf("expected state to be saved as %v, got %v (%v, %v)", now, last, ok, err)
+	}
+
+	if err := s.SetSchedules([]Schedule{{Spec: "a", Cron: "@daily"}, {Spec: "a", Cron: "@hourly"}}); err == nil {
+		t.Error("expected error for duplicate schedules")
+	}
+}
@@ -0,0 +1,66 @@
+package schedule
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// SQLState keeps the schedule state in the schedule_runs table of a PostgreSQL database, so that
+// it survives a change of leader
+type SQLState struct {
+	DB *sql.DB
+}
+
+// Migrate creates the schedule_runs table if it does not exist
+func (s *SQLState) Migrate(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schedule_runs (
+			schedule TEXT PRIMARY KEY,
+			last_run TIMESTAMPTZ NOT NULL
+		);
+	`)
+	return err
+}
+
+// LastRun returns the time up to which a schedule was processed
+func (s *SQLState) LastRun(schedule string) (time.Time, bool, error) {
+	var t time.Time
+	err := s.DB.QueryRow(`SELECT last_run FROM schedule_runs WHERE schedule = $1`, schedule).Scan(&t)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
+
+// SetLastRun stores the time up to which a schedule was processed
+func (s *SQLState) SetLastRun(schedule string, t time.Time) error {
+	_, err := s.DB.Exec(
+		`INSERT INTO schedule_runs (schedule, last_run) VALUES ($1, $2) ON CONFLICT (schedule) DO UPDATE SET last_run = EXCLUDED.last_run`,
+		schedule, t,
+	)
+	return err
+}
@@ -0,0 +1,298 @@
+package serverconfig
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package serverconfig loads and validates the configuration file of the Bhojpur Text server,
+// and reloads it on SIGHUP.
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bhojpur/text/pkg/auth"
+	"github.com/bhojpur/text/pkg/queue"
+	"github.com/bhojpur/text/pkg/retention"
+	"github.com/bhojpur/text/pkg/retry"
+	"github.com/bhojpur/text/pkg/schedule"
+	"github.com/bhojpur/text/pkg/tlsutil"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the server configuration
+type Config struct {
+	Listen   Listen   `json:"listen"`
+	Store    Store    `json:"store"`
+	Executor Executor `json:"executor"`
+	// LeaderElection determines which replica runs the scheduler, retention and cron triggers
+	LeaderElection LeaderElection `json:"leaderElection,omitempty"`
+
+	// TLS configures the transport security of the gRPC server
+	TLS tlsutil.ServerConfig `json:"tls,omitempty"`
+	// Auth configures authentication and authorization. Without any authenticator calls are not authenticated.
+	Auth Auth `json:"auth,omitempty"`
+	// Tracing configures where the server's traces go. Tracing is disabled unless it has an exporter.
+	Tracing Tracing `json:"tracing,omitempty"`
+
+	// Limits are the concurrency limits of engine execution
+	Limits queue.Limits `json:"limits,omitempty"`
+	// Retries determine if and when failed engines are retried, by default and per spec
+	Retries retry.Policies `json:"retries,omitempty"`
+	// Retention determines which finished engines are removed
+	Retention Retention `json:"retention,omitempty"`
+
+	// Schedules start engines on cron schedules. Only the leader runs them.
+	Schedules []schedule.Schedule `json:"schedules,omitempty"`
+	// SpecDir is a directory of engine specs, e.g. index.yaml for the spec index. Engines run the
+	// command of their spec, the dependsOn fields of the specs form the graph pipelines are run from.
+	SpecDir string `json:"specDir,omitempty"`
+}
+
+// Listen configures the addresses the server listens on
+type Listen struct {
+	// GRPC is the address of the gRPC API
+	GRPC string `json:"grpc"`
+	// HTTP is the address of the web UI, metrics and health endpoints
+	HTTP string `json:"http"`
+}
+
+// Store configures the database
+type Store struct {
+	// DSN is the PostgreSQL connection string
+	DSN string `json:"dsn"`
+}
+
+// Executor configures how engines run. The leader runs the commands of the engine specs as
+// processes of its own.
+type Executor struct {
+	// Workdir holds the directories engines run in and their logs
+	Workdir string `json:"workdir"`
+}
+
+// Auth configures authentication and authorization
+type Auth struct {
+	// TokenFile is a file of static bearer tokens
+	TokenFile string `json:"tokenFile,omitempty"`
+	// OIDC validates OIDC ID tokens
+	OIDC *auth.OIDCConfig `json:"oidc,omitempty"`
+	// ClientCert authenticates callers by the common name of their TLS client certificate
+	ClientCert bool `json:"clientCert,omitempty"`
+	// AdminRole is the role which exempts a caller from ownership checks
+	AdminRole string `json:"adminRole,omitempty"`
+}
+
+// Enabled returns true if any authenticator is configured
+func (a Auth) Enabled() bool {
+	return a.TokenFile != "" || a.OIDC != nil || a.ClientCert
+}
+
+// Tracing configures the export of traces
+type Tracing struct {
+	// File is a file the spans are appended to in the OTLP/JSON encoding
+	File string `json:"file,omitempty"`
+}
+
+// Enabled returns true if spans are exported anywhere
+func (t Tracing) Enabled() bool {
+	return t.File != ""
+}
+
+// Retention configures the retention policy
+type Retention struct {
+	MaxAge     Duration `json:"maxAge,omitempty"`
+	MaxCount   int      `json:"maxCount,omitempty"`
+	KeepFailed int      `json:"keepFailed,omitempty"`
+	Interval   Duration `json:"interval,omitempty"`
+}
+
+// Policy returns the retention policy
+func (r Retention) Policy() retention.Policy {
+	return retention.Policy{
+		MaxAge:     time.Duration(r.MaxAge),
+		MaxCount:   r.MaxCount,
+		KeepFailed: r.KeepFailed,
+		Interval:   time.Duration(r.Interval),
+	}
+}
+
+// Redacted returns a copy of the configuration which is safe to print, i.e. without the store password
+func (c *Config) Redacted() *Config {
+	res := *c
+	res.Store.DSN = redactDSN(c.Store.DSN)
+	return &res
+}
+
+// redactDSN masks the password of a PostgreSQL connection string, in URL as well as key=value form
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+		}
+		return u.String()
+	}
+
+	fields := strings.Fields(dsn)
+	for i, f := range fields {
+		if strings.HasPrefix(f, "password=") {
+			fields[i] = "password=xxxxx"
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// Duration is a time.Duration which reads and writes as string, e.g. 1m30s
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Leader election kinds
+const (
+	LeaderElectionNone       = "none"
+	LeaderElectionKubernetes = "kubernetes"
+	LeaderElectionPostgres   = "postgres"
+)
+
+// LeaderElection configures how the replicas of the server elect a leader
+type LeaderElection struct {
+	// Kind is none, kubernetes or postgres. With none the server assumes it is the only replica.
+	Kind string `json:"kind,omitempty"`
+	// Namespace and LeaseName identify the Lease, for kubernetes leader election
+	Namespace string `json:"namespace,omitempty"`
+	LeaseName string `json:"leaseName,omitempty"`
+	// Kubeconfig is the kubeconfig file used for kubernetes leader election. Uses the in-cluster config if empty.
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// LockKey is the advisory lock key, for postgres leader election. Defaults to leader.DefaultLockKey.
+	LockKey int64 `json:"lockKey,omitempty"`
+}
+
+// Default returns the configuration used for settings the config file does not set
+func Default() *Config {
+	return &Config{
+		Listen: Listen{
+			GRPC: ":7777",
+			HTTP: ":8080",
+		},
+		LeaderElection: LeaderElection{
+			Kind:      LeaderElectionNone,
+			LeaseName: "text-server",
+		},
+	}
+}
+
+// Load reads the config file on top of the defaults and applies the environment overrides
+// (see EnvVars). Unknown fields are an error. If fn is empty only defaults and environment apply.
+func Load(fn string) (*Config, error) {
+	res := Default()
+	if fn != "" {
+		fc, err := os.ReadFile(fn)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.UnmarshalStrict(fc, res); err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %w", fn, err)
+		}
+	}
+	if err := res.applyEnv(os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := res.Validate(); err != nil {
+		if fn == "" {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+		return nil, fmt.Errorf("invalid config %s: %w", fn, err)
+	}
+	return res, nil
+}
+
+// Validate checks the configuration for consistency
+func (c *Config) Validate() error {
+	for name, addr := range map[string]string{"listen.grpc": c.Listen.GRPC, "listen.http": c.Listen.HTTP} {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if c.Listen.GRPC == c.Listen.HTTP {
+		return fmt.Errorf("listen.grpc and listen.http must differ")
+	}
+	if c.Store.DSN == "" {
+		return fmt.Errorf("store.dsn is required")
+	}
+
+	if c.Executor.Workdir == "" {
+		return fmt.Errorf("executor.workdir is required")
+	}
+
+	switch c.LeaderElection.Kind {
+	case LeaderElectionNone, LeaderElectionPostgres:
+	case LeaderElectionKubernetes:
+		if c.LeaderElection.Namespace == "" || c.LeaderElection.LeaseName == "" {
+			return fmt.Errorf("leaderElection.namespace and leaderElection.leaseName are required for kubernetes leader election")
+		}
+	default:
+		return fmt.Errorf("leaderElection.kind: unknown kind %q, expected %s, %s or %s", c.LeaderElection.Kind, LeaderElectionNone, LeaderElectionKubernetes, LeaderElectionPostgres)
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("tls.certFile and tls.keyFile must be set together")
+	}
+	if c.Auth.ClientCert && c.TLS.ClientCAFile == "" {
+		return fmt.Errorf("auth.clientCert requires tls.clientCAFile")
+	}
+	if o := c.Auth.OIDC; o != nil && (o.Issuer == "" || o.Audience == "" || o.JWKSFile == "") {
+		return fmt.Errorf("auth.oidc requires issuer, audience and jwksFile")
+	}
+
+	if err := c.Limits.Validate(); err != nil {
+		return fmt.Errorf("limits: %w", err)
+	}
+	if err := c.Retries.Validate(); err != nil {
+		return fmt.Errorf("retries: %w", err)
+	}
+	if err := c.Retention.Policy().Validate(); err != nil {
+		return fmt.Errorf("retention: %w", err)
+	}
+
+	if err := schedule.Validate(c.Schedules); err != nil {
+		return fmt.Errorf("schedules: %w", err)
+	}
+	return nil
+}
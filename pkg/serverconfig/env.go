@@ -0,0 +1,95 @@
+package serverconfig
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EnvVar is an environment variable which overrides a setting of the config file
+type EnvVar struct {
+	Name    string
+	Setting string
+	set     func(c *Config, v string) error
+}
+
+// EnvVars lists the environment variables which override settings of the config file.
+// Environment variables take precedence over the file, command line flags over both.
+var EnvVars = []EnvVar{
+	{"TEXT_LISTEN_GRPC", "listen.grpc", func(c *Config, v string) error { c.Listen.GRPC = v; return nil }},
+	{"TEXT_LISTEN_HTTP", "listen.http", func(c *Config, v string) error { c.Listen.HTTP = v; return nil }},
+	{"TEXT_STORE_DSN", "store.dsn", func(c *Config, v string) error { c.Store.DSN = v; return nil }},
+	{"TEXT_EXECUTOR_WORKDIR", "executor.workdir", func(c *Config, v string) error { c.Executor.Workdir = v; return nil }},
+	{"TEXT_LEADER_ELECTION_KIND", "leaderElection.kind", func(c *Config, v string) error { c.LeaderElection.Kind = v; return nil }},
+	{"TEXT_LEADER_ELECTION_NAMESPACE", "leaderElection.namespace", func(c *Config, v string) error { c.LeaderElection.Namespace = v; return nil }},
+	{"TEXT_LEADER_ELECTION_KUBECONFIG", "leaderElection.kubeconfig", func(c *Config, v string) error { c.LeaderElection.Kubeconfig = v; return nil }},
+	{"TEXT_TLS_CERT_FILE", "tls.certFile", func(c *Config, v string) error { c.TLS.CertFile = v; return nil }},
+	{"TEXT_TLS_KEY_FILE", "tls.keyFile", func(c *Config, v string) error { c.TLS.KeyFile = v; return nil }},
+	{"TEXT_TLS_CLIENT_CA_FILE", "tls.clientCAFile", func(c *Config, v string) error { c.TLS.ClientCAFile = v; return nil }},
+	{"TEXT_AUTH_TOKEN_FILE", "auth.tokenFile", func(c *Config, v string) error { c.Auth.TokenFile = v; return nil }},
+	{"TEXT_AUTH_ADMIN_ROLE", "auth.adminRole", func(c *Config, v string) error { c.Auth.AdminRole = v; return nil }},
+	{"TEXT_TRACING_FILE", "tracing.file", func(c *Config, v string) error { c.Tracing.File = v; return nil }},
+	{"TEXT_LIMITS_GLOBAL", "limits.global", intSetter(func(c *Config, v int) { c.Limits.Global = v })},
+	{"TEXT_LIMITS_PER_OWNER", "limits.perOwner", intSetter(func(c *Config, v int) { c.Limits.PerOwner = v })},
+	{"TEXT_LIMITS_PER_SPEC", "limits.perSpec", intSetter(func(c *Config, v int) { c.Limits.PerSpec = v })},
+	{"TEXT_RETENTION_MAX_AGE", "retention.maxAge", durationSetter(func(c *Config, v Duration) { c.Retention.MaxAge = v })},
+	{"TEXT_RETENTION_MAX_COUNT", "retention.maxCount", intSetter(func(c *Config, v int) { c.Retention.MaxCount = v })},
+	{"TEXT_RETENTION_KEEP_FAILED", "retention.keepFailed", intSetter(func(c *Config, v int) { c.Retention.KeepFailed = v })},
+	{"TEXT_RETENTION_INTERVAL", "retention.interval", durationSetter(func(c *Config, v Duration) { c.Retention.Interval = v })},
+}
+
+func intSetter(f func(c *Config, v int)) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		f(c, i)
+		return nil
+	}
+}
+
+func durationSetter(f func(c *Config, v Duration)) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
+		f(c, Duration(d))
+		return nil
+	}
+}
+
+// applyEnv applies the environment overrides. lookup is os.LookupEnv except in tests.
+func (c *Config) applyEnv(lookup func(string) (string, bool)) error {
+	for _, e := range EnvVars {
+		v, ok := lookup(e.Name)
+		if !ok {
+			continue
+		}
+		if err := e.set(c, v); err != nil {
+			return fmt.Errorf("invalid value of %s: %w", e.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package serverconfig
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// settings lists the top-level settings and whether they can change while the server is running.
+// Changing the others requires a restart, e.g. because listeners or the store connection would have to be re-established.
+var settings = []struct {
+	Name       string
+	Reloadable bool
+	Copy       func(dst, src *Config)
+}{
+	{"listen", false, func(dst, src *Config) { dst.Listen = src.Listen }},
+	{"store", false, func(dst, src *Config) { dst.Store = src.Store }},
+	{"executor", false, func(dst, src *Config) { dst.Executor = src.Executor }},
+	{"leaderElection", false, func(dst, src *Config) { dst.LeaderElection = src.LeaderElection }},
+	{"tls", false, func(dst, src *Config) { dst.TLS = src.TLS }},
+	{"tracing", false, func(dst, src *Config) { dst.Tracing = src.Tracing }},
+	{"auth", true, func(dst, src *Config) { dst.Auth = src.Auth }},
+	{"limits", true, func(dst, src *Config) { dst.Limits = src.Limits }},
+	{"retries", true, func(dst, src *Config) { dst.Retries = src.Retries }},
+	{"retention", true, func(dst, src *Config) { dst.Retention = src.Retention }},
+	{"schedules", true, func(dst, src *Config) { dst.Schedules = src.Schedules }},
+	{"specDir", true, func(dst, src *Config) { dst.SpecDir = src.SpecDir }},
+}
+
+// Diff compares two configurations and returns the names of the changed settings,
+// split into those which can be applied at runtime and those which require a restart
+func Diff(old, new *Config) (reloadable, restart []string) {
+	for _, s := range settings {
+		var a, b Config
+		s.Copy(&a, old)
+		s.Copy(&b, new)
+		if reflect.DeepEqual(a, b) {
+			continue
+		}
+		if s.Reloadable {
+			reloadable = append(reloadable, s.Name)
+		} else {
+			restart = append(restart, s.Name)
+		}
+	}
+	return
+}
+
+// Reloader reloads the config file on SIGHUP and applies the settings which can change at runtime
+type Reloader struct {
+	Path string
+	// Load loads the configuration. Defaults to loading Path with Load. Callers which apply
+	// overrides of their own, e.g. command line flags, must set it so that reloads keep them.
+	Load func() (*Config, error)
+	// OnReload applies the new configuration. If it returns an error the previous configuration stays in effect.
+	OnReload func(cfg *Config, changed []string) error
+
+	mu      sync.Mutex
+	current *Config
+}
+
+// NewReloader creates a reloader for the configuration loaded from path
+func NewReloader(path string, current *Config) *Reloader {
+	return &Reloader{Path: path, current: current}
+}
+
+// Current returns the configuration in effect
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Reload loads the config file and applies the reloadable settings which changed. It returns the
+// names of the applied settings, and of the changed settings which were not applied because they
+// require a restart.
+func (r *Reloader) Reload() (applied, restart []string, err error) {
+	load := r.Load
+	if load == nil {
+		load = func() (*Config, error) { return Load(r.Path) }
+	}
+	cfg, err := load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	applied, restart = Diff(r.current, cfg)
+	if len(applied) == 0 {
+		return nil, restart, nil
+	}
+
+	effective := *r.current
+	for _, s := range settings {
+		if s.Reloadable {
+			s.Copy(&effective, cfg)
+		}
+	}
+	if r.OnReload != nil {
+		if err := r.OnReload(&effective, applied); err != nil {
+			return nil, restart, err
+		}
+	}
+	r.current = &effective
+	return applied, restart, nil
+}
+
+// Run reloads the configuration whenever the process receives SIGHUP, until the context is canceled
+func (r *Reloader) Run(ctx context.Context) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sigs:
+		}
+
+		applied, restart, err := r.Reload()
+		if err != nil {
+			log.WithError(err).WithField("path", r.Path).Error("cannot reload config - keeping current config")
+			continue
+		}
+		if len(restart) > 0 {
+			log.WithField("settings", strings.Join(restart, ", ")).Warn("config changes require a restart and were not applied")
+		}
+		if len(applied) > 0 {
+			log.WithField("settings", strings.Join(applied, ", ")).Info("config reloaded")
+		} else {
+			log.Info("config reloaded - nothing to apply")
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package store
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package store keeps the status of engines. Every change is announced to the replicas of the
+// server through the notify package.
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"github.com/bhojpur/text/pkg/notify"
+	"github.com/bhojpur/text/pkg/pagination"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Store keeps the status of engines. It implements retention.EngineStore.
+type Store interface {
+	// Get returns the status of an engine, or a NotFound error if it does not exist
+	Get(ctx context.Context, name string) (*v1.EngineStatus, error)
+	// Find searches for engines, with the filter and order semantics of ListEngines
+	Find(ctx context.Context, filter []*v1.FilterExpression, order []*v1.OrderExpression, start, limit int) (slice []*v1.EngineStatus, total int, err error)
+	// Store stores an engine status, replacing a previous status of the same engine
+	Store(ctx context.Context, status *v1.EngineStatus) error
+	// Delete removes an engine. Removing an engine which does not exist must not fail.
+	Delete(ctx context.Context, name string) error
+}
+
+// find answers a Find call from all engines
+func find(engines []*v1.EngineStatus, filter []*v1.FilterExpression, order []*v1.OrderExpression, start, limit int) ([]*v1.EngineStatus, int, error) {
+	res, err := pagination.List(engines, &v1.ListEnginesRequest{
+		Filter: filter,
+		Order:  order,
+		Start:  int32(start),
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return res.Result, int(res.Total), nil
+}
+
+func notFound(name string) error {
+	return status.Errorf(codes.NotFound, "engine %s not found", name)
+}
+
+// MemoryStore keeps engines in memory, e.g. for tests
+type MemoryStore struct {
+	mu      sync.RWMutex
+	engines map[string]*v1.EngineStatus
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(ctx context.Context, name string) (*v1.EngineStatus, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.engines[name]
+	if !ok {
+		return nil, notFound(name)
+	}
+	return proto.Clone(e).(*v1.EngineStatus), nil
+}
+
+// Find implements Store
+func (s *MemoryStore) Find(ctx context.Context, filter []*v1.FilterExpression, order []*v1.OrderExpression, start, limit int) ([]*v1.EngineStatus, int, error) {
+	s.mu.RLock()
+	engines := make([]*v1.EngineStatus, 0, len(s.engines))
+	for _, e := range s.engines {
+		engines = append(engines, proto.Clone(e).(*v1.EngineStatus))
+	}
+	s.mu.RUnlock()
+
+	return find(engines, filter, order, start, limit)
+}
+
+// Store implements Store
+func (s *MemoryStore) Store(ctx context.Context, status *v1.EngineStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.engines == nil {
+		s.engines = make(map[string]*v1.EngineStatus)
+	}
+	s.engines[status.Name] = proto.Clone(status).(*v1.EngineStatus)
+	return nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.engines, name)
+	return nil
+}
+
+// SQLStore stores engines in the engines table of a PostgreSQL database. Every change sends a
+// notification (see notify.Notify) once it is committed.
+type SQLStore struct {
+	DB *sql.DB
+}
+
+// Migrate creates the engines table if it does not exist
+func (s *SQLStore) Migrate(ctx context.Context) error {
+	_, err := s.DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS engines (
+			name   TEXT PRIMARY KEY,
+			status BYTEA NOT NULL
+		);
+	`)
+	return err
+}
+
+// Get implements Store
+func (s *SQLStore) Get(ctx context.Context, name string) (*v1.EngineStatus, error) {
+	var raw []byte
+	err := s.DB.QueryRowContext(ctx, `SELECT status FROM engines WHERE name = $1`, name).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return nil, notFound(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res v1.EngineStatus
+	if err := proto.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Find implements Store. Filter expressions cannot be translated to SQL, hence Find loads all
+// engines and filters and orders them like ListEngines does.
+func (s *SQLStore) Find(ctx context.Context, filter []*v1.FilterExpression, order []*v1.OrderExpression, start, limit int) ([]*v1.EngineStatus, int, error) {
+	rows, err := s.DB.QueryContext(ctx, `SELECT status FROM engines`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var engines []*v1.EngineStatus
+	for rows.Next() {
+		var raw []byte
+		if err := rows.Scan(&raw); err != nil {
+			return nil, 0, err
+		}
+		var e v1.EngineStatus
+		if err := proto.Unmarshal(raw, &e); err != nil {
+			return nil, 0, err
+		}
+		engines = append(engines, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	return find(engines, filter, order, start, limit)
+}
+
+// Store implements Store
+func (s *SQLStore) Store(ctx context.Context, status *v1.EngineStatus) error {
+	raw, err := proto.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return s.change(ctx, status.Name,
+		`INSERT INTO engines (name, status) VALUES ($1, $2) ON CONFLICT (name) DO UPDATE SET status = EXCLUDED.status`,
+		status.Name, raw,
+	)
+}
+
+// Delete implements Store
+func (s *SQLStore) Delete(ctx context.Context, name string) error {
+	return s.change(ctx, name, `DELETE FROM engines WHERE name = $1`, name)
+}
+
+// change executes a statement which changes an engine and notifies the replicas in the same transaction
+func (s *SQLStore) change(ctx context.Context, name, query string, args ...interface{}) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	if err := notify.Notify(ctx, tx, name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
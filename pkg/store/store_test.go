@@ -0,0 +1,109 @@
+package store
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := &MemoryStore{}
+	if _, err := s.Get(ctx, "a"); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+
+	for _, e := range []*v1.EngineStatus{
+		{Name: "a", Phase: v1.EnginePhase_PHASE_DONE},
+		{Name: "b", Phase: v1.EnginePhase_PHASE_RUNNING},
+		{Name: "c", Phase: v1.EnginePhase_PHASE_DONE},
+		{Name: "d", Phase: v1.EnginePhase_PHASE_DONE},
+	} {
+		if err := s.Store(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e, err := s.Get(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the store keeps a copy
+	e.Phase = v1.EnginePhase_PHASE_DONE
+	if e, _ := s.Get(ctx, "b"); e.Phase != v1.EnginePhase_PHASE_RUNNING {
+		t.Errorf("expected stored engine to remain unchanged, got %v", e.Phase)
+	}
+
+	tests := []struct {
+		Name          string
+		Filter        []*v1.FilterExpression
+		Start, Limit  int
+		Expectation   []string
+		ExpectedTotal int
+	}{
+		{Name: "all", Expectation: []string{"a", "b", "c", "d"}, ExpectedTotal: 4},
+		{
+			Name:          "filter",
+			Filter:        []*v1.FilterExpression{{Terms: []*v1.FilterTerm{{Field: "phase", Value: "done"}}}},
+			Expectation:   []string{"a", "c", "d"},
+			ExpectedTotal: 3,
+		},
+		{
+			Name:          "page",
+			Filter:        []*v1.FilterExpression{{Terms: []*v1.FilterTerm{{Field: "phase", Value: "done"}}}},
+			Start:         1,
+			Limit:         1,
+			Expectation:   []string{"c"},
+			ExpectedTotal: 3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			slice, total, err := s.Find(ctx, test.Filter, []*v1.OrderExpression{{Field: "name", Ascending: true}}, test.Start, test.Limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, e := range slice {
+				names = append(names, e.Name)
+			}
+			if !reflect.DeepEqual(names, test.Expectation) || total != test.ExpectedTotal {
+				t.Errorf("expected %v of %d, got %v of %d", test.Expectation, test.ExpectedTotal, names, total)
+			}
+		})
+	}
+
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Errorf("deleting a removed engine must not fail: %v", err)
+	}
+	if _, err := s.Get(ctx, "a"); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+}
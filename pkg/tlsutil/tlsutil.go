@@ -0,0 +1,127 @@
+package tlsutil
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// ServerConfig describes the TLS setup of a server
+type ServerConfig struct {
+	// CertFile is the PEM encoded server certificate
+	CertFile string `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+	// KeyFile is the PEM encoded private key of the server certificate
+	KeyFile string `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+	// ClientCAFile is a PEM bundle of CAs used to verify client certificates. If set,
+	// clients presenting a certificate must present one signed by these CAs.
+	ClientCAFile string `json:"clientCAFile,omitempty" yaml:"clientCAFile,omitempty"`
+	// RequireClientCert rejects clients which do not present a certificate
+	RequireClientCert bool `json:"requireClientCert,omitempty" yaml:"requireClientCert,omitempty"`
+}
+
+// Enabled returns true if TLS is configured
+func (c ServerConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// TLSConfig loads the certificates and produces a TLS configuration
+func (c ServerConfig) TLSConfig() (*tls.Config, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, fmt.Errorf("both TLS certificate and key are required")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load TLS certificate: %w", err)
+	}
+
+	res := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.ClientCAFile != "" {
+		res.ClientCAs, err = LoadCertPool(c.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		res.ClientAuth = tls.VerifyClientCertIfGiven
+		if c.RequireClientCert {
+			res.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	} else if c.RequireClientCert {
+		return nil, fmt.Errorf("requiring client certificates needs a client CA")
+	}
+	return res, nil
+}
+
+// ClientConfig describes the TLS setup of a client
+type ClientConfig struct {
+	// CAFile is a PEM bundle of CAs used to verify the server. Defaults to the system roots.
+	CAFile string `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+	// CertFile is the PEM encoded client certificate used for mutual TLS
+	CertFile string `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+	// KeyFile is the PEM encoded private key of the client certificate
+	KeyFile string `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+	// ServerName overrides the name used to verify the server certificate
+	ServerName string `json:"serverName,omitempty" yaml:"serverName,omitempty"`
+}
+
+// TLSConfig loads the certificates and produces a TLS configuration
+func (c ClientConfig) TLSConfig() (*tls.Config, error) {
+	res := &tls.Config{
+		ServerName: c.ServerName,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	var err error
+	if c.CAFile != "" {
+		res.RootCAs, err = LoadCertPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, fmt.Errorf("TLS client certificate and key must be used together")
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load TLS client certificate: %w", err)
+		}
+		res.Certificates = []tls.Certificate{cert}
+	}
+	return res, nil
+}
+
+// LoadCertPool loads a PEM bundle of certificates
+func LoadCertPool(fn string) (*x509.CertPool, error) {
+	fc, err := os.ReadFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read CA file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(fc) {
+		return nil, fmt.Errorf("%s contains no PEM certificates", fn)
+	}
+	return pool, nil
+}
@@ -0,0 +1,160 @@
+package tlsutil
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	Cert *x509.Certificate
+	Key  *ecdsa.PrivateKey
+}
+
+func issue(t *testing.T, name string, parent *testCert, serverName string) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if serverName != "" {
+		tmpl.DNSNames = []string{serverName}
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.Cert, parent.Key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCert{Cert: cert, Key: key}
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) (certFile, keyFile string) {
+	certFile, keyFile = filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kb, err := x509.MarshalECPrivateKey(c.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: kb}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, "ca", nil, "")
+	caFile, _ := ca.write(t, dir, "ca")
+	srvCert, srvKey := issue(t, "server", ca, "text.example.com").write(t, dir, "server")
+	cltCert, cltKey := issue(t, "alice", ca, "").write(t, dir, "client")
+
+	srvCfg, err := ServerConfig{CertFile: srvCert, KeyFile: srvKey, ClientCAFile: caFile, RequireClientCert: true}.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cltCfg, err := ClientConfig{CAFile: caFile, CertFile: cltCert, KeyFile: cltKey, ServerName: "text.example.com"}.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", srvCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	peerCN := make(chan string, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			peerCN <- err.Error()
+			return
+		}
+		defer conn.Close()
+		tc := conn.(*tls.Conn)
+		if err := tc.Handshake(); err != nil {
+			peerCN <- err.Error()
+			return
+		}
+		peerCN <- tc.ConnectionState().VerifiedChains[0][0].Subject.CommonName
+	}()
+
+	conn, err := tls.Dial("tcp", l.Addr().String(), cltCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if cn := <-peerCN; cn != "alice" {
+		t.Errorf("expected client CN alice, got %q", cn)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	if _, err := (ServerConfig{CertFile: "cert.pem"}).TLSConfig(); err == nil {
+		t.Error("expected error for missing key")
+	}
+	if _, err := (ClientConfig{CertFile: "cert.pem"}).TLSConfig(); err == nil {
+		t.Error("expected error for client cert without key")
+	}
+	if _, err := LoadCertPool(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Error("expected error for missing CA file")
+	}
+	if !(ServerConfig{CertFile: "cert.pem"}).Enabled() {
+		t.Error("expected TLS to be enabled")
+	}
+	if (ServerConfig{}).Enabled() {
+		t.Error("expected TLS to be disabled")
+	}
+}
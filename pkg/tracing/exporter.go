@@ -0,0 +1,280 @@
+package tracing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// FileExporter writes spans to a file in the OTLP/JSON encoding, one ExportTraceServiceRequest
+// per line. This is the format the OpenTelemetry Collector's file exporter produces and its
+// otlpjsonfile receiver reads, so traces can be recorded offline and imported later.
+type FileExporter struct {
+	mu  sync.Mutex
+	out *os.File
+	enc *json.Encoder
+}
+
+var _ sdktrace.SpanExporter = &FileExporter{}
+
+// NewFileExporter creates an exporter that appends to the file at path, creating it if necessary
+func NewFileExporter(path string) (*FileExporter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileExporter{out: f, enc: json.NewEncoder(f)}, nil
+}
+
+// ExportSpans writes the spans as a single line to the file
+func (e *FileExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.out == nil {
+		return errors.New("exporter is shut down")
+	}
+	return e.enc.Encode(toOTLP(spans))
+}
+
+// Shutdown closes the file. Spans exported afterwards are rejected.
+func (e *FileExporter) Shutdown(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.out == nil {
+		return nil
+	}
+	err := e.out.Close()
+	e.out = nil
+	return err
+}
+
+// The types below mirror the JSON mapping of opentelemetry/proto/collector/trace/v1.
+// IDs are hex encoded and 64 bit integers are strings, as the OTLP/JSON encoding requires.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name    string `json:"name,omitempty"`
+		Version string `json:"version,omitempty"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		TraceState        string         `json:"traceState,omitempty"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Events            []otlpEvent    `json:"events,omitempty"`
+		Links             []otlpLink     `json:"links,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpEvent struct {
+		TimeUnixNano string         `json:"timeUnixNano"`
+		Name         string         `json:"name"`
+		Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpLink struct {
+		TraceID    string         `json:"traceId"`
+		SpanID     string         `json:"spanId"`
+		Attributes []otlpKeyValue `json:"attributes,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpAnyValue struct {
+		StringValue *string         `json:"stringValue,omitempty"`
+		BoolValue   *bool           `json:"boolValue,omitempty"`
+		IntValue    *string         `json:"intValue,omitempty"`
+		DoubleValue *float64        `json:"doubleValue,omitempty"`
+		ArrayValue  *otlpArrayValue `json:"arrayValue,omitempty"`
+	}
+	otlpArrayValue struct {
+		Values []otlpAnyValue `json:"values"`
+	}
+)
+
+// OTLP status codes, which differ from the numbering of go.opentelemetry.io/otel/codes
+const (
+	otlpStatusOk    = 1
+	otlpStatusError = 2
+)
+
+func toOTLP(spans []sdktrace.ReadOnlySpan) otlpRequest {
+	var (
+		req       otlpRequest
+		resources = make(map[attribute.Distinct]int)
+		scopes    = make(map[attribute.Distinct]map[otlpScope]int)
+	)
+	for _, s := range spans {
+		var (
+			res = s.Resource()
+			key attribute.Distinct
+		)
+		if res != nil {
+			key = res.Equivalent()
+		}
+		ri, ok := resources[key]
+		if !ok {
+			ri = len(req.ResourceSpans)
+			resources[key] = ri
+			scopes[key] = make(map[otlpScope]int)
+			var attrs []otlpKeyValue
+			if res != nil {
+				attrs = toKeyValues(res.Attributes())
+			}
+			req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{Resource: otlpResource{Attributes: attrs}})
+		}
+
+		lib := s.InstrumentationLibrary()
+		scope := otlpScope{Name: lib.Name, Version: lib.Version}
+		si, ok := scopes[key][scope]
+		if !ok {
+			si = len(req.ResourceSpans[ri].ScopeSpans)
+			scopes[key][scope] = si
+			req.ResourceSpans[ri].ScopeSpans = append(req.ResourceSpans[ri].ScopeSpans, otlpScopeSpans{Scope: scope})
+		}
+		req.ResourceSpans[ri].ScopeSpans[si].Spans = append(req.ResourceSpans[ri].ScopeSpans[si].Spans, toSpan(s))
+	}
+	return req
+}
+
+func toSpan(s sdktrace.ReadOnlySpan) otlpSpan {
+	sc := s.SpanContext()
+	res := otlpSpan{
+		TraceID:           sc.TraceID().String(),
+		SpanID:            sc.SpanID().String(),
+		TraceState:        sc.TraceState().String(),
+		Name:              s.Name(),
+		Kind:              int(s.SpanKind()),
+		StartTimeUnixNano: strconv.FormatInt(s.StartTime().UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.EndTime().UnixNano(), 10),
+		Attributes:        toKeyValues(s.Attributes()),
+	}
+	if p := s.Parent(); p.HasSpanID() {
+		res.ParentSpanID = p.SpanID().String()
+	}
+	for _, e := range s.Events() {
+		res.Events = append(res.Events, otlpEvent{
+			TimeUnixNano: strconv.FormatInt(e.Time.UnixNano(), 10),
+			Name:         e.Name,
+			Attributes:   toKeyValues(e.Attributes),
+		})
+	}
+	for _, l := range s.Links() {
+		res.Links = append(res.Links, otlpLink{
+			TraceID:    l.SpanContext.TraceID().String(),
+			SpanID:     l.SpanContext.SpanID().String(),
+			Attributes: toKeyValues(l.Attributes),
+		})
+	}
+	switch st := s.Status(); st.Code {
+	case codes.Ok:
+		res.Status = otlpStatus{Code: otlpStatusOk}
+	case codes.Error:
+		res.Status = otlpStatus{Code: otlpStatusError, Message: st.Description}
+	}
+	return res
+}
+
+func toKeyValues(attrs []attribute.KeyValue) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	res := make([]otlpKeyValue, 0, len(attrs))
+	for _, a := range attrs {
+		res = append(res, otlpKeyValue{Key: string(a.Key), Value: toAnyValue(a.Value)})
+	}
+	return res
+}
+
+func toAnyValue(v attribute.Value) otlpAnyValue {
+	switch v.Type() {
+	case attribute.BOOL:
+		b := v.AsBool()
+		return otlpAnyValue{BoolValue: &b}
+	case attribute.INT64:
+		i := strconv.FormatInt(v.AsInt64(), 10)
+		return otlpAnyValue{IntValue: &i}
+	case attribute.FLOAT64:
+		f := v.AsFloat64()
+		return otlpAnyValue{DoubleValue: &f}
+	case attribute.BOOLSLICE:
+		var vs []otlpAnyValue
+		for _, b := range v.AsBoolSlice() {
+			vs = append(vs, toAnyValue(attribute.BoolValue(b)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: vs}}
+	case attribute.INT64SLICE:
+		var vs []otlpAnyValue
+		for _, i := range v.AsInt64Slice() {
+			vs = append(vs, toAnyValue(attribute.Int64Value(i)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: vs}}
+	case attribute.FLOAT64SLICE:
+		var vs []otlpAnyValue
+		for _, f := range v.AsFloat64Slice() {
+			vs = append(vs, toAnyValue(attribute.Float64Value(f)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: vs}}
+	case attribute.STRINGSLICE:
+		var vs []otlpAnyValue
+		for _, s := range v.AsStringSlice() {
+			vs = append(vs, toAnyValue(attribute.StringValue(s)))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: vs}}
+	default:
+		s := v.Emit()
+		return otlpAnyValue{StringValue: &s}
+	}
+}
@@ -0,0 +1,176 @@
+package tracing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attributes set on engine spans
+const (
+	AttributeEngineName = attribute.Key("text.engine.name")
+	AttributeEngineSpec = attribute.Key("text.engine.spec")
+	AttributeOwner      = attribute.Key("text.engine.owner")
+	AttributeTrigger    = attribute.Key("text.engine.trigger")
+	AttributeSuccess    = attribute.Key("text.engine.success")
+)
+
+// Lifecycle follows engines through their phases. Each engine gets a span from its creation
+// until it is done, with a child span per phase. The engine span continues the trace recorded
+// in the engine's annotations (see Annotate), i.e. it becomes part of the trace of the RPC
+// that started the engine. All methods are safe for concurrent use.
+type Lifecycle struct {
+	tracer trace.Tracer
+
+	mu      sync.Mutex
+	engines map[string]*engineSpans
+}
+
+type engineSpans struct {
+	Ctx       context.Context
+	Engine    trace.Span
+	Phase     v1.EnginePhase
+	PhaseCtx  context.Context
+	PhaseSpan trace.Span
+}
+
+// NewLifecycle creates a new lifecycle tracer
+func NewLifecycle(tp trace.TracerProvider) *Lifecycle {
+	return &Lifecycle{
+		tracer:  Tracer(tp),
+		engines: make(map[string]*engineSpans),
+	}
+}
+
+// Observe records an engine status update. It starts the engine span when an engine is first
+// seen, starts a new phase span whenever the phase changes and ends all spans once the engine is done.
+func (l *Lifecycle) Observe(status *v1.EngineStatus) {
+	if status == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s, known := l.engines[status.Name]
+	if !known {
+		if isFinished(status.Phase) {
+			// we never saw this engine run, hence have nothing to trace
+			return
+		}
+
+		md := status.Metadata
+		opts := []trace.SpanStartOption{
+			trace.WithAttributes(
+				AttributeEngineName.String(status.Name),
+				AttributeEngineSpec.String(md.GetEngineSpecName()),
+				AttributeOwner.String(md.GetOwner()),
+				AttributeTrigger.String(formatTrigger(md.GetTrigger())),
+			),
+		}
+		if created := md.GetCreated(); created != nil {
+			opts = append(opts, trace.WithTimestamp(created.AsTime()))
+		}
+		ctx, span := l.tracer.Start(Extract(context.Background(), md), "engine", opts...)
+		s = &engineSpans{Ctx: ctx, Engine: span, Phase: v1.EnginePhase_PHASE_UNKNOWN, PhaseCtx: ctx}
+		l.engines[status.Name] = s
+	}
+
+	if status.Phase == s.Phase {
+		return
+	}
+	if s.PhaseSpan != nil {
+		s.PhaseSpan.End()
+		s.PhaseSpan, s.PhaseCtx = nil, s.Ctx
+	}
+	s.Phase = status.Phase
+
+	if !isFinished(status.Phase) {
+		s.PhaseCtx, s.PhaseSpan = l.tracer.Start(s.Ctx, "phase "+formatPhase(status.Phase))
+		return
+	}
+
+	success := status.Conditions.GetSuccess()
+	s.Engine.SetAttributes(AttributeSuccess.Bool(success))
+	if !success {
+		s.Engine.SetStatus(codes.Error, status.Details)
+	}
+	var opts []trace.SpanEndOption
+	if finished := status.Metadata.GetFinished(); finished != nil {
+		opts = append(opts, trace.WithTimestamp(finished.AsTime()))
+	}
+	s.Engine.End(opts...)
+	delete(l.engines, status.Name)
+}
+
+// Stage starts a span for a stage of the engine's lifecycle, e.g. fetching the repository or
+// scheduling the executor. The span is a child of the engine's current phase, or of the trace
+// recorded in its annotations if the engine is not followed. The caller must end the span.
+func (l *Lifecycle) Stage(status *v1.EngineStatus, stage string) (context.Context, trace.Span) {
+	l.mu.Lock()
+	s, known := l.engines[status.GetName()]
+	var ctx context.Context
+	if known {
+		ctx = s.PhaseCtx
+	}
+	l.mu.Unlock()
+
+	if ctx == nil {
+		ctx = Extract(context.Background(), status.GetMetadata())
+	}
+	return l.tracer.Start(ctx, stage, trace.WithAttributes(AttributeEngineName.String(status.GetName())))
+}
+
+// Forget ends the spans of an engine that disappeared before it was done, e.g. because it was deleted
+func (l *Lifecycle) Forget(name string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s, ok := l.engines[name]
+	if !ok {
+		return
+	}
+	if s.PhaseSpan != nil {
+		s.PhaseSpan.End()
+	}
+	s.Engine.SetStatus(codes.Error, "engine disappeared before it was done")
+	s.Engine.End()
+	delete(l.engines, name)
+}
+
+func isFinished(p v1.EnginePhase) bool {
+	return p == v1.EnginePhase_PHASE_DONE || p == v1.EnginePhase_PHASE_CLEANUP
+}
+
+func formatPhase(p v1.EnginePhase) string {
+	return strings.ToLower(strings.TrimPrefix(p.String(), "PHASE_"))
+}
+
+func formatTrigger(t v1.EngineTrigger) string {
+	return strings.ToLower(strings.TrimPrefix(t.String(), "TRIGGER_"))
+}
@@ -0,0 +1,211 @@
+package tracing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package tracing provides OpenTelemetry tracing for the Bhojpur Text server. It traces
+// RPCs through gRPC interceptors, follows each engine through its lifecycle and carries the
+// trace into the executor environment, so that API calls, repository fetches, scheduling
+// and execution of an engine end up in a single trace.
+
+import (
+	"context"
+	"strings"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.7.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// AnnotationTraceID records the ID of the trace an engine belongs to, e.g. to look it up in a tracing backend
+	AnnotationTraceID = "text.bhojpur.net/trace-id"
+
+	// AnnotationTraceParent records the W3C traceparent of the span that started an engine
+	AnnotationTraceParent = "text.bhojpur.net/traceparent"
+
+	// EnvTraceParent is the environment variable executors read the W3C traceparent from
+	EnvTraceParent = "TRACEPARENT"
+
+	// EnvTraceID is the environment variable executors read the trace ID from
+	EnvTraceID = "TEXT_TRACE_ID"
+)
+
+const instrumentationName = "github.com/bhojpur/text/pkg/tracing"
+
+var propagator = propagation.TraceContext{}
+
+// NewProvider creates a tracer provider that batches spans into the exporter. The service
+// name identifies the process in the exported resource.
+func NewProvider(exporter sdktrace.SpanExporter, serviceName string) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+}
+
+// Tracer returns the tracer all spans of the server are created with
+func Tracer(tp trace.TracerProvider) trace.Tracer {
+	return tp.Tracer(instrumentationName)
+}
+
+// Annotate stores the trace ID and traceparent of the span in ctx as annotations of the engine
+// metadata. Existing trace annotations are replaced. Annotate does nothing if ctx carries no span.
+func Annotate(ctx context.Context, md *v1.EngineMetadata) {
+	sc := trace.SpanContextFromContext(ctx)
+	if md == nil || !sc.IsValid() {
+		return
+	}
+
+	carrier := propagation.MapCarrier{}
+	propagator.Inject(ctx, carrier)
+
+	annotations := make([]*v1.Annotation, 0, len(md.Annotations)+2)
+	for _, a := range md.Annotations {
+		if a.Key == AnnotationTraceID || a.Key == AnnotationTraceParent {
+			continue
+		}
+		annotations = append(annotations, a)
+	}
+	md.Annotations = append(annotations,
+		&v1.Annotation{Key: AnnotationTraceID, Value: sc.TraceID().String()},
+		&v1.Annotation{Key: AnnotationTraceParent, Value: carrier.Get("traceparent")},
+	)
+}
+
+// Extract returns a context that continues the trace recorded in the engine's annotations.
+// If the engine has no trace annotations ctx is returned unchanged.
+func Extract(ctx context.Context, md *v1.EngineMetadata) context.Context {
+	tp := annotation(md, AnnotationTraceParent)
+	if tp == "" {
+		return ctx
+	}
+	return propagator.Extract(ctx, propagation.MapCarrier{"traceparent": tp})
+}
+
+// Environment returns the environment variables that propagate the engine's trace into its
+// executor, in KEY=value form. Executors that use OpenTelemetry pick up TRACEPARENT directly.
+func Environment(md *v1.EngineMetadata) []string {
+	var res []string
+	if tp := annotation(md, AnnotationTraceParent); tp != "" {
+		res = append(res, EnvTraceParent+"="+tp)
+	}
+	if id := annotation(md, AnnotationTraceID); id != "" {
+		res = append(res, EnvTraceID+"="+id)
+	}
+	return res
+}
+
+func annotation(md *v1.EngineMetadata, key string) string {
+	for _, a := range md.GetAnnotations() {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// UnaryServerInterceptor starts a server span for each unary RPC. Incoming W3C trace context
+// in the request metadata becomes the parent of the span.
+func UnaryServerInterceptor(tp trace.TracerProvider) grpc.UnaryServerInterceptor {
+	tracer := Tracer(tp)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startRPC(ctx, tracer, info.FullMethod)
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+		endRPC(span, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor starts a server span for each streaming RPC, which lasts until the stream is closed
+func StreamServerInterceptor(tp trace.TracerProvider) grpc.StreamServerInterceptor {
+	tracer := Tracer(tp)
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startRPC(ss.Context(), tracer, info.FullMethod)
+		defer span.End()
+
+		err := handler(srv, &tracedStream{ServerStream: ss, ctx: ctx})
+		endRPC(span, err)
+		return err
+	}
+}
+
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedStream) Context() context.Context {
+	return s.ctx
+}
+
+func startRPC(ctx context.Context, tracer trace.Tracer, fullMethod string) (context.Context, trace.Span) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = propagator.Extract(ctx, metadataCarrier(md))
+	}
+
+	name := strings.TrimPrefix(fullMethod, "/")
+	attrs := []attribute.KeyValue{semconv.RPCSystemKey.String("grpc")}
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		attrs = append(attrs, semconv.RPCServiceKey.String(name[:i]), semconv.RPCMethodKey.String(name[i+1:]))
+	}
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attrs...))
+}
+
+func endRPC(span trace.Span, err error) {
+	s, _ := status.FromError(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int64(int64(s.Code())))
+	if err != nil {
+		span.SetStatus(codes.Error, s.Message())
+	}
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	vs := metadata.MD(c).Get(key)
+	if len(vs) == 0 {
+		return ""
+	}
+	return vs[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	res := make([]string, 0, len(c))
+	for k := range c {
+		res = append(res, k)
+	}
+	return res
+}
@@ -0,0 +1,199 @@
+package tracing
+
+// Copyright (c) 2018 Bhojpur Consulting Private Limited, India. All rights reserved.
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/bhojpur/text/pkg/api/v1"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func newTestProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	rec := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)), rec
+}
+
+func TestInterceptorContinuesIncomingTrace(t *testing.T) {
+	tp, rec := newTestProvider()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("traceparent", testTraceParent))
+	info := &grpc.UnaryServerInfo{FullMethod: "/v1.TextService/StartEngine"}
+	md := &v1.EngineMetadata{Annotations: []*v1.Annotation{{Key: "lang", Value: "en"}}}
+	_, err := UnaryServerInterceptor(tp)(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		Annotate(ctx, md)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "v1.TextService/StartEngine" {
+		t.Errorf("unexpected span name %q", span.Name())
+	}
+	if act := span.SpanContext().TraceID().String(); act != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("span did not continue incoming trace: %s", act)
+	}
+	if act := span.Parent().SpanID().String(); act != "00f067aa0ba902b7" {
+		t.Errorf("unexpected parent span %s", act)
+	}
+
+	if act := annotation(md, AnnotationTraceID); act != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("unexpected trace ID annotation %q", act)
+	}
+	if act := annotation(md, AnnotationTraceParent); !strings.Contains(act, span.SpanContext().SpanID().String()) {
+		t.Errorf("traceparent annotation %q does not point to the RPC span", act)
+	}
+	if len(md.Annotations) != 3 {
+		t.Errorf("expected existing annotations to be kept, got %v", md.Annotations)
+	}
+
+	env := Environment(md)
+	if len(env) != 2 || env[0] != EnvTraceParent+"="+annotation(md, AnnotationTraceParent) || env[1] != EnvTraceID+"=4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("unexpected environment %v", env)
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	tp, rec := newTestProvider()
+	l := NewLifecycle(tp)
+
+	md := &v1.EngineMetadata{
+		EngineSpecName: "index",
+		Annotations:    []*v1.Annotation{{Key: AnnotationTraceParent, Value: testTraceParent}},
+	}
+	status := func(phase v1.EnginePhase, success bool) *v1.EngineStatus {
+		return &v1.EngineStatus{Name: "index.1", Metadata: md, Phase: phase, Conditions: &v1.EngineConditions{Success: success}, Details: "exit code 1"}
+	}
+
+	l.Observe(status(v1.EnginePhase_PHASE_PREPARING, false))
+	_, fetch := l.Stage(status(v1.EnginePhase_PHASE_PREPARING, false), "fetch repository")
+	fetch.End()
+	l.Observe(status(v1.EnginePhase_PHASE_PREPARING, false))
+	l.Observe(status(v1.EnginePhase_PHASE_RUNNING, false))
+	l.Observe(status(v1.EnginePhase_PHASE_DONE, false))
+	l.Observe(status(v1.EnginePhase_PHASE_CLEANUP, false))
+
+	var names []string
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, s := range rec.Ended() {
+		names = append(names, s.Name())
+		spans[s.Name()] = s
+	}
+	if act, exp := strings.Join(names, ","), "fetch repository,phase preparing,phase running,engine"; act != exp {
+		t.Fatalf("unexpected spans %s, expected %s", act, exp)
+	}
+
+	engine := spans["engine"]
+	if act := engine.SpanContext().TraceID().String(); act != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("engine span did not continue the annotated trace: %s", act)
+	}
+	if engine.Status().Code != codes.Error || engine.Status().Description != "exit code 1" {
+		t.Errorf("unexpected engine span status %v", engine.Status())
+	}
+	if spans["phase running"].Parent().SpanID() != engine.SpanContext().SpanID() {
+		t.Error("phase span is not a child of the engine span")
+	}
+	if spans["fetch repository"].Parent().SpanID() != spans["phase preparing"].SpanContext().SpanID() {
+		t.Error("stage span is not a child of the phase span")
+	}
+}
+
+func TestLifecycleIgnoresFinishedEngines(t *testing.T) {
+	tp, rec := newTestProvider()
+	l := NewLifecycle(tp)
+	l.Observe(&v1.EngineStatus{Name: "old", Phase: v1.EnginePhase_PHASE_DONE})
+	l.Forget("old")
+	if len(rec.Started()) != 0 {
+		t.Errorf("expected no spans, got %d", len(rec.Started()))
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "traces.jsonl")
+	exp, err := NewFileExporter(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+
+	ctx, parent := Tracer(tp).Start(context.Background(), "parent")
+	_, child := Tracer(tp).Start(ctx, "child")
+	child.SetStatus(codes.Error, "failed")
+	child.End()
+	parent.End()
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var spans []otlpSpan
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var req otlpRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			t.Fatal(err)
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				if ss.Scope.Name != instrumentationName {
+					t.Errorf("unexpected scope %q", ss.Scope.Name)
+				}
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	if len(spans) != 2 {
+		t.Fatalf("expected two spans, got %d", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.Name != "child" || p.Name != "parent" {
+		t.Fatalf("unexpected spans %s, %s", c.Name, p.Name)
+	}
+	if len(c.TraceID) != 32 || c.TraceID != p.TraceID || c.ParentSpanID != p.SpanID {
+		t.Errorf("IDs are not hex encoded or not linked: %+v %+v", c, p)
+	}
+	if c.Status.Code != otlpStatusError || c.Status.Message != "failed" {
+		t.Errorf("unexpected status %+v", c.Status)
+	}
+}